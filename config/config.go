@@ -3,9 +3,43 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// Policies for JSON-RPC batches reusing the same id in several requests.
+const (
+	DuplicateIDsReject   = "reject"
+	DuplicateIDsAnnotate = "annotate"
+)
+
+// Policies for StoreTransaction when MAX_STORED_TX transactions are already waiting.
+const (
+	QueueFullReject      = "reject"
+	QueueFullEvictOldest = "evict_oldest"
+)
+
+// Policies for speed-ups whose fee cap is below the current base fee.
+const (
+	UnderpricedIgnore = "ignore"
+	UnderpricedWarn   = "warn"
+	UnderpricedReject = "reject"
+)
+
+// Policies for the status change subscribers that don't keep up with the events.
+const (
+	EventOverflowDrop       = "drop"
+	EventOverflowDisconnect = "disconnect"
+)
+
+// Version is the version of the server, reported in the default User-Agent of the upstream requests.
+// Releases set it at build time with -ldflags "-X github.com/safwentrabelsi/tx-json-rpc-server/config.Version=1.2.3".
+var Version = "dev"
+
 // Config is a struct representing the application's configuration.
 type Config struct {
 	infuraKey  string
@@ -13,17 +47,89 @@ type Config struct {
 	url        string
 	addr       string
 	logLevel   string
+	proxyRetries      int
+	proxyRetryBackoff time.Duration
+	rpcRetries        int
+	rpcRetryBackoff   time.Duration
+	upstreamRPS       float64
+	upstreamBurst     int
+	upstreamRateLimitRetries  int
+	upstreamRateLimitMaxDelay time.Duration
+	batchDuplicateIDs string
+	allowUnprotectedTx bool
+	maxNonceGap        int
+	shutdownTimeout    time.Duration
+	queuedGasInfo      bool
+	maxStoredTx        int
+	queueFullPolicy    string
+	underpricedReplacement string
+	eventBufferSize        int
+	eventOverflowPolicy    string
+	broadcastErrorGrace    int
+	gasFetchTimeout        time.Duration
+	idempotencyTTL         time.Duration
+	idempotencyMaxKeys     int
+	proxyEnabled           bool
+	senderRPS              float64
+	senderBurst            int
+	gasMonitoringInterval  time.Duration
+	batchConcurrency       int
+	rejectZeroTip          bool
+	broadcastLogFile       string
+	broadcastLogMaxEntries int
+	retryAfterHeader       bool
+	clockSkewTolerance     time.Duration
+	adminAPIKey            string
+	devMode                bool
+	maxTxDataBytes         int
+	broadcastOnStore       bool
+	checkBalance           bool
+	snapshotInterval       time.Duration
+	readOnly               bool
+	// The CORS lists are kept comma separated so that Config stays comparable.
+	corsAllowedOrigins   string
+	corsAllowedMethods   string
+	corsAllowedHeaders   string
+	corsAllowCredentials bool
+	allowedChainIDs      string
+	validateProxyResponses bool
+	broadcastConfirmTicks  int
+	gasHistorySize         int
+	broadcastURLs          string
+	userAgent              string
+	baseFeeAware           bool
+	attemptHistorySize     int
+	compactStore           bool
+	maxGasPriceGwei        float64
+	methodRateLimits       string
+	txTTL                  time.Duration
+	maxDeadline            time.Duration
 }
 
-var	cfg Config
+var (
+	cfg Config
+	// cfgMutex guards cfg, which a reload may update while the server reads it.
+	cfgMutex sync.RWMutex
+)
 
-// LoadConfig loads configuration settings from environment variables.
-func LoadConfig() error {
+// ReadConfig reads the configuration settings from environment variables, without changing the loaded Config.
+func ReadConfig() (Config, error) {
 	network := os.Getenv("NETWORK")
-	infuraKey := os.Getenv("INFURA_PROJECT_ID")
+	infuraKey, err := getEnvOrFile("INFURA_PROJECT_ID")
+	if err != nil {
+		return Config{}, err
+	}
 
-	if network == "" || infuraKey == "" {
-		return errors.New("NETWORK and INFURA_PROJECT_ID must be set")
+	// RPC_URL points at any provider, e.g. Alchemy or a local node, instead of Infura.
+	// It may embed an API key, so it's never echoed in the errors.
+	rpcURL := os.Getenv("RPC_URL")
+	if rpcURL != "" {
+		u, err := url.Parse(rpcURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, errors.New("invalid RPC_URL: not an http or https URL")
+		}
+	} else if network == "" || infuraKey == "" {
+		return Config{}, errors.New("NETWORK and INFURA_PROJECT_ID must be set")
 	}
 
 	logLevel := os.Getenv("LOG_LEVEL")
@@ -41,25 +147,625 @@ func LoadConfig() error {
 		port = "8080" 
 	}
 
+	proxyRetries, err := getEnvInt("PROXY_RETRIES", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if proxyRetries < 0 {
+		return Config{}, errors.New("PROXY_RETRIES must not be negative")
+	}
+
+	proxyRetryBackoff, err := getEnvDuration("PROXY_RETRY_BACKOFF", 200*time.Millisecond)
+	if err != nil {
+		return Config{}, err
+	}
+
+	rpcRetries, err := getEnvInt("RPC_RETRIES", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if rpcRetries < 0 {
+		return Config{}, errors.New("RPC_RETRIES must not be negative")
+	}
+
+	rpcRetryBackoff, err := getEnvDuration("RPC_RETRY_BACKOFF", 500*time.Millisecond)
+	if err != nil {
+		return Config{}, err
+	}
+
+	upstreamRPS, err := getEnvFloat("UPSTREAM_RPS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if upstreamRPS < 0 {
+		return Config{}, errors.New("UPSTREAM_RPS must not be negative")
+	}
+
+	upstreamBurst, err := getEnvInt("UPSTREAM_BURST", 1)
+	if err != nil {
+		return Config{}, err
+	}
+	if upstreamBurst < 1 {
+		return Config{}, errors.New("UPSTREAM_BURST must be at least 1")
+	}
+
+	upstreamRateLimitRetries, err := getEnvInt("UPSTREAM_RATE_LIMIT_RETRIES", 3)
+	if err != nil {
+		return Config{}, err
+	}
+	if upstreamRateLimitRetries < 0 {
+		return Config{}, errors.New("UPSTREAM_RATE_LIMIT_RETRIES must not be negative")
+	}
+
+	upstreamRateLimitMaxDelay, err := getEnvDuration("UPSTREAM_RATE_LIMIT_MAX_DELAY", 5*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	if upstreamRateLimitMaxDelay < 0 {
+		return Config{}, errors.New("UPSTREAM_RATE_LIMIT_MAX_DELAY must not be negative")
+	}
+
+	batchDuplicateIDs := os.Getenv("BATCH_DUPLICATE_IDS")
+	if batchDuplicateIDs == "" {
+		batchDuplicateIDs = DuplicateIDsReject
+	}
+	if batchDuplicateIDs != DuplicateIDsReject && batchDuplicateIDs != DuplicateIDsAnnotate {
+		return Config{}, fmt.Errorf("BATCH_DUPLICATE_IDS must be %q or %q", DuplicateIDsReject, DuplicateIDsAnnotate)
+	}
+
+	allowUnprotectedTx, err := getEnvBool("ALLOW_UNPROTECTED_TX", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxNonceGap, err := getEnvInt("MAX_NONCE_GAP", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxNonceGap < 0 {
+		return Config{}, errors.New("MAX_NONCE_GAP must not be negative")
+	}
+
+	shutdownTimeout, err := getEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	if shutdownTimeout <= 0 {
+		return Config{}, errors.New("SHUTDOWN_TIMEOUT must be positive")
+	}
+
+	queuedGasInfo, err := getEnvBool("QUEUED_GAS_INFO", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxStoredTx, err := getEnvInt("MAX_STORED_TX", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxStoredTx < 0 {
+		return Config{}, errors.New("MAX_STORED_TX must not be negative")
+	}
+
+	queueFullPolicy := os.Getenv("QUEUE_FULL_POLICY")
+	if queueFullPolicy == "" {
+		queueFullPolicy = QueueFullReject
+	}
+	if queueFullPolicy != QueueFullReject && queueFullPolicy != QueueFullEvictOldest {
+		return Config{}, fmt.Errorf("QUEUE_FULL_POLICY must be %q or %q", QueueFullReject, QueueFullEvictOldest)
+	}
+
+	underpricedReplacement := os.Getenv("UNDERPRICED_REPLACEMENT")
+	if underpricedReplacement == "" {
+		underpricedReplacement = UnderpricedIgnore
+	}
+	if underpricedReplacement != UnderpricedIgnore && underpricedReplacement != UnderpricedWarn && underpricedReplacement != UnderpricedReject {
+		return Config{}, fmt.Errorf("UNDERPRICED_REPLACEMENT must be %q, %q or %q", UnderpricedIgnore, UnderpricedWarn, UnderpricedReject)
+	}
+
+	eventBufferSize, err := getEnvInt("EVENT_BUFFER_SIZE", 64)
+	if err != nil {
+		return Config{}, err
+	}
+	if eventBufferSize < 1 {
+		return Config{}, errors.New("EVENT_BUFFER_SIZE must be at least 1")
+	}
+
+	eventOverflowPolicy := os.Getenv("EVENT_OVERFLOW_POLICY")
+	if eventOverflowPolicy == "" {
+		eventOverflowPolicy = EventOverflowDrop
+	}
+	if eventOverflowPolicy != EventOverflowDrop && eventOverflowPolicy != EventOverflowDisconnect {
+		return Config{}, fmt.Errorf("EVENT_OVERFLOW_POLICY must be %q or %q", EventOverflowDrop, EventOverflowDisconnect)
+	}
+
+	broadcastErrorGrace, err := getEnvInt("BROADCAST_ERROR_GRACE", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if broadcastErrorGrace < 0 {
+		return Config{}, errors.New("BROADCAST_ERROR_GRACE must not be negative")
+	}
+
+	gasFetchTimeoutKey := networkKey("GAS_FETCH_TIMEOUT", network)
+	gasFetchTimeout, err := getEnvDuration(gasFetchTimeoutKey, 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if gasFetchTimeout < 0 {
+		return Config{}, fmt.Errorf("%s must not be negative", gasFetchTimeoutKey)
+	}
+
+	idempotencyTTL, err := getEnvDuration("IDEMPOTENCY_TTL", 10*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	if idempotencyTTL <= 0 {
+		return Config{}, errors.New("IDEMPOTENCY_TTL must be positive")
+	}
+
+	idempotencyMaxKeys, err := getEnvInt("IDEMPOTENCY_MAX_KEYS", 10000)
+	if err != nil {
+		return Config{}, err
+	}
+	if idempotencyMaxKeys < 0 {
+		return Config{}, errors.New("IDEMPOTENCY_MAX_KEYS must not be negative")
+	}
+
+	proxyEnabled, err := getEnvBool("PROXY_ENABLED", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	senderRPS, err := getEnvFloat("SENDER_RPS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if senderRPS < 0 {
+		return Config{}, errors.New("SENDER_RPS must not be negative")
+	}
+
+	senderBurst, err := getEnvInt("SENDER_BURST", 1)
+	if err != nil {
+		return Config{}, err
+	}
+	if senderBurst < 1 {
+		return Config{}, errors.New("SENDER_BURST must be at least 1")
+	}
+
+	gasMonitoringIntervalKey := networkKey("GAS_MONITORING_INTERVAL", network)
+	gasMonitoringInterval, err := getEnvDuration(gasMonitoringIntervalKey, 5*time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	if gasMonitoringInterval <= 0 {
+		return Config{}, fmt.Errorf("%s must be positive", gasMonitoringIntervalKey)
+	}
+
+	batchConcurrency, err := getEnvInt("BATCH_CONCURRENCY", 1)
+	if err != nil {
+		return Config{}, err
+	}
+	if batchConcurrency < 1 {
+		return Config{}, errors.New("BATCH_CONCURRENCY must be at least 1")
+	}
+
+	rejectZeroTip, err := getEnvBool("REJECT_ZERO_TIP", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	broadcastLogMaxEntries, err := getEnvInt("BROADCAST_LOG_MAX_ENTRIES", 100000)
+	if err != nil {
+		return Config{}, err
+	}
+	if broadcastLogMaxEntries < 1 {
+		return Config{}, errors.New("BROADCAST_LOG_MAX_ENTRIES must be at least 1")
+	}
+
+	retryAfterHeader, err := getEnvBool("RETRY_AFTER_HEADER", true)
+	if err != nil {
+		return Config{}, err
+	}
+
+	clockSkewTolerance, err := getEnvDuration("CLOCK_SKEW_TOLERANCE", time.Second)
+	if err != nil {
+		return Config{}, err
+	}
+	if clockSkewTolerance < 0 {
+		return Config{}, errors.New("CLOCK_SKEW_TOLERANCE must not be negative")
+	}
+
+	adminAPIKey, err := getEnvOrFile("ADMIN_API_KEY")
+	if err != nil {
+		return Config{}, err
+	}
+
+	devMode, err := getEnvBool("DEV_MODE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxTxDataBytes, err := getEnvInt("MAX_TX_DATA_BYTES", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxTxDataBytes < 0 {
+		return Config{}, errors.New("MAX_TX_DATA_BYTES must not be negative")
+	}
+
+	broadcastOnStore, err := getEnvBool("BROADCAST_ON_STORE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	checkBalance, err := getEnvBool("CHECK_BALANCE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	snapshotInterval, err := getEnvDuration("SNAPSHOT_INTERVAL", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if snapshotInterval < 0 {
+		return Config{}, errors.New("SNAPSHOT_INTERVAL must not be negative")
+	}
+
+	readOnly, err := getEnvBool("READ_ONLY", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	corsAllowedOrigins := getEnvList("CORS_ALLOWED_ORIGINS", "")
+	corsAllowedMethods := getEnvList("CORS_ALLOWED_METHODS", "GET,POST,OPTIONS")
+	corsAllowedHeaders := getEnvList("CORS_ALLOWED_HEADERS", "Content-Type")
+	corsAllowCredentials, err := getEnvBool("CORS_ALLOW_CREDENTIALS", false)
+	if err != nil {
+		return Config{}, err
+	}
+	// Browsers refuse credentialed responses allowing any origin, and take the wildcards literally in the other lists.
+	if corsAllowCredentials {
+		lists := []struct{ key, value string }{
+			{"CORS_ALLOWED_ORIGINS", corsAllowedOrigins},
+			{"CORS_ALLOWED_METHODS", corsAllowedMethods},
+			{"CORS_ALLOWED_HEADERS", corsAllowedHeaders},
+		}
+		for _, list := range lists {
+			if containsWildcard(list.value) {
+				return Config{}, fmt.Errorf("%s can't contain a wildcard when CORS_ALLOW_CREDENTIALS is set", list.key)
+			}
+		}
+	}
+
+	allowedChainIDs := getEnvList("ALLOWED_CHAIN_IDS", "")
+	for _, id := range splitList(allowedChainIDs) {
+		_, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid ALLOWED_CHAIN_IDS: %w", err)
+		}
+	}
+
+	validateProxyResponses, err := getEnvBool("VALIDATE_PROXY_RESPONSES", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	broadcastConfirmTicks, err := getEnvInt("BROADCAST_CONFIRM_TICKS", 1)
+	if err != nil {
+		return Config{}, err
+	}
+	if broadcastConfirmTicks < 1 {
+		return Config{}, errors.New("BROADCAST_CONFIRM_TICKS must be at least 1")
+	}
+
+	gasHistorySize, err := getEnvInt("GAS_HISTORY_SIZE", 100)
+	if err != nil {
+		return Config{}, err
+	}
+	if gasHistorySize < 0 {
+		return Config{}, errors.New("GAS_HISTORY_SIZE must not be negative")
+	}
+
+	// The URLs may embed API keys, so they're not echoed in the errors.
+	broadcastURLs := getEnvList("BROADCAST_URLS", "")
+	for i, broadcastURL := range splitList(broadcastURLs) {
+		u, err := url.Parse(broadcastURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return Config{}, fmt.Errorf("invalid BROADCAST_URLS: URL %d is not an http or https URL", i+1)
+		}
+	}
+
+	baseFeeAware, err := getEnvBool("BASE_FEE_AWARE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	attemptHistorySize, err := getEnvInt("ATTEMPT_HISTORY_SIZE", 10)
+	if err != nil {
+		return Config{}, err
+	}
+	if attemptHistorySize < 0 {
+		return Config{}, errors.New("ATTEMPT_HISTORY_SIZE must not be negative")
+	}
+
+	compactStore, err := getEnvBool("COMPACT_STORE", false)
+	if err != nil {
+		return Config{}, err
+	}
+
+	maxGasPriceGwei, err := getEnvFloat("MAX_GAS_PRICE_GWEI", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxGasPriceGwei < 0 {
+		return Config{}, errors.New("MAX_GAS_PRICE_GWEI must not be negative")
+	}
+
+	methodRateLimits := getEnvList("METHOD_RATE_LIMITS", "")
+	_, err = parseMethodRateLimits(methodRateLimits)
+	if err != nil {
+		return Config{}, err
+	}
+
+	txTTL, err := getEnvDuration("TX_TTL", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if txTTL < 0 {
+		return Config{}, errors.New("TX_TTL must not be negative")
+	}
+
+	maxDeadline, err := getEnvDuration("MAX_DEADLINE", 30*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxDeadline < 0 {
+		return Config{}, errors.New("MAX_DEADLINE must not be negative")
+	}
+
+	userAgent := os.Getenv("USER_AGENT")
+	if userAgent == "" {
+		userAgent = "tx-json-rpc-server/" + Version
+	}
+
 	addr := fmt.Sprintf("%s:%s", host, port)
 	baseURL := fmt.Sprintf("https://%s.infura.io/v3/%s", network, infuraKey)
+	if rpcURL != "" {
+		baseURL = rpcURL
+	}
 
-	cfg = Config{
+	return Config{
 		network:   network,
 		infuraKey: infuraKey,
 		url:       baseURL,
 		addr: 	   addr,
 		logLevel:  logLevel,
-	}
+		proxyRetries:      proxyRetries,
+		proxyRetryBackoff: proxyRetryBackoff,
+		rpcRetries:        rpcRetries,
+		rpcRetryBackoff:   rpcRetryBackoff,
+		upstreamRPS:       upstreamRPS,
+		upstreamBurst:     upstreamBurst,
+		upstreamRateLimitRetries:  upstreamRateLimitRetries,
+		upstreamRateLimitMaxDelay: upstreamRateLimitMaxDelay,
+		batchDuplicateIDs: batchDuplicateIDs,
+		allowUnprotectedTx: allowUnprotectedTx,
+		maxNonceGap:        maxNonceGap,
+		shutdownTimeout:    shutdownTimeout,
+		queuedGasInfo:      queuedGasInfo,
+		maxStoredTx:        maxStoredTx,
+		queueFullPolicy:    queueFullPolicy,
+		underpricedReplacement: underpricedReplacement,
+		eventBufferSize:        eventBufferSize,
+		eventOverflowPolicy:    eventOverflowPolicy,
+		broadcastErrorGrace:    broadcastErrorGrace,
+		gasFetchTimeout:        gasFetchTimeout,
+		idempotencyTTL:         idempotencyTTL,
+		idempotencyMaxKeys:     idempotencyMaxKeys,
+		proxyEnabled:           proxyEnabled,
+		senderRPS:              senderRPS,
+		senderBurst:            senderBurst,
+		gasMonitoringInterval:  gasMonitoringInterval,
+		batchConcurrency:       batchConcurrency,
+		rejectZeroTip:          rejectZeroTip,
+		broadcastLogFile:       os.Getenv("BROADCAST_LOG_FILE"),
+		broadcastLogMaxEntries: broadcastLogMaxEntries,
+		retryAfterHeader:       retryAfterHeader,
+		clockSkewTolerance:     clockSkewTolerance,
+		adminAPIKey:            adminAPIKey,
+		devMode:                devMode,
+		maxTxDataBytes:         maxTxDataBytes,
+		broadcastOnStore:       broadcastOnStore,
+		checkBalance:           checkBalance,
+		snapshotInterval:       snapshotInterval,
+		readOnly:               readOnly,
+		corsAllowedOrigins:     corsAllowedOrigins,
+		corsAllowedMethods:     corsAllowedMethods,
+		corsAllowedHeaders:     corsAllowedHeaders,
+		corsAllowCredentials:   corsAllowCredentials,
+		allowedChainIDs:        allowedChainIDs,
+		validateProxyResponses: validateProxyResponses,
+		broadcastConfirmTicks:  broadcastConfirmTicks,
+		gasHistorySize:         gasHistorySize,
+		broadcastURLs:          broadcastURLs,
+		userAgent:              userAgent,
+		baseFeeAware:           baseFeeAware,
+		attemptHistorySize:     attemptHistorySize,
+		compactStore:           compactStore,
+		maxGasPriceGwei:        maxGasPriceGwei,
+		methodRateLimits:       methodRateLimits,
+		txTTL:                  txTTL,
+		maxDeadline:            maxDeadline,
+	}, nil
+}
 
+// LoadConfig loads configuration settings from environment variables, the loaded Config being kept when they're invalid.
+func LoadConfig() error {
+	loaded, err := ReadConfig()
+	if err != nil {
+		return err
+	}
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+	cfg = loaded
 	return nil
 }
 
+// getEnvOrFile returns the value of an environment variable, or the content of the file named by its _FILE variant (e.g. a Docker secret) which takes precedence.
+func getEnvOrFile(key string) (string, error) {
+	path := os.Getenv(key + "_FILE")
+	if path == "" {
+		return os.Getenv(key), nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s_FILE: %w", key, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// getEnvInt returns the integer value of an environment variable, or the default value when it's not set.
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return i, nil
+}
+
+// getEnvFloat returns the float value of an environment variable, or the default value when it's not set.
+func getEnvFloat(key string, defaultValue float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return f, nil
+}
+
+// getEnvList returns the comma separated list of an environment variable with its items trimmed and the empty ones dropped,
+// or the default value when it's not set.
+func getEnvList(key string, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// splitList returns the items of a list normalized by getEnvList, nil when it's empty.
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	return strings.Split(list, ",")
+}
+
+// parseMethodRateLimits parses a list of method=rps pairs normalized by getEnvList, e.g. "eth_getLogs=2,eth_call=10".
+func parseMethodRateLimits(list string) (map[string]float64, error) {
+	limits := map[string]float64{}
+	for _, pair := range splitList(list) {
+		method, value, ok := strings.Cut(pair, "=")
+		method = strings.TrimSpace(method)
+		if !ok || method == "" {
+			return nil, fmt.Errorf("invalid METHOD_RATE_LIMITS: %q is not a method=rps pair", pair)
+		}
+		rps, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || rps <= 0 {
+			return nil, fmt.Errorf("invalid METHOD_RATE_LIMITS: the rate of %s must be a positive number", method)
+		}
+		limits[method] = rps
+	}
+	return limits, nil
+}
+
+// containsWildcard reports whether a list normalized by getEnvList contains "*".
+func containsWildcard(list string) bool {
+	for _, item := range splitList(list) {
+		if item == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// getEnvBool returns the boolean value (e.g. "true", "1") of an environment variable, or the default value when it's not set.
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return b, nil
+}
+
+// getEnvDuration returns the duration value (e.g. "500ms") of an environment variable, or the default value when it's not set.
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
+
+// networkKey returns the network specific variant of a setting, e.g. GAS_MONITORING_INTERVAL_ARBITRUM_SEPOLIA, when it's set and the setting itself otherwise.
+// It lets a single environment carry the gas tuning of every network the server may run on.
+func networkKey(key, network string) string {
+	suffix := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(network))
+	if _, ok := os.LookupEnv(key + "_" + suffix); ok {
+		return key + "_" + suffix
+	}
+	return key
+}
+
+// RestartRequired reports whether next differs from c in other settings than the ones applied on reload, LOG_LEVEL and
+// GAS_MONITORING_INTERVAL, the only ones read again by their users: the logger and the gas monitor on its next tick.
+// Every other setting, the rate limits, MAX_GAS_PRICE_GWEI and ADMIN_API_KEY included, is copied on start into the client,
+// the limiters or the RPC server, which read it without locking, so swapping it at runtime would race with the requests.
+func (c Config) RestartRequired(next Config) bool {
+	next.logLevel = c.logLevel
+	next.gasMonitoringInterval = c.gasMonitoringInterval
+	return next != c
+}
+
 // GetConfig returns the loaded Config instance.
 func GetConfig() Config {
+	cfgMutex.RLock()
+	defer cfgMutex.RUnlock()
 	return cfg
 }
 
+// SetReloadable updates the settings of the loaded Config that are applied on reload, the log level and the gas monitoring
+// interval. The other settings only take effect after a restart, see RestartRequired.
+func SetReloadable(logLevel string, gasMonitoringInterval time.Duration) {
+	cfgMutex.Lock()
+	defer cfgMutex.Unlock()
+	cfg.logLevel = logLevel
+	cfg.gasMonitoringInterval = gasMonitoringInterval
+}
+
 // Network returns the Ethereum network for the configuration.
 func (c Config) Network() string {
 	return c.network
@@ -85,3 +791,289 @@ func (c Config) LogLevel() string {
 	return c.logLevel
 }
 
+// ProxyRetries returns how many times a failed proxied read-only request is retried.
+func (c Config) ProxyRetries() int {
+	return c.proxyRetries
+}
+
+// ProxyRetryBackoff returns the base delay between two attempts of a proxied read-only request.
+func (c Config) ProxyRetryBackoff() time.Duration {
+	return c.proxyRetryBackoff
+}
+
+// RPCRetries returns how many times a failed internal call to the node (gas price fetch, broadcast) is retried.
+func (c Config) RPCRetries() int {
+	return c.rpcRetries
+}
+
+// RPCRetryBackoff returns the base delay between two attempts of an internal call to the node.
+func (c Config) RPCRetryBackoff() time.Duration {
+	return c.rpcRetryBackoff
+}
+
+// UpstreamRPS returns the maximum rate of requests per second sent to the node, 0 meaning unlimited.
+func (c Config) UpstreamRPS() float64 {
+	return c.upstreamRPS
+}
+
+// UpstreamBurst returns how many requests can be sent to the node at once before being paced by UpstreamRPS.
+func (c Config) UpstreamBurst() int {
+	return c.upstreamBurst
+}
+
+// BatchDuplicateIDs returns how batches reusing an id are handled: rejected or processed with annotated responses.
+func (c Config) BatchDuplicateIDs() string {
+	return c.batchDuplicateIDs
+}
+
+// AllowUnprotectedTx returns whether legacy transactions without EIP-155 replay protection are accepted.
+func (c Config) AllowUnprotectedTx() bool {
+	return c.allowUnprotectedTx
+}
+
+// MaxNonceGap returns how far ahead of the sender's next expected nonce a transaction can be, 0 meaning unchecked.
+func (c Config) MaxNonceGap() int {
+	return c.maxNonceGap
+}
+
+// ShutdownTimeout returns the maximum time given to the gas monitor and the final flush, if any, on shutdown.
+func (c Config) ShutdownTimeout() time.Duration {
+	return c.shutdownTimeout
+}
+
+// QueuedGasInfo returns whether eth_sendRawTransaction answers with the gas price and cap of a queued transaction instead of its hash only.
+func (c Config) QueuedGasInfo() bool {
+	return c.queuedGasInfo
+}
+
+// MaxStoredTx returns the maximum number of transactions waiting for broadcast, 0 meaning unlimited.
+func (c Config) MaxStoredTx() int {
+	return c.maxStoredTx
+}
+
+// QueueFullPolicy returns how new transactions are handled once MaxStoredTx is reached, either QueueFullReject or QueueFullEvictOldest.
+func (c Config) QueueFullPolicy() string {
+	return c.queueFullPolicy
+}
+
+// UnderpricedReplacement returns how speed-ups with a fee cap below the current base fee are handled: UnderpricedIgnore, UnderpricedWarn or UnderpricedReject.
+func (c Config) UnderpricedReplacement() string {
+	return c.underpricedReplacement
+}
+
+// EventBufferSize returns the number of status changes buffered for each subscriber.
+func (c Config) EventBufferSize() int {
+	return c.eventBufferSize
+}
+
+// EventOverflowPolicy returns what happens to a subscriber with a full buffer: EventOverflowDrop or EventOverflowDisconnect.
+func (c Config) EventOverflowPolicy() string {
+	return c.eventOverflowPolicy
+}
+
+// BroadcastErrorGrace returns how many transient RPC errors a broadcast can get before its transaction is marked FAILED.
+func (c Config) BroadcastErrorGrace() int {
+	return c.broadcastErrorGrace
+}
+
+// GasFetchTimeout returns the deadline of a gas price fetch, 0 meaning only the HTTP client timeout applies.
+func (c Config) GasFetchTimeout() time.Duration {
+	return c.gasFetchTimeout
+}
+
+// IdempotencyTTL returns how long the outcome of a submission is kept for its idempotency key.
+func (c Config) IdempotencyTTL() time.Duration {
+	return c.idempotencyTTL
+}
+
+// IdempotencyMaxKeys returns the maximum number of idempotency keys remembered, 0 disabling idempotency keys.
+func (c Config) IdempotencyMaxKeys() int {
+	return c.idempotencyMaxKeys
+}
+
+// ProxyEnabled returns whether the methods not handled by the server are forwarded to the node.
+func (c Config) ProxyEnabled() bool {
+	return c.proxyEnabled
+}
+
+// SenderRPS returns the maximum number of transactions per second a sender can queue, 0 meaning unlimited.
+func (c Config) SenderRPS() float64 {
+	return c.senderRPS
+}
+
+// SenderBurst returns the number of transactions a sender can queue at once before being limited by SenderRPS.
+func (c Config) SenderBurst() int {
+	return c.senderBurst
+}
+
+// GasMonitoringInterval returns the time between two gas price checks.
+func (c Config) GasMonitoringInterval() time.Duration {
+	return c.gasMonitoringInterval
+}
+
+// BatchConcurrency returns how many elements of a JSON-RPC batch are processed in parallel.
+func (c Config) BatchConcurrency() int {
+	return c.batchConcurrency
+}
+
+// RejectZeroTip returns whether transactions without priority fee are rejected.
+func (c Config) RejectZeroTip() bool {
+	return c.rejectZeroTip
+}
+
+// BroadcastLogFile returns the path of the log of the broadcast transaction hashes, empty when disabled.
+func (c Config) BroadcastLogFile() string {
+	return c.broadcastLogFile
+}
+
+// BroadcastLogMaxEntries returns how many of the last broadcast transaction hashes the broadcast log keeps.
+func (c Config) BroadcastLogMaxEntries() int {
+	return c.broadcastLogMaxEntries
+}
+
+// RetryAfterHeader returns whether rate limited responses tell the client when to retry with a Retry-After header.
+func (c Config) RetryAfterHeader() bool {
+	return c.retryAfterHeader
+}
+
+// ClockSkewTolerance returns the extra time given to the expirations so a small clock drift doesn't expire entries early.
+func (c Config) ClockSkewTolerance() time.Duration {
+	return c.clockSkewTolerance
+}
+
+// AdminAPIKey returns the key required to call the admin methods, empty when they are disabled.
+func (c Config) AdminAPIKey() string {
+	return c.adminAPIKey
+}
+
+// DevMode returns whether error responses include debugging details such as stack traces.
+func (c Config) DevMode() bool {
+	return c.devMode
+}
+
+// UpstreamRateLimitRetries returns how many times a request rate limited by the Ethereum node (HTTP 429) is sent again.
+func (c Config) UpstreamRateLimitRetries() int {
+	return c.upstreamRateLimitRetries
+}
+
+// UpstreamRateLimitMaxDelay returns the longest wait before sending a rate limited request again, whatever its Retry-After header says.
+func (c Config) UpstreamRateLimitMaxDelay() time.Duration {
+	return c.upstreamRateLimitMaxDelay
+}
+
+// MaxTxDataBytes returns the maximum size of the calldata of a transaction, 0 meaning unlimited.
+func (c Config) MaxTxDataBytes() int {
+	return c.maxTxDataBytes
+}
+
+// BroadcastOnStore returns whether a transaction is checked for broadcast as soon as it's stored, instead of on the next monitor tick.
+func (c Config) BroadcastOnStore() bool {
+	return c.broadcastOnStore
+}
+
+// CheckBalance returns whether the sender's balance must cover the maximum cost of a transaction to queue it.
+func (c Config) CheckBalance() bool {
+	return c.checkBalance
+}
+
+// SnapshotInterval returns the time between two flushes of the stored transactions, 0 meaning they're only flushed on shutdown.
+func (c Config) SnapshotInterval() time.Duration {
+	return c.snapshotInterval
+}
+
+// ReadOnly returns whether the instance never broadcasts transactions, leaving it to another instance.
+func (c Config) ReadOnly() bool {
+	return c.readOnly
+}
+
+// CORSAllowedOrigins returns the origins allowed to call the server from a browser, "*" allowing any. CORS is disabled when empty.
+func (c Config) CORSAllowedOrigins() []string {
+	return splitList(c.corsAllowedOrigins)
+}
+
+// CORSAllowedMethods returns the HTTP methods allowed in CORS requests.
+func (c Config) CORSAllowedMethods() []string {
+	return splitList(c.corsAllowedMethods)
+}
+
+// CORSAllowedHeaders returns the request headers allowed in CORS requests, e.g. X-API-Key.
+func (c Config) CORSAllowedHeaders() []string {
+	return splitList(c.corsAllowedHeaders)
+}
+
+// CORSAllowCredentials returns whether CORS requests can carry credentials, e.g. cookies.
+func (c Config) CORSAllowCredentials() bool {
+	return c.corsAllowCredentials
+}
+
+// AllowedChainIDs returns the chain ids of the transactions accepted by the server, any being accepted when empty.
+func (c Config) AllowedChainIDs() []uint64 {
+	ids := []uint64{}
+	for _, id := range splitList(c.allowedChainIDs) {
+		// Validated by ReadConfig.
+		value, _ := strconv.ParseUint(id, 10, 64)
+		ids = append(ids, value)
+	}
+	return ids
+}
+
+// ValidateProxyResponses returns whether the proxied responses are checked to be well-formed JSON-RPC responses instead of streamed as is.
+func (c Config) ValidateProxyResponses() bool {
+	return c.validateProxyResponses
+}
+
+// BroadcastConfirmTicks returns the number of consecutive checks a transaction must be eligible at before it's broadcast.
+func (c Config) BroadcastConfirmTicks() int {
+	return c.broadcastConfirmTicks
+}
+
+// GasHistorySize returns the number of gas price observations kept for gas_history, 0 meaning none.
+func (c Config) GasHistorySize() int {
+	return c.gasHistorySize
+}
+
+// BroadcastURLs returns the additional nodes the transactions are broadcast to, along with the configured one.
+func (c Config) BroadcastURLs() []string {
+	return splitList(c.broadcastURLs)
+}
+
+// UserAgent returns the User-Agent header of the requests sent to the node.
+func (c Config) UserAgent() string {
+	return c.userAgent
+}
+
+// BaseFeeAware returns whether the dynamic fee transactions are checked against the pending base fee rather than the gas price.
+func (c Config) BaseFeeAware() bool {
+	return c.baseFeeAware
+}
+
+// AttemptHistorySize returns the number of broadcast attempts kept for each transaction, 0 meaning none.
+func (c Config) AttemptHistorySize() int {
+	return c.attemptHistorySize
+}
+
+// CompactStore returns whether the stored transactions are kept as raw hex only and decoded when needed.
+func (c Config) CompactStore() bool {
+	return c.compactStore
+}
+
+// MaxGasPriceGwei returns the highest gas price, in gwei, paid by the transactions broadcast by the monitor, 0 meaning no limit.
+func (c Config) MaxGasPriceGwei() float64 {
+	return c.maxGasPriceGwei
+}
+
+// MethodRateLimits returns the requests per second allowed for the methods with their own rate limit, by method.
+func (c Config) MethodRateLimits() map[string]float64 {
+	limits, _ := parseMethodRateLimits(c.methodRateLimits)
+	return limits
+}
+
+// TxTTL returns how long a transaction can stay STORED before it expires, 0 meaning forever.
+func (c Config) TxTTL() time.Duration {
+	return c.txTTL
+}
+
+// MaxDeadline returns how far ahead a transaction deadline can be set, 0 meaning no limit.
+func (c Config) MaxDeadline() time.Duration {
+	return c.maxDeadline
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -53,4 +55,298 @@ func TestLoadConfig(t *testing.T) {
 		require.Equal(t, "DEBUG", cfg.LogLevel())
 		require.Equal(t, "test_host:9090", cfg.Addr())
 	})
+
+	t.Run("when PROXY_RETRIES is invalid, return error", func(t *testing.T) {
+		os.Setenv("PROXY_RETRIES", "-1")
+		defer os.Unsetenv("PROXY_RETRIES")
+
+		err := LoadConfig()
+		require.Error(t, err)
+	})
+
+	t.Run("when proxy retry env variables are set, load config with those values", func(t *testing.T) {
+		os.Setenv("PROXY_RETRIES", "3")
+		os.Setenv("PROXY_RETRY_BACKOFF", "50ms")
+
+		err := LoadConfig()
+		require.NoError(t, err)
+
+		cfg := GetConfig()
+
+		require.Equal(t, 3, cfg.ProxyRetries())
+		require.Equal(t, 50*time.Millisecond, cfg.ProxyRetryBackoff())
+	})
+
+	t.Run("when INFURA_PROJECT_ID_FILE is set, read the key from the file over the env variable", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "infura_project_id")
+		err := os.WriteFile(path, []byte("file_project_id\n"), 0600)
+		require.NoError(t, err)
+		os.Setenv("INFURA_PROJECT_ID", "env_project_id")
+		os.Setenv("INFURA_PROJECT_ID_FILE", path)
+		defer os.Unsetenv("INFURA_PROJECT_ID_FILE")
+
+		err = LoadConfig()
+		require.NoError(t, err)
+		require.Equal(t, "file_project_id", GetConfig().InfuraKey())
+	})
+
+	t.Run("when INFURA_PROJECT_ID_FILE can't be read, return error", func(t *testing.T) {
+		os.Setenv("INFURA_PROJECT_ID_FILE", filepath.Join(t.TempDir(), "missing"))
+		defer os.Unsetenv("INFURA_PROJECT_ID_FILE")
+
+		err := LoadConfig()
+		require.Error(t, err)
+	})
+
+	t.Run("only changes of the reloadable settings don't require a restart", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		current := GetConfig()
+
+		os.Setenv("LOG_LEVEL", "ERROR")
+		os.Setenv("GAS_MONITORING_INTERVAL", "1s")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL")
+		require.NoError(t, LoadConfig())
+		require.False(t, current.RestartRequired(GetConfig()))
+
+		os.Setenv("PORT", "9191")
+		require.NoError(t, LoadConfig())
+		require.True(t, current.RestartRequired(GetConfig()))
+	})
+
+	t.Run("the gas settings of the configured network take precedence", func(t *testing.T) {
+		os.Setenv("GAS_MONITORING_INTERVAL", "5s")
+		os.Setenv("GAS_MONITORING_INTERVAL_MAINNET", "12s")
+		os.Setenv("GAS_MONITORING_INTERVAL_ARBITRUM_SEPOLIA", "250ms")
+		os.Setenv("GAS_FETCH_TIMEOUT_ARBITRUM_SEPOLIA", "1s")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL_MAINNET")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL_ARBITRUM_SEPOLIA")
+		defer os.Unsetenv("GAS_FETCH_TIMEOUT_ARBITRUM_SEPOLIA")
+		defer os.Setenv("NETWORK", "test_network")
+
+		os.Setenv("NETWORK", "mainnet")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 12*time.Second, GetConfig().GasMonitoringInterval())
+		require.Equal(t, time.Duration(0), GetConfig().GasFetchTimeout())
+
+		os.Setenv("NETWORK", "arbitrum-sepolia")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 250*time.Millisecond, GetConfig().GasMonitoringInterval())
+		require.Equal(t, time.Second, GetConfig().GasFetchTimeout())
+
+		os.Setenv("NETWORK", "goerli")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 5*time.Second, GetConfig().GasMonitoringInterval())
+	})
+
+	t.Run("an invalid network specific setting names its variable", func(t *testing.T) {
+		os.Setenv("NETWORK", "mainnet")
+		os.Setenv("GAS_MONITORING_INTERVAL_MAINNET", "0s")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL_MAINNET")
+		defer os.Setenv("NETWORK", "test_network")
+
+		err := LoadConfig()
+		require.EqualError(t, err, "GAS_MONITORING_INTERVAL_MAINNET must be positive")
+	})
+
+	t.Run("the CORS lists are trimmed", func(t *testing.T) {
+		os.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example, https://admin.example,")
+		os.Setenv("CORS_ALLOWED_HEADERS", "Content-Type, X-API-Key")
+		os.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+		defer os.Unsetenv("CORS_ALLOWED_ORIGINS")
+		defer os.Unsetenv("CORS_ALLOWED_HEADERS")
+		defer os.Unsetenv("CORS_ALLOW_CREDENTIALS")
+
+		require.NoError(t, LoadConfig())
+		require.Equal(t, []string{"https://app.example", "https://admin.example"}, GetConfig().CORSAllowedOrigins())
+		require.Equal(t, []string{"GET", "POST", "OPTIONS"}, GetConfig().CORSAllowedMethods())
+		require.Equal(t, []string{"Content-Type", "X-API-Key"}, GetConfig().CORSAllowedHeaders())
+		require.True(t, GetConfig().CORSAllowCredentials())
+	})
+
+	t.Run("credentials can't be allowed with a wildcard", func(t *testing.T) {
+		os.Setenv("CORS_ALLOWED_ORIGINS", "*")
+		os.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+		defer os.Unsetenv("CORS_ALLOWED_ORIGINS")
+		defer os.Unsetenv("CORS_ALLOW_CREDENTIALS")
+
+		err := LoadConfig()
+		require.EqualError(t, err, "CORS_ALLOWED_ORIGINS can't contain a wildcard when CORS_ALLOW_CREDENTIALS is set")
+	})
+
+	t.Run("the allowed chain ids must be numbers", func(t *testing.T) {
+		os.Setenv("ALLOWED_CHAIN_IDS", "1, 11155111")
+		defer os.Unsetenv("ALLOWED_CHAIN_IDS")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, []uint64{1, 11155111}, GetConfig().AllowedChainIDs())
+
+		os.Setenv("ALLOWED_CHAIN_IDS", "1,sepolia")
+		require.Error(t, LoadConfig())
+	})
+
+	t.Run("the gas history size must not be negative", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 100, GetConfig().GasHistorySize())
+
+		os.Setenv("GAS_HISTORY_SIZE", "-1")
+		defer os.Unsetenv("GAS_HISTORY_SIZE")
+		err := LoadConfig()
+		require.EqualError(t, err, "GAS_HISTORY_SIZE must not be negative")
+	})
+
+	t.Run("the snapshot interval is a non-negative duration", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Equal(t, time.Duration(0), GetConfig().SnapshotInterval())
+
+		os.Setenv("SNAPSHOT_INTERVAL", "-1s")
+		defer os.Unsetenv("SNAPSHOT_INTERVAL")
+		err := LoadConfig()
+		require.EqualError(t, err, "SNAPSHOT_INTERVAL must not be negative")
+	})
+
+	t.Run("the broadcast log keeps at least one entry", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 100000, GetConfig().BroadcastLogMaxEntries())
+
+		os.Setenv("BROADCAST_LOG_MAX_ENTRIES", "0")
+		defer os.Unsetenv("BROADCAST_LOG_MAX_ENTRIES")
+		err := LoadConfig()
+		require.EqualError(t, err, "BROADCAST_LOG_MAX_ENTRIES must be at least 1")
+	})
+
+	t.Run("the broadcast URLs must be http URLs", func(t *testing.T) {
+		os.Setenv("BROADCAST_URLS", "https://rpc.example, http://localhost:8545")
+		defer os.Unsetenv("BROADCAST_URLS")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, []string{"https://rpc.example", "http://localhost:8545"}, GetConfig().BroadcastURLs())
+
+		os.Setenv("BROADCAST_URLS", "https://rpc.example,rpc.example")
+		err := LoadConfig()
+		require.EqualError(t, err, "invalid BROADCAST_URLS: URL 2 is not an http or https URL")
+	})
+
+	t.Run("the user agent defaults to the server version", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Equal(t, "tx-json-rpc-server/dev", GetConfig().UserAgent())
+
+		os.Setenv("USER_AGENT", "my-wallet-backend/2.0")
+		defer os.Unsetenv("USER_AGENT")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, "my-wallet-backend/2.0", GetConfig().UserAgent())
+	})
+
+	t.Run("base fee aware broadcasting is opt-in", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.False(t, GetConfig().BaseFeeAware())
+
+		os.Setenv("BASE_FEE_AWARE", "true")
+		defer os.Unsetenv("BASE_FEE_AWARE")
+		require.NoError(t, LoadConfig())
+		require.True(t, GetConfig().BaseFeeAware())
+	})
+
+	t.Run("the attempt history size must not be negative", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 10, GetConfig().AttemptHistorySize())
+
+		os.Setenv("ATTEMPT_HISTORY_SIZE", "-1")
+		defer os.Unsetenv("ATTEMPT_HISTORY_SIZE")
+		err := LoadConfig()
+		require.EqualError(t, err, "ATTEMPT_HISTORY_SIZE must not be negative")
+	})
+
+	t.Run("the compact store is opt-in", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.False(t, GetConfig().CompactStore())
+
+		os.Setenv("COMPACT_STORE", "true")
+		defer os.Unsetenv("COMPACT_STORE")
+		require.NoError(t, LoadConfig())
+		require.True(t, GetConfig().CompactStore())
+	})
+
+	t.Run("when RPC_URL is set, use it instead of Infura", func(t *testing.T) {
+		os.Unsetenv("NETWORK")
+		os.Unsetenv("INFURA_PROJECT_ID")
+		defer os.Setenv("NETWORK", "test_network")
+		defer os.Setenv("INFURA_PROJECT_ID", "test_project_id")
+		os.Setenv("RPC_URL", "http://localhost:8545")
+		defer os.Unsetenv("RPC_URL")
+
+		require.NoError(t, LoadConfig())
+		require.Equal(t, "http://localhost:8545", GetConfig().URL())
+
+		os.Setenv("NETWORK", "test_network")
+		os.Setenv("INFURA_PROJECT_ID", "test_project_id")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, "http://localhost:8545", GetConfig().URL())
+
+		os.Setenv("RPC_URL", "localhost:8545/secret")
+		err := LoadConfig()
+		require.EqualError(t, err, "invalid RPC_URL: not an http or https URL")
+
+		os.Unsetenv("RPC_URL")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, "https://test_network.infura.io/v3/test_project_id", GetConfig().URL())
+	})
+
+	t.Run("the max gas price must not be negative", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Zero(t, GetConfig().MaxGasPriceGwei())
+
+		os.Setenv("MAX_GAS_PRICE_GWEI", "150.5")
+		defer os.Unsetenv("MAX_GAS_PRICE_GWEI")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 150.5, GetConfig().MaxGasPriceGwei())
+
+		os.Setenv("MAX_GAS_PRICE_GWEI", "-1")
+		err := LoadConfig()
+		require.EqualError(t, err, "MAX_GAS_PRICE_GWEI must not be negative")
+	})
+
+	t.Run("the method rate limits are method=rps pairs", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Empty(t, GetConfig().MethodRateLimits())
+
+		os.Setenv("METHOD_RATE_LIMITS", "eth_getLogs=2, eth_call = 0.5")
+		defer os.Unsetenv("METHOD_RATE_LIMITS")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, map[string]float64{"eth_getLogs": 2, "eth_call": 0.5}, GetConfig().MethodRateLimits())
+
+		os.Setenv("METHOD_RATE_LIMITS", "eth_getLogs")
+		err := LoadConfig()
+		require.EqualError(t, err, `invalid METHOD_RATE_LIMITS: "eth_getLogs" is not a method=rps pair`)
+
+		os.Setenv("METHOD_RATE_LIMITS", "eth_getLogs=0")
+		err = LoadConfig()
+		require.EqualError(t, err, "invalid METHOD_RATE_LIMITS: the rate of eth_getLogs must be a positive number")
+	})
+
+	t.Run("the transaction TTL is a non-negative duration", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Equal(t, time.Duration(0), GetConfig().TxTTL())
+
+		os.Setenv("TX_TTL", "24h")
+		defer os.Unsetenv("TX_TTL")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 24*time.Hour, GetConfig().TxTTL())
+
+		os.Setenv("TX_TTL", "-1h")
+		err := LoadConfig()
+		require.EqualError(t, err, "TX_TTL must not be negative")
+	})
+
+	t.Run("the max deadline defaults to 30 days and can be disabled", func(t *testing.T) {
+		require.NoError(t, LoadConfig())
+		require.Equal(t, 720*time.Hour, GetConfig().MaxDeadline())
+
+		os.Setenv("MAX_DEADLINE", "0")
+		defer os.Unsetenv("MAX_DEADLINE")
+		require.NoError(t, LoadConfig())
+		require.Equal(t, time.Duration(0), GetConfig().MaxDeadline())
+
+		os.Setenv("MAX_DEADLINE", "-1h")
+		err := LoadConfig()
+		require.EqualError(t, err, "MAX_DEADLINE must not be negative")
+	})
 }
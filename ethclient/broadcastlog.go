@@ -0,0 +1,213 @@
+package ethclient
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// retractPrefix marks a line of the broadcast log retracting a hash whose send failed.
+const retractPrefix = "-"
+
+// broadcastLog is an append-only file of the hashes of the broadcast transactions, one per line.
+// It's loaded on start so that a transaction broadcast before a restart isn't sent again.
+// A hash is appended before its transaction is sent and retracted if the send fails. A crash during the send leaves it
+// recorded, so the transaction is never sent twice, at the risk of never being sent at all.
+// Only the last maxHashes hashes are kept, the file being rewritten with them once it holds twice as many lines.
+type broadcastLog struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	hashes map[string]bool
+	// order lists the kept hashes from the oldest to the newest.
+	order     []string
+	maxHashes int
+	// lines counts the lines of the file.
+	lines int
+}
+
+// openBroadcastLog loads the last maxHashes hashes of a broadcast log, creating the file if needed, and opens it for appending.
+func openBroadcastLog(path string, maxHashes int) (*broadcastLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open broadcast log: %w", err)
+	}
+
+	bl := &broadcastLog{path: path, file: file, hashes: make(map[string]bool), maxHashes: maxHashes}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		bl.lines++
+		if strings.HasPrefix(line, retractPrefix) {
+			bl.forget(strings.TrimPrefix(line, retractPrefix))
+			continue
+		}
+		bl.remember(line)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read broadcast log: %w", err)
+	}
+	if bl.lines > len(bl.order) {
+		err = bl.compact()
+		if err != nil {
+			bl.file.Close()
+			return nil, err
+		}
+	}
+	return bl, nil
+}
+
+// contains reports whether a transaction was broadcast, a nil log contains nothing.
+func (bl *broadcastLog) contains(hash string) bool {
+	if bl == nil {
+		return false
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	return bl.hashes[hash]
+}
+
+// append durably records a transaction about to be broadcast, it's a no-op on a nil log.
+func (bl *broadcastLog) append(hash string) error {
+	if bl == nil {
+		return nil
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	if bl.hashes[hash] {
+		return nil
+	}
+	err := bl.write(hash)
+	if err != nil {
+		return err
+	}
+	bl.remember(hash)
+	bl.compactIfNeeded()
+	return nil
+}
+
+// retract durably removes a transaction whose broadcast failed, so it can be sent again. It's a no-op on a nil log.
+func (bl *broadcastLog) retract(hash string) error {
+	if bl == nil {
+		return nil
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	if !bl.hashes[hash] {
+		return nil
+	}
+	err := bl.write(retractPrefix + hash)
+	if err != nil {
+		return err
+	}
+	bl.forget(hash)
+	bl.compactIfNeeded()
+	return nil
+}
+
+// close closes the log file, it's a no-op on a nil log.
+func (bl *broadcastLog) close() error {
+	if bl == nil {
+		return nil
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	return bl.file.Close()
+}
+
+// write appends a line to the file and syncs it, bl.mu must be held.
+func (bl *broadcastLog) write(line string) error {
+	_, err := bl.file.WriteString(line + "\n")
+	if err != nil {
+		return err
+	}
+	bl.lines++
+	return bl.file.Sync()
+}
+
+// remember keeps a hash, forgetting the oldest one beyond maxHashes.
+func (bl *broadcastLog) remember(hash string) {
+	if bl.hashes[hash] {
+		return
+	}
+	bl.hashes[hash] = true
+	bl.order = append(bl.order, hash)
+	if bl.maxHashes > 0 && len(bl.order) > bl.maxHashes {
+		delete(bl.hashes, bl.order[0])
+		bl.order = bl.order[1:]
+	}
+}
+
+// forget drops a hash, the most recent ones being the likeliest to be retracted.
+func (bl *broadcastLog) forget(hash string) {
+	if !bl.hashes[hash] {
+		return
+	}
+	delete(bl.hashes, hash)
+	for i := len(bl.order) - 1; i >= 0; i-- {
+		if bl.order[i] == hash {
+			bl.order = append(bl.order[:i:i], bl.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// compactIfNeeded rewrites the file once it holds twice as many lines as kept hashes, bl.mu must be held.
+// A failure only delays the compaction, the lines are already recorded.
+func (bl *broadcastLog) compactIfNeeded() {
+	if bl.maxHashes <= 0 || bl.lines < 2*bl.maxHashes {
+		return
+	}
+	err := bl.compact()
+	if err != nil {
+		log.Warn(err.Error())
+	}
+}
+
+// compact replaces the file with one listing the kept hashes only, bl.mu must be held.
+// The new file is written aside then renamed over the old one, so a crash meanwhile leaves either of them whole.
+func (bl *broadcastLog) compact() error {
+	tmpPath := bl.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to compact broadcast log: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, hash := range bl.order {
+		writer.WriteString(hash + "\n")
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, bl.path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compact broadcast log: %w", err)
+	}
+
+	file, err := os.OpenFile(bl.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to reopen broadcast log: %w", err)
+	}
+	bl.file.Close()
+	bl.file = file
+	bl.lines = len(bl.order)
+	return nil
+}
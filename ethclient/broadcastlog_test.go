@@ -0,0 +1,143 @@
+package ethclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// MethodRecordingDoer answers {"result":"0x1"} to every request and records the JSON-RPC methods.
+type MethodRecordingDoer struct {
+	Methods []string
+}
+
+func (m *MethodRecordingDoer) Do(req *http.Request) (*http.Response, error) {
+	var rpcReq types.JSONRPCRequest
+	err := json.NewDecoder(req.Body).Decode(&rpcReq)
+	if err != nil {
+		return nil, err
+	}
+	m.Methods = append(m.Methods, rpcReq.Method)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)),
+	}, nil
+}
+
+// tests the broadcast log.
+func TestBroadcastLog(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+
+	t.Run("the appended hashes are loaded on reopen", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broadcasts.log")
+		broadcasts, err := openBroadcastLog(path, 10)
+		require.NoError(t, err)
+		require.False(t, broadcasts.contains(hash))
+		require.NoError(t, broadcasts.append(hash))
+		require.NoError(t, broadcasts.close())
+
+		reopened, err := openBroadcastLog(path, 10)
+		require.NoError(t, err)
+		defer reopened.close()
+		require.True(t, reopened.contains(hash))
+	})
+
+	t.Run("a broadcast transaction is appended to the log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broadcasts.log")
+		broadcasts, err := openBroadcastLog(path, 10)
+		require.NoError(t, err)
+		defer broadcasts.close()
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &MonitorGasMockDoer{},
+			broadcasts:         broadcasts,
+		}
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+		require.True(t, broadcasts.contains(hash))
+	})
+
+	t.Run("a transaction whose send failed is retracted from the log", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broadcasts.log")
+		broadcasts, err := openBroadcastLog(path, 10)
+		require.NoError(t, err)
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client: &SequenceDoer{Bodies: []string{
+				`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+				`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`,
+			}},
+			broadcasts: broadcasts,
+		}
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.FAILED, ec.storedTransactions[hash].Status)
+		require.False(t, broadcasts.contains(hash))
+		require.NoError(t, broadcasts.close())
+
+		reopened, err := openBroadcastLog(path, 10)
+		require.NoError(t, err)
+		defer reopened.close()
+		require.False(t, reopened.contains(hash))
+	})
+
+	t.Run("only the last hashes are kept and the file is compacted", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broadcasts.log")
+		broadcasts, err := openBroadcastLog(path, 2)
+		require.NoError(t, err)
+		for _, hash := range []string{"0x01", "0x02", "0x03", "0x04"} {
+			require.NoError(t, broadcasts.append(hash))
+		}
+		require.False(t, broadcasts.contains("0x02"))
+		require.True(t, broadcasts.contains("0x03"))
+		require.NoError(t, broadcasts.close())
+
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "0x03\n0x04\n", string(content))
+
+		reopened, err := openBroadcastLog(path, 2)
+		require.NoError(t, err)
+		defer reopened.close()
+		require.False(t, reopened.contains("0x02"))
+		require.True(t, reopened.contains("0x04"))
+	})
+
+	t.Run("a transaction of the reloaded log isn't broadcast again", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broadcasts.log")
+		previous, err := openBroadcastLog(path, 10)
+		require.NoError(t, err)
+		require.NoError(t, previous.append(hash))
+		require.NoError(t, previous.close())
+
+		// The client restarts with the transaction still STORED.
+		broadcasts, err := openBroadcastLog(path, 10)
+		require.NoError(t, err)
+		defer broadcasts.close()
+		doer := &MethodRecordingDoer{}
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             doer,
+			broadcasts:         broadcasts,
+		}
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, []string{"eth_gasPrice"}, doer.Methods)
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+}
@@ -0,0 +1,164 @@
+package ethclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// storeJournal records the changes made to the store by storeValidated so they can be undone, along with the status
+// changes and the stored count they are to publish once committed. Its methods are no-ops on a nil journal.
+type storeJournal struct {
+	added   []string
+	changed []journaledStatus
+	events  []journaledEvent
+	stored  uint64
+}
+
+// journaledStatus is the status of a transaction before storeValidated changed it.
+type journaledStatus struct {
+	hash   string
+	status types.TransactionStatus
+	reason string
+}
+
+// journaledEvent is a status change held back until the batch it belongs to is committed.
+type journaledEvent struct {
+	hash     string
+	from, to types.TransactionStatus
+}
+
+func (j *storeJournal) recordAdded(hash string) {
+	if j == nil {
+		return
+	}
+	j.added = append(j.added, hash)
+}
+
+func (j *storeJournal) recordStatus(hash string, previous types.Transaction) {
+	if j == nil {
+		return
+	}
+	j.changed = append(j.changed, journaledStatus{hash: hash, status: previous.Status, reason: previous.Reason})
+}
+
+// notifyStatusChange publishes a status change, or holds it back in journal when not nil until the batch is committed.
+func (ec *EthClient) notifyStatusChange(journal *storeJournal, hash string, from, to types.TransactionStatus) {
+	if journal == nil {
+		ec.publishStatusChange(hash, from, to)
+		return
+	}
+	journal.events = append(journal.events, journaledEvent{hash: hash, from: from, to: to})
+}
+
+// countStored counts a stored transaction, or holds the count back in journal when not nil until the batch is committed.
+func (ec *EthClient) countStored(journal *storeJournal) {
+	if journal == nil {
+		ec.statusTotals[types.STORED].Add(1)
+		return
+	}
+	journal.stored++
+}
+
+// commit publishes the status changes and counts the transactions held back in a journal, transactionsMutex must be held.
+func (ec *EthClient) commit(journal *storeJournal) {
+	ec.statusTotals[types.STORED].Add(journal.stored)
+	for _, event := range journal.events {
+		ec.publishStatusChange(event.hash, event.from, event.to)
+	}
+}
+
+// StoreTransactions stores several transactions atomically: either all of them are stored or none.
+// They are all validated first, then stored in order under the store lock, the changes made so far being rolled back on
+// the first failure. The status changes are only published once the whole batch is stored, a rejected batch publishes
+// none. The sender limiter tokens taken by a rejected batch are given back.
+func (ec *EthClient) StoreTransactions(txs []types.Transaction) ([]string, error) {
+	hashes := make([]string, len(txs))
+	seen := make(map[string]bool, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash().String()
+		if seen[hashes[i]] {
+			return nil, fmt.Errorf("transaction %d: duplicate transaction", i)
+		}
+		seen[hashes[i]] = true
+	}
+
+	validations := make([]validation, 0, len(txs))
+	for i := range txs {
+		checked, err := ec.validateTransaction(&txs[i], txs[:i])
+		if err != nil {
+			refundSenders(validations)
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		validations = append(validations, checked)
+	}
+
+	journal := &storeJournal{}
+	ec.transactionsMutex.Lock()
+	for i, tx := range txs {
+		err := ec.storeValidated(tx, validations[i], journal)
+		if err != nil {
+			ec.rollback(journal)
+			ec.transactionsMutex.Unlock()
+			refundSenders(validations)
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+	}
+	ec.commit(journal)
+	ec.transactionsMutex.Unlock()
+	ec.checkStored(hashes...)
+	return hashes, nil
+}
+
+// refundSenders gives back the sender limiter tokens taken by validated transactions.
+func refundSenders(validations []validation) {
+	for _, checked := range validations {
+		checked.token.giveBack()
+	}
+}
+
+// rollback undoes the changes recorded in a journal, the most recent first, and discards the status changes it held back.
+// transactionsMutex must have been held since the changes were made, so none of the transactions changed since.
+func (ec *EthClient) rollback(journal *storeJournal) {
+	for i := len(journal.changed) - 1; i >= 0; i-- {
+		previous := journal.changed[i]
+		tx := ec.storedTransactions[previous.hash]
+		tx.Status = previous.status
+		tx.Reason = previous.reason
+		ec.storedTransactions[previous.hash] = tx
+	}
+	for _, hash := range journal.added {
+		err := ec.removeTransaction(hash)
+		if err != nil {
+			log.WithField(txHashField, hash).Error("failed to roll back transaction: ", err)
+		}
+	}
+}
+
+// removeTransaction deletes a stored transaction and its sender index entry, transactionsMutex must be held.
+func (ec *EthClient) removeTransaction(hash string) error {
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return errors.New("transaction not found")
+	}
+	delete(ec.storedTransactions, hash)
+
+	tx = ec.expand(hash, tx)
+	from, err := sender(&tx)
+	if err != nil {
+		return err
+	}
+	hashes := ec.senderIndex[from]
+	for i, indexed := range hashes {
+		if indexed == hash {
+			ec.senderIndex[from] = append(hashes[:i:i], hashes[i+1:]...)
+			break
+		}
+	}
+	if len(ec.senderIndex[from]) == 0 {
+		delete(ec.senderIndex, from)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package ethclient
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// tests the StoreTransactions function.
+func TestStoreTransactions(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64, feeCap int64) *types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(feeCap), Gas: 21000, To: &common.Address{1},
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+	newClient := func() *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+		}
+	}
+
+	t.Run("store all the transactions and return their hashes", func(t *testing.T) {
+		client := newClient()
+		first, second := newTx(1, 10), newTx(2, 10)
+
+		hashes, err := client.StoreTransactions([]types.Transaction{*first, *second})
+		require.NoError(t, err)
+		require.Equal(t, []string{first.Hash().String(), second.Hash().String()}, hashes)
+		require.Len(t, client.storedTransactions, 2)
+	})
+
+	t.Run("when a transaction fails to be stored, roll back the ones stored before", func(t *testing.T) {
+		client := newClient()
+		existing := newTx(1, 10)
+		require.NoError(t, client.StoreTransaction(*existing))
+
+		_, err := client.StoreTransactions([]types.Transaction{*newTx(2, 10), *existing})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "transaction 1: already STORED")
+		require.Equal(t, []string{existing.Hash().String()}, snapshotHashes(client.transactionsSnapshot()))
+		from, err := sender(existing)
+		require.NoError(t, err)
+		require.Equal(t, []string{existing.Hash().String()}, client.senderIndex[from])
+	})
+
+	t.Run("when a transaction fails to be stored, restore the transactions sped up before", func(t *testing.T) {
+		client := newClient()
+		original, existing := newTx(1, 10), newTx(2, 10)
+		require.NoError(t, client.StoreTransaction(*original))
+		require.NoError(t, client.StoreTransaction(*existing))
+
+		_, err := client.StoreTransactions([]types.Transaction{*newTx(1, 20), *existing})
+		require.Error(t, err)
+		require.Equal(t, types.STORED, client.storedTransactions[original.Hash().String()].Status)
+		require.Len(t, client.storedTransactions, 2)
+	})
+
+	t.Run("when a transaction is invalid, store none of them", func(t *testing.T) {
+		client := newClient()
+		unprotected, err := getTxFromRaw(signRawTx(t, key, ethTypes.HomesteadSigner{}, &ethTypes.LegacyTx{
+			Nonce: 3, GasPrice: big.NewInt(10), Gas: 21000, To: &common.Address{1},
+		}))
+		require.NoError(t, err)
+
+		_, err = client.StoreTransactions([]types.Transaction{*newTx(1, 10), *unprotected})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "missing replay protection")
+		require.Empty(t, client.storedTransactions)
+	})
+
+	t.Run("when a transaction is invalid, give back the sender limiter tokens of the batch", func(t *testing.T) {
+		client := newClient()
+		client.senderLimit = rate.Every(time.Minute)
+		client.senderBurst = 2
+		client.rejectZeroTip = true
+		zeroTip, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: 3, GasTipCap: big.NewInt(0), GasFeeCap: big.NewInt(10), Gas: 21000, To: &common.Address{1},
+		}))
+		require.NoError(t, err)
+
+		_, err = client.StoreTransactions([]types.Transaction{*newTx(1, 10), *newTx(2, 10), *zeroTip})
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "transaction 2: zero priority fee")
+
+		_, err = client.StoreTransactions([]types.Transaction{*newTx(1, 10), *newTx(2, 10)})
+		require.NoError(t, err)
+	})
+
+	t.Run("the nonce gap counts the transactions before in the batch", func(t *testing.T) {
+		// The mocked node returns 0x1 as pending nonce.
+		client := newClient()
+		client.Client = &MonitorGasMockDoer{}
+		client.maxNonceGap = 1
+
+		_, err := client.StoreTransactions([]types.Transaction{*newTx(1, 10), *newTx(2, 10), *newTx(3, 10)})
+		require.NoError(t, err)
+		require.Len(t, client.storedTransactions, 3)
+	})
+}
+
+// tests that a rejected batch publishes none of its status changes nor counts its transactions.
+func TestStoreTransactionsEvents(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64, feeCap int64) *types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(feeCap), Gas: 21000, To: &common.Address{1},
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+	newClient := func() *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+			events:             newEventHub(8, false),
+			maxStoredTx:        2,
+			evictOldest:        true,
+		}
+	}
+
+	t.Run("a rejected batch publishes nothing and leaves the totals alone", func(t *testing.T) {
+		client := newClient()
+		original, existing := newTx(1, 10), newTx(2, 10)
+		require.NoError(t, client.StoreTransaction(*original))
+		require.NoError(t, client.StoreTransaction(*existing))
+		totals := client.StatusTotals()
+		events, unsubscribe := client.SubscribeStatusChanges()
+		defer unsubscribe()
+
+		// The speed-up of original and the eviction made for the new transaction are rolled back.
+		_, err := client.StoreTransactions([]types.Transaction{*newTx(1, 20), *newTx(3, 10), *existing})
+		require.Error(t, err)
+		require.Len(t, events, 0)
+		require.Equal(t, totals, client.StatusTotals())
+		require.Equal(t, types.STORED, client.storedTransactions[original.Hash().String()].Status)
+		require.Equal(t, types.STORED, client.storedTransactions[existing.Hash().String()].Status)
+	})
+
+	t.Run("a stored batch publishes its status changes once committed", func(t *testing.T) {
+		client := newClient()
+		original := newTx(1, 10)
+		require.NoError(t, client.StoreTransaction(*original))
+		events, unsubscribe := client.SubscribeStatusChanges()
+		defer unsubscribe()
+
+		_, err := client.StoreTransactions([]types.Transaction{*newTx(1, 20), *newTx(2, 10)})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		event := <-events
+		require.Equal(t, original.Hash().String(), event.Hash)
+		require.Equal(t, "SPEDUP", event.To)
+		require.Equal(t, uint64(3), client.StatusTotals()["STORED"])
+	})
+}
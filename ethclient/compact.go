@@ -0,0 +1,105 @@
+package ethclient
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// decodedCacheSize is the number of decoded transactions kept by a compact store, the most recently used ones.
+const decodedCacheSize = 256
+
+// decodedCache is a small LRU cache of the transactions decoded from their raw hex, by hash.
+// A nil cache decodes on every call.
+type decodedCache struct {
+	mu       sync.Mutex
+	capacity int
+	// order lists the entries, most recently used first.
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type decodedEntry struct {
+	hash string
+	tx   *ethTypes.Transaction
+}
+
+// newDecodedCache returns a cache keeping the last capacity decoded transactions, nil when capacity is 0.
+func newDecodedCache(capacity int) *decodedCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &decodedCache{capacity: capacity, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// decode returns the transaction encoded by rawHex, from the cache when it was decoded recently.
+func (c *decodedCache) decode(hash, rawHex string) (*ethTypes.Transaction, error) {
+	if c != nil {
+		c.mu.Lock()
+		if elem, ok := c.entries[hash]; ok {
+			c.order.MoveToFront(elem)
+			c.mu.Unlock()
+			return elem.Value.(*decodedEntry).tx, nil
+		}
+		c.mu.Unlock()
+	}
+
+	rawTx, err := hexutil.Decode(rawHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode raw transaction: %w", err)
+	}
+	tx := new(ethTypes.Transaction)
+	err = tx.UnmarshalBinary(rawTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal raw transaction: %w", err)
+	}
+	if c == nil {
+		return tx, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Decoded concurrently, keep the first one.
+	if elem, ok := c.entries[hash]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*decodedEntry).tx, nil
+	}
+	c.entries[hash] = c.order.PushFront(&decodedEntry{hash: hash, tx: tx})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*decodedEntry).hash)
+	}
+	return tx, nil
+}
+
+// compact returns the transaction to keep in the store: with a compact store, only its raw hex and metadata,
+// the decoded transaction being dropped. Transactions without raw hex are kept decoded since they couldn't be decoded again.
+func (ec *EthClient) compact(tx types.Transaction) types.Transaction {
+	if !ec.compactStore || tx.RawHex == "" {
+		return tx
+	}
+	tx.Transaction = ethTypes.Transaction{}
+	return tx
+}
+
+// expand returns a stored transaction along with its decoded form, decoding its raw hex when the store is compact.
+// Status checks only need the metadata, expand is for the callers of the transaction methods, e.g. Nonce or GasFeeCap.
+func (ec *EthClient) expand(hash string, tx types.Transaction) types.Transaction {
+	if !ec.compactStore || tx.RawHex == "" {
+		return tx
+	}
+	decoded, err := ec.decodedTxs.decode(hash, tx.RawHex)
+	if err != nil {
+		// The raw hex was checked against the decoded transaction when it was stored.
+		log.WithField(txHashField, hash).Error(err.Error())
+		return tx
+	}
+	tx.Transaction = *decoded
+	return tx
+}
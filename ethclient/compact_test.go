@@ -0,0 +1,162 @@
+package ethclient
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the decoded transactions cache.
+func TestDecodedCache(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	raws := make([]string, 3)
+	for i := range raws {
+		raws[i] = signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: uint64(i), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		})
+	}
+
+	t.Run("it keeps the most recently used transactions", func(t *testing.T) {
+		cache := newDecodedCache(2)
+		first, err := cache.decode("a", raws[0])
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), first.Nonce())
+		_, err = cache.decode("b", raws[1])
+		require.NoError(t, err)
+
+		// Using a makes b the least recently used one, evicted by c.
+		again, err := cache.decode("a", raws[0])
+		require.NoError(t, err)
+		require.Same(t, first, again)
+		_, err = cache.decode("c", raws[2])
+		require.NoError(t, err)
+		require.Contains(t, cache.entries, "a")
+		require.NotContains(t, cache.entries, "b")
+		require.Contains(t, cache.entries, "c")
+	})
+
+	t.Run("a nil cache decodes every time", func(t *testing.T) {
+		var cache *decodedCache
+		tx, err := cache.decode("a", raws[0])
+		require.NoError(t, err)
+		require.Equal(t, uint64(0), tx.Nonce())
+	})
+
+	t.Run("an invalid raw hex is an error", func(t *testing.T) {
+		_, err := newDecodedCache(2).decode("a", "0xzz")
+		require.Error(t, err)
+	})
+}
+
+// Test a compact store only keeps the raw hex and decodes the transactions when needed.
+func TestCompactStore(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return *tx
+	}
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		compactStore:       true,
+		decodedTxs:         newDecodedCache(decodedCacheSize),
+	}
+	txs := []types.Transaction{newTx(6), newTx(5)}
+	for _, tx := range txs {
+		require.NoError(t, client.StoreTransaction(tx))
+	}
+
+	for _, tx := range txs {
+		stored := client.storedTransactions[tx.Hash().String()]
+		require.Equal(t, tx.RawHex, stored.RawHex)
+		require.Equal(t, types.STORED, stored.Status)
+		require.Equal(t, ethTypes.Transaction{}, stored.Transaction)
+	}
+
+	view, err := client.GetTransaction(txs[0].Hash().String())
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(6), *view.Nonce)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey).Hex(), view.From)
+	require.Equal(t, 2, view.SenderPosition)
+
+	snapshot := client.Transactions()
+	require.Equal(t, []string{txs[1].Hash().String(), txs[0].Hash().String()}, snapshotHashes(snapshot))
+
+	hash, err := client.CancelTransactionByNonce(crypto.PubkeyToAddress(key.PublicKey), 5)
+	require.NoError(t, err)
+	require.Equal(t, txs[1].Hash().String(), hash)
+	require.Equal(t, ethTypes.Transaction{}, client.storedTransactions[hash].Transaction)
+}
+
+// Benchmark the memory used by the store for thousands of transactions, decoded or compact.
+func BenchmarkStoreMemory(b *testing.B) {
+	const count = 5000
+	key, err := crypto.GenerateKey()
+	require.NoError(b, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	txs := make([]types.Transaction, count)
+	for i := range txs {
+		signed, err := ethTypes.SignNewTx(key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: uint64(i), GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(3e10), Gas: 100000,
+			To: &common.Address{}, Data: make([]byte, 200),
+		})
+		require.NoError(b, err)
+		raw, err := signed.MarshalBinary()
+		require.NoError(b, err)
+		txs[i] = types.Transaction{Status: types.STORED, RawHex: hexutil.Encode(raw)}
+	}
+
+	for _, compact := range []bool{false, true} {
+		b.Run(fmt.Sprintf("compact=%t", compact), func(b *testing.B) {
+			var bytesPerTx float64
+			for n := 0; n < b.N; n++ {
+				before := heapInUse()
+				// Decode the transactions for every run, like the rpc server does, so the store owns them.
+				decoded := make([]types.Transaction, count)
+				for i := range txs {
+					tx, err := getTxFromRaw(txs[i].RawHex)
+					require.NoError(b, err)
+					decoded[i] = *tx
+				}
+				client := &EthClient{
+					storedTransactions: map[string]types.Transaction{},
+					transactionsMutex:  &sync.RWMutex{},
+					compactStore:       compact,
+				}
+				for i := range decoded {
+					client.addTransaction(decoded[i].Hash().String(), decoded[i])
+				}
+				decoded = nil
+				// Only what the store retains is left.
+				bytesPerTx = float64(heapInUse()-before) / count
+				runtime.KeepAlive(client)
+			}
+			b.ReportMetric(bytesPerTx, "B/tx")
+		})
+	}
+}
+
+// heapInUse returns the bytes of the live heap objects, after a collection.
+func heapInUse() int64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return int64(stats.HeapAlloc)
+}
@@ -7,14 +7,22 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/safwentrabelsi/tx-json-rpc-server/config"
 	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"golang.org/x/time/rate"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -24,13 +32,117 @@ type HTTPDoer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// Flusher persists the stored transactions, it's called when the client shuts down and periodically by StartSnapshots.
+// It's installed with SetFlusher.
+type Flusher interface {
+	Flush(ctx context.Context, transactions []types.Transaction) error
+}
+
+// Broadcaster sends the raw transactions picked by the monitor, e.g. to the configured node, a private relay or several nodes.
+// isRPCErr reports the transaction was rejected by the destination, as opposed to a failure to reach it.
+type Broadcaster interface {
+	Broadcast(ctx context.Context, rawHex string) (txHash string, isRPCErr bool, err error)
+}
+
 // EthClient is a struct that represents the Ethereum client which interacts with the Ethereum network.
 type EthClient struct {
 	URL    string
 	Client HTTPDoer
 	storedTransactions map[string]types.Transaction
-	transactionsMutex  *sync.Mutex
+	transactionsMutex  *sync.RWMutex
+	// compactStore keeps the stored transactions as raw hex and metadata, decoding them when needed through decodedTxs.
+	compactStore bool
+	decodedTxs   *decodedCache
+	// senderIndex lists the hashes of the stored transactions of each sender.
+	senderIndex map[common.Address][]string
 	gasMonitoringFrequence time.Duration
+	// reloadedFrequence is the monitoring interval set by a config reload, it overrides gasMonitoringFrequence when not 0.
+	reloadedFrequence atomic.Int64
+	retries      int
+	retryBackoff time.Duration
+	// userAgent is the User-Agent header of the requests to the node, Go's default when empty.
+	userAgent string
+	limiter      *rate.Limiter
+	// rateLimitRetries is the number of times a request answered with HTTP 429 is sent again, waiting at most rateLimitMaxDelay each time.
+	rateLimitRetries  int
+	rateLimitMaxDelay time.Duration
+	// lastGasPrice is the gas price observed by the last MonitorGas tick, 0 until the first one.
+	lastGasPrice  float64
+	gasPriceMutex sync.RWMutex
+	// lastBaseFee is the pending base fee observed with lastGasPrice, nil when it wasn't.
+	lastBaseFee *big.Int
+	// baseFeeAware checks the dynamic fee transactions against the pending base fee rather than the gas price.
+	baseFeeAware bool
+	// maxGasPrice is the highest gas price, in wei, paid by the transactions broadcast by the monitor, nil means no limit.
+	maxGasPrice *big.Int
+	// txTTL is how long a transaction without deadline can stay STORED before it expires, 0 means forever.
+	txTTL time.Duration
+	// clockSkew delays the expirations so a small clock drift doesn't expire transactions early.
+	clockSkew time.Duration
+	// gasHistory keeps the last gas prices observed, nil keeps none.
+	gasHistory *gasHistory
+	// allowUnprotected accepts legacy transactions signed without a chain id, which can be replayed on other chains.
+	allowUnprotected bool
+	// allowedChainIDs lists the chain ids of the accepted transactions, nil accepts any.
+	allowedChainIDs map[uint64]bool
+	// rejectZeroTip rejects the transactions without priority fee, which builders may never include.
+	rejectZeroTip bool
+	// maxTxDataBytes bounds the calldata of a transaction, 0 means unlimited.
+	maxTxDataBytes int
+	// broadcastOnStore checks a transaction as soon as it's stored instead of leaving it to the next monitor tick.
+	broadcastOnStore bool
+	// checkBalance rejects the transactions whose maximum cost exceeds the sender's balance.
+	checkBalance bool
+	// broadcastConfirmTicks is the number of consecutive checks a transaction must be eligible at before it's broadcast,
+	// so a momentary gas price dip doesn't trigger it. 0 and 1 broadcast on the first eligible check.
+	broadcastConfirmTicks int
+	// readOnly never broadcasts the transactions, the gas price is still monitored.
+	readOnly bool
+	// maxNonceGap bounds how far ahead of the sender's next expected nonce a transaction can be, 0 disables the check.
+	maxNonceGap uint64
+	// maxStoredTx bounds the number of STORED transactions, 0 means unlimited.
+	maxStoredTx int
+	// evictOldest makes room for new transactions at capacity by failing the oldest STORED one instead of rejecting them.
+	evictOldest bool
+	// underpricedReplacement is the config.Underpriced* policy for speed-ups below the base fee, the zero value ignores them.
+	underpricedReplacement string
+	// attemptHistorySize is the number of broadcast attempts kept for each transaction, 0 keeps none.
+	attemptHistorySize int
+	// broadcastErrorGrace is the number of transient RPC errors tolerated before failing a broadcast transaction.
+	broadcastErrorGrace int
+	// gasFetchTimeout bounds a gas price fetch, retries included, so a slow node doesn't delay the monitor tick. 0 disables it.
+	gasFetchTimeout time.Duration
+	// senderLimit and senderBurst configure the token bucket of each sender, a zero senderLimit disables it.
+	senderLimit    rate.Limit
+	senderBurst    int
+	senderLimiters map[common.Address]*rate.Limiter
+	limitersMutex  sync.Mutex
+	// broadcasts persists the hashes of the broadcast transactions so they're never sent twice, even across restarts. nil disables it.
+	// A transaction is recorded before it's sent, and retracted if the send fails.
+	broadcasts *broadcastLog
+	// broadcaster sends the transactions, nil sends them to the configured node.
+	broadcaster Broadcaster
+	// events streams the status changes to the subscribers, nil discards them.
+	events *eventHub
+	// flusher, when set, saves the stored transactions on shutdown, and every snapshotInterval when not 0.
+	flusher          Flusher
+	snapshotInterval time.Duration
+	// upstreamErrors counts the requests to the node that failed or were answered with an HTTP error status.
+	upstreamErrors atomic.Uint64
+	// primary, when set, is the client counting the upstream errors of this one, e.g. for the extra broadcast nodes.
+	primary *EthClient
+	// checks counts the gas price checks run by the monitor or on demand.
+	checks atomic.Uint64
+	// statusTotals counts the transactions moved to each status, by status, the stored ones included.
+	statusTotals [types.EXPIRED + 1]atomic.Uint64
+	// gasSaved sums, over the broadcast transactions, the gas price drop between their storing and their broadcast times their gas limit.
+	gasSaved      big.Int
+	gasSavedMutex sync.Mutex
+	// monitorWG tracks the MonitorGas loops started by StartMonitor so Shutdown can wait for them.
+	monitorWG sync.WaitGroup
+	// checksCtx is the context of the checks started by checkStored, cancelChecks cancels it on shutdown. nil uses a background context.
+	checksCtx    context.Context
+	cancelChecks context.CancelFunc
 }
 
 var (
@@ -41,19 +153,56 @@ var (
 
 	// Define allowed state transition for a transaction
 	allowedTransitions = map[types.TransactionStatus][]types.TransactionStatus{
-		types.STORED:    {types.CANCELED, types.SPEDUP, types.FAILED, types.BROADCASTED},
+		types.STORED:    {types.CANCELED, types.SPEDUP, types.FAILED, types.BROADCASTED, types.EXPIRED},
 		types.CANCELED:  {types.SPEDUP},
 		types.SPEDUP:    {},
 		types.FAILED:    {},
-		types.BROADCASTED: {},
+		types.EXPIRED:   {},
+		// A broadcast transaction can still be replaced by a speed-up while it's in the mempool.
+		types.BROADCASTED: {types.SPEDUP},
 	}
 
 )
 
 const txHashField = "tx_hash"
 
+// ErrSenderRateLimited is returned when a sender queues transactions faster than allowed.
+var ErrSenderRateLimited = errors.New("sender rate limit exceeded")
+
+// SenderRateLimitError is the ErrSenderRateLimited error with the time until the sender can queue a transaction again.
+type SenderRateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *SenderRateLimitError) Error() string {
+	return ErrSenderRateLimited.Error()
+}
+
+// Is makes errors.Is(err, ErrSenderRateLimited) match.
+func (e *SenderRateLimitError) Is(target error) bool {
+	return target == ErrSenderRateLimited
+}
+
+// permanentBroadcastErrors are the node errors for which broadcasting the same transaction again can't succeed.
+var permanentBroadcastErrors = []string{
+	"nonce too low",
+	"already known",
+	"insufficient funds",
+	"intrinsic gas too low",
+	"invalid sender",
+	"exceeds block gas limit",
+	"transaction type not supported",
+	"replacement transaction underpriced",
+}
+
+// evictedReason is the failure reason of the transactions evicted to make room for new ones.
+const evictedReason = "evicted"
+
+// deadlineExpiredReason is the failure reason of the transactions that couldn't be broadcast by their deadline.
+const deadlineExpiredReason = "deadline expired"
+
 // Init function initializes the global Ethereum client with the configured URL and an HTTP client.
-func Init()  {
+func Init() error {
 	cfg := config.GetConfig()
 	Client = &EthClient{
 		URL:        cfg.URL(),
@@ -61,9 +210,74 @@ func Init()  {
 			Timeout: time.Second * 10, 
 		},
 		storedTransactions: make(map[string]types.Transaction),
-		transactionsMutex:  &sync.Mutex{},
-		gasMonitoringFrequence: 5 * time.Second,
+		senderIndex:        make(map[common.Address][]string),
+		transactionsMutex:  &sync.RWMutex{},
+		gasMonitoringFrequence: cfg.GasMonitoringInterval(),
+		retries:      cfg.RPCRetries(),
+		retryBackoff: cfg.RPCRetryBackoff(),
+		userAgent:    cfg.UserAgent(),
+		baseFeeAware: cfg.BaseFeeAware(),
+		rateLimitRetries:  cfg.UpstreamRateLimitRetries(),
+		rateLimitMaxDelay: cfg.UpstreamRateLimitMaxDelay(),
+		allowUnprotected: cfg.AllowUnprotectedTx(),
+		maxNonceGap:      uint64(cfg.MaxNonceGap()),
+		rejectZeroTip:    cfg.RejectZeroTip(),
+		maxTxDataBytes:   cfg.MaxTxDataBytes(),
+		broadcastOnStore: cfg.BroadcastOnStore(),
+		checkBalance:     cfg.CheckBalance(),
+		snapshotInterval: cfg.SnapshotInterval(),
+		gasHistory:       newGasHistory(cfg.GasHistorySize()),
+		readOnly:         cfg.ReadOnly(),
+		broadcastConfirmTicks: cfg.BroadcastConfirmTicks(),
+		maxStoredTx:      cfg.MaxStoredTx(),
+		evictOldest:      cfg.QueueFullPolicy() == config.QueueFullEvictOldest,
+		underpricedReplacement: cfg.UnderpricedReplacement(),
+		broadcastErrorGrace: cfg.BroadcastErrorGrace(),
+		attemptHistorySize:  cfg.AttemptHistorySize(),
+		compactStore:        cfg.CompactStore(),
+		decodedTxs:          newDecodedCache(decodedCacheSize),
+		gasFetchTimeout:     cfg.GasFetchTimeout(),
+		events:           newEventHub(cfg.EventBufferSize(), cfg.EventOverflowPolicy() == config.EventOverflowDisconnect),
+	}
+	Client.checksCtx, Client.cancelChecks = context.WithCancel(context.Background())
+	if urls := cfg.BroadcastURLs(); len(urls) > 0 {
+		Client.broadcaster = newMultiBroadcaster(Client, urls)
 	}
+	for _, id := range cfg.AllowedChainIDs() {
+		if Client.allowedChainIDs == nil {
+			Client.allowedChainIDs = make(map[uint64]bool)
+		}
+		Client.allowedChainIDs[id] = true
+	}
+	if gwei := cfg.MaxGasPriceGwei(); gwei > 0 {
+		Client.maxGasPrice, _ = new(big.Float).Mul(big.NewFloat(gwei), big.NewFloat(params.GWei)).Int(nil)
+	}
+	Client.txTTL = cfg.TxTTL()
+	Client.clockSkew = cfg.ClockSkewTolerance()
+	if cfg.SenderRPS() > 0 {
+		Client.senderLimit = rate.Limit(cfg.SenderRPS())
+		Client.senderBurst = cfg.SenderBurst()
+	}
+	if cfg.UpstreamRPS() > 0 {
+		Client.limiter = rate.NewLimiter(rate.Limit(cfg.UpstreamRPS()), cfg.UpstreamBurst())
+	}
+	if cfg.BroadcastLogFile() != "" {
+		broadcasts, err := openBroadcastLog(cfg.BroadcastLogFile(), cfg.BroadcastLogMaxEntries())
+		if err != nil {
+			return err
+		}
+		Client.broadcasts = broadcasts
+	}
+	return nil
+}
+
+// countUpstreamError counts a failed request to the node, on the primary client when there is one.
+func (ec *EthClient) countUpstreamError() {
+	if ec.primary != nil {
+		ec.primary.countUpstreamError()
+		return
+	}
+	ec.upstreamErrors.Add(1)
 }
 
 // doRequest is a helper function that sends an HTTP request to the Ethereum network and returns the response.
@@ -94,47 +308,162 @@ func (ec *EthClient) doRequest(ctx context.Context,  reqBody []byte) (*types.JSO
 	return &respBody, nil
 }
 
+// doRequestWithRetry calls doRequest, retrying failed attempts with a linear backoff.
+// It gives up as soon as ctx is done so that shutting down the monitor isn't delayed by pending retries.
+func (ec *EthClient) doRequestWithRetry(ctx context.Context, reqBody []byte) (*types.JSONRPCResponse, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := ec.doRequest(ctx, reqBody)
+		if err == nil || attempt > ec.retries {
+			return resp, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(ec.retryBackoff * time.Duration(attempt)):
+		}
+	}
+}
+
 // SendRequest sends an HTTP request to the Ethereum network.
+// Proxied and internal requests share the outbound rate limiter, so a request waits for its turn until ctx is done.
+// A request rate limited by the node (HTTP 429) is sent again up to rateLimitRetries times, honoring its Retry-After header,
+// and the last 429 response is returned once they're exhausted.
 func (ec *EthClient) SendRequest(ctx context.Context, body io.Reader, headers http.Header) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost,  ec.URL, body)
+	// The body is buffered so that a rate limited request can be sent again.
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := ec.sendRequestOnce(ctx, payload, headers)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt >= ec.rateLimitRetries {
+			if err != nil || resp.StatusCode >= http.StatusBadRequest {
+				ec.countUpstreamError()
+			}
+			return resp, err
+		}
+		delay := retryAfterDelay(resp.Header.Get("Retry-After"), time.Now(), ec.rateLimitMaxDelay)
+		resp.Body.Close()
+		log.WithField("retry_in", delay).Warn("Rate limited by the Ethereum node, sending the request again")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// sendRequestOnce sends a single HTTP request to the Ethereum network, waiting for the outbound rate limiter.
+func (ec *EthClient) sendRequestOnce(ctx context.Context, payload []byte, headers http.Header) (*http.Response, error) {
+	if ec.limiter != nil {
+		if err := ec.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,  ec.URL, bytes.NewReader(payload))
 	if err != nil {
 		return nil, err
 	}
-	req.Header = headers
+	// The headers are copied since the proxied ones come from the client request.
+	req.Header = headers.Clone()
+	if ec.userAgent != "" {
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		req.Header.Set("User-Agent", ec.userAgent)
+	}
 	return ec.Client.Do(req)
 }
 
+// defaultRateLimitDelay is the wait before sending a rate limited request again when the node doesn't say how long to wait.
+const defaultRateLimitDelay = time.Second
+
+// retryAfterDelay returns the wait requested by a Retry-After header, in seconds or as an HTTP date, bounded by maxDelay.
+func retryAfterDelay(retryAfter string, now time.Time, maxDelay time.Duration) time.Duration {
+	delay := defaultRateLimitDelay
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(retryAfter); err == nil {
+		delay = date.Sub(now)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
 
-// sendTransaction sends a raw transaction to the Ethereum network.
+// sendTransaction sends a raw transaction to the Ethereum network through the broadcaster, the configured node by default.
 func (ec *EthClient) sendTransaction(ctx context.Context, hex string)( rpcError bool,err error) {
+	broadcaster := ec.broadcaster
+	if broadcaster == nil {
+		broadcaster = ec
+	}
+	_, rpcError, err = broadcaster.Broadcast(ctx, hex)
+	return rpcError, err
+}
+
+// Broadcast sends a raw transaction to the configured node, it's the default Broadcaster.
+func (ec *EthClient) Broadcast(ctx context.Context, rawHex string) (string, bool, error) {
 	reqBody, err := json.Marshal(types.JSONRPCRequest{
 		Jsonrpc: "2.0",
 		Method:  "eth_sendRawTransaction",
-		Params:  []interface{}{hex},
+		Params:  []interface{}{rawHex},
 		ID:      1,
 	})
 
 	if err != nil {
-		return false, err
+		return "", false, err
 	}
 	
 
-	resp, err := ec.doRequest(ctx,reqBody)
+	resp, err := ec.doRequestWithRetry(ctx, reqBody)
 	if err != nil {
-		return false,err
+		return "", false,err
 	}
 
 	if resp.Error != nil  {
-		return true,errors.New(resp.Error.Message)
+		return "", true,resp.Error
 	}
 
 	log.WithField(txHashField,resp.Result).Info("Transaction sent successfully")
 
-	return false,nil
+	txHash, _ := resp.Result.(string)
+	return txHash, false,nil
+}
+
+// SetBroadcaster routes the broadcasts through b instead of the configured node, it must be called before the monitor starts.
+func (ec *EthClient) SetBroadcaster(b Broadcaster) {
+	ec.broadcaster = b
+}
+
+// SetFlusher saves the stored transactions through f on shutdown, within the shutdown timeout, and every snapshot interval.
+// Without one, they're lost on exit. It must be called before StartSnapshots.
+func (ec *EthClient) SetFlusher(f Flusher) {
+	ec.flusher = f
 }
 
 // getGasPrice fetches the current gas price from the Ethereum network.
 func (ec *EthClient) getGasPrice(ctx context.Context) (float64, error) {
+	if ec.gasFetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ec.gasFetchTimeout)
+		defer cancel()
+	}
+
 	reqBody, err := json.Marshal(types.JSONRPCRequest{
 		Jsonrpc: "2.0",
 		Method:  "eth_gasPrice",
@@ -146,13 +475,13 @@ func (ec *EthClient) getGasPrice(ctx context.Context) (float64, error) {
 		return 0, err
 	}
 
-	resp, err := ec.doRequest(ctx, reqBody)
+	resp, err := ec.doRequestWithRetry(ctx, reqBody)
 	if err != nil {
 		return 0, err
 	}
 
 	if resp.Error != nil  {
-		return 0, errors.New(resp.Error.Message)
+		return 0, resp.Error
 	}
 
 	gasPrice, err := strconv.ParseInt(resp.Result.(string)[2:], 16, 64)
@@ -164,151 +493,1771 @@ func (ec *EthClient) getGasPrice(ctx context.Context) (float64, error) {
 }
 
 
-// StoreTransaction stores a transaction in memory.
-func (ec *EthClient) StoreTransaction( tx types.Transaction) error {
-	hash := tx.Hash().String()
-	isCancelingTx := false
-	for oldHash, oldTx := range ec.storedTransactions{
+// getTransactionCount fetches the pending nonce of an address from the Ethereum network.
+func (ec *EthClient) getTransactionCount(ctx context.Context, address common.Address) (uint64, error) {
+	reqBody, err := json.Marshal(types.JSONRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getTransactionCount",
+		Params:  []interface{}{address.Hex(), "pending"},
+		ID:      1,
+	})
+	if err != nil {
+		return 0, err
+	}
 
-		if oldHash == hash  {
-			// This returns an error because an Ethereum node will return an error as well with a message: "already known".
-			return fmt.Errorf("already %s",oldTx.Status.String())	
-		}
+	resp, err := ec.doRequestWithRetry(ctx, reqBody)
+	if err != nil {
+		return 0, err
+	}
 
-		// If the transaction is SPEDUP it means that there is another transaction stored that the user wanted to cancel or even speed up.
-		if oldTx.Status == types.SPEDUP {
-			continue
-		}
-		// Get the sender address from the oldtx.
-		oldFromAddress, err := ethTypes.Sender(ethTypes.LatestSignerForChainID(tx.ChainId()), &oldTx.Transaction)
-		if err != nil {
-			log.Error("failed to get sender address from stored transaction ",err)
-			continue
-		}	
+	if resp.Error != nil {
+		return 0, resp.Error
+	}
 
-		// Get the sender address from the new tx.
-		newFromAddress, err := ethTypes.Sender(ethTypes.LatestSignerForChainID(tx.ChainId()), &tx.Transaction)
-		if err != nil {
-			log.Error("failed to get sender address from new transaction ",err)
-			break
-		}
-		// If the same wallet is sending a transaction with the same nonce usually it's to either cancel or speed up a transaction.
-		if	oldFromAddress == newFromAddress && tx.Nonce() == oldTx.Nonce()  {
-			// the gas caps
-			gasCap := tx.GasFeeCap().Int64() + tx.GasTipCap().Int64()
-			oldGasCap := oldTx.GasFeeCap().Int64() + oldTx.GasTipCap().Int64()
-			// In case of a cancel transaction in a metamask way.
-			if  newFromAddress == *tx.To() && tx.Value().Int64() == 0  &&  gasCap > oldGasCap && len(tx.Data())== 0  {
-				isCancelingTx = true
-				err = ec.changeTransactionStatus(oldHash, types.CANCELED)
-				// This a way to ensure that all the transaction from the same sender are being cancelled in the scenario of a user
-				// cancelling a transaction then sending another one with the same nonce then trying to cancel it again.
-				if err != nil {
-					continue 
-				}
-				log.WithField(txHashField,oldHash).Info("Canceled transaction")
-			return nil
-			}
-			// In case of a speed up transaction in a metamask way.
-			if *tx.To() == *oldTx.To() && tx.Value().Int64() == oldTx.Value().Int64() &&  gasCap > oldGasCap && bytes.Equal(tx.Data(),oldTx.Data()) {
-				err = ec.changeTransactionStatus(oldHash, types.SPEDUP)
-				if err != nil {
-					return err
-				}
-				tx.Status = types.STORED
-				ec.storedTransactions[hash] = tx
-				log.WithField(txHashField,oldHash).Info("Sped up transaction")
-				return nil
-			}
-			
-		}
+	result, ok := resp.Result.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected transaction count: %v", resp.Result)
 	}
+	return hexutil.DecodeUint64(result)
+}
 
-	// No need to store cancelling transactions since subbmitting them will be a total loss of gas.
-	if isCancelingTx {
-		return nil
+// BlockNumber fetches the number of the latest block from the Ethereum network, e.g. to check it's reachable.
+func (ec *EthClient) BlockNumber(ctx context.Context) (uint64, error) {
+	reqBody, err := json.Marshal(types.JSONRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_blockNumber",
+		Params:  []interface{}{},
+		ID:      1,
+	})
+	if err != nil {
+		return 0, err
 	}
-	tx.Status = types.STORED
-	ec.storedTransactions[hash] = tx
-	log.WithField(txHashField,hash).Info("Stored transaction")
-	return nil
+
+	resp, err := ec.doRequestWithRetry(ctx, reqBody)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.Error != nil {
+		return 0, resp.Error
+	}
+
+	result, ok := resp.Result.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected block number: %v", resp.Result)
+	}
+	return hexutil.DecodeUint64(result)
 }
 
-// CancelTransaction changes the status of a transaction to canceled.
-func (ec *EthClient) CancelTransaction(hash string) error {
-err := ec.changeTransactionStatus(hash,types.CANCELED)
-if err != nil {
-	return err
+// getBalance fetches the latest balance of an address from the Ethereum network.
+func (ec *EthClient) getBalance(ctx context.Context, address common.Address) (*big.Int, error) {
+	reqBody, err := json.Marshal(types.JSONRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getBalance",
+		Params:  []interface{}{address.Hex(), "latest"},
+		ID:      1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ec.doRequestWithRetry(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	result, ok := resp.Result.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected balance: %v", resp.Result)
+	}
+	return hexutil.DecodeBig(result)
 }
-log.WithField(txHashField,hash).Info("Canceled transaction")
-return nil
+
+// errNoBaseFee is returned when the network has no base fee, i.e. before EIP-1559.
+var errNoBaseFee = errors.New("block has no base fee")
+
+// getBaseFee fetches the base fee of the latest block from the Ethereum network.
+func (ec *EthClient) getBaseFee(ctx context.Context) (*big.Int, error) {
+	return ec.getBlockBaseFee(ctx, "latest")
 }
 
-// changeTransactionStatus is a helper function that changes the status of a transaction.
-func  (ec *EthClient) changeTransactionStatus(hash string, newStatus types.TransactionStatus) error {
+// getBlockBaseFee fetches the base fee of a block, e.g. "latest" or "pending", from the Ethereum network.
+func (ec *EthClient) getBlockBaseFee(ctx context.Context, block string) (*big.Int, error) {
+	reqBody, err := json.Marshal(types.JSONRPCRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_getBlockByNumber",
+		Params:  []interface{}{block, false},
+		ID:      1,
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	ec.transactionsMutex.Lock()
-	defer ec.transactionsMutex.Unlock()
+	resp, err := ec.doRequestWithRetry(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
 
-	trx, ok := ec.storedTransactions[hash]
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected block: %v", resp.Result)
+	}
+	baseFee, ok := result["baseFeePerGas"].(string)
 	if !ok {
-		return errors.New("transaction not found") 
+		return nil, fmt.Errorf("%s %w", block, errNoBaseFee)
 	}
+	return hexutil.DecodeBig(baseFee)
+}
 
-	// Check if the new status is an allowed transition
-	for _, allowedStatus := range allowedTransitions[trx.Status] {
-		if newStatus == allowedStatus {
-			trx.Status = newStatus
-			ec.storedTransactions[hash] = trx
-			return nil
+// pendingBaseFee returns the base fee of the pending block when the broadcasts are base fee aware or a max gas price is set,
+// nil otherwise. nil makes the checks fall back to the gas price and the max gas price to the fee cap, which is also the case
+// on networks without base fee or when it can't be fetched.
+func (ec *EthClient) pendingBaseFee(ctx context.Context) *big.Int {
+	if !ec.baseFeeAware && ec.maxGasPrice == nil {
+		return nil
+	}
+	baseFee, err := ec.getBlockBaseFee(ctx, "pending")
+	if err != nil {
+		if !errors.Is(err, errNoBaseFee) {
+			log.Warn("failed to get the base fee, checking the gas price and the fee caps only: ", err)
 		}
+		return nil
 	}
+	return baseFee
+}
 
-	return fmt.Errorf("invalid status transition from %s to %s for transaction: %s", trx.Status.String(), newStatus.String(), hash)
+// checkReplacementFee applies the underpriced replacement policy to a speed-up, which can't be mined while its fee cap is below the base fee.
+// The replacement is accepted when the base fee can't be fetched.
+func (ec *EthClient) checkReplacementFee(tx *types.Transaction) error {
+	if ec.underpricedReplacement != config.UnderpricedWarn && ec.underpricedReplacement != config.UnderpricedReject {
+		return nil
+	}
+	baseFee, err := ec.getBaseFee(context.Background())
+	if err != nil {
+		log.WithField(txHashField, tx.Hash().String()).Error("failed to get base fee: ", err)
+		return nil
+	}
+	if tx.GasFeeCap().Cmp(baseFee) >= 0 {
+		return nil
+	}
+	if ec.underpricedReplacement == config.UnderpricedReject {
+		return fmt.Errorf("replacement underpriced: fee cap %s below base fee %s", tx.GasFeeCap(), baseFee)
+	}
+	log.WithField(txHashField, tx.Hash().String()).Warnf("Replacement fee cap %s below base fee %s", tx.GasFeeCap(), baseFee)
+	return nil
 }
 
-// MonitorGas monitors gas prices and submits transactions when the gas price is low enough.
-func (ec *EthClient) MonitorGas(ctx context.Context) {
-	ticker := time.NewTicker(ec.gasMonitoringFrequence)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ticker.C:
-			gasPrice, err := ec.getGasPrice(ctx)
-			if err != nil {
-				log.Error("failed to get gas price: ", err)
-				continue
-			}
-				for hash, tx := range ec.storedTransactions {
-					if tx.Status != types.STORED {
-						continue
-					}
-					if tx.GasFeeCap().Int64() +  tx.GasTipCap().Int64() >= int64(gasPrice) {
-						ec.transactionsMutex.Lock()
-						isRPCErr,err := ec.sendTransaction(ctx, tx.RawHex)
-						if err != nil {
-							log.Error("failed to send transaction: ", err)
-							// If invalid transaction e.g: nonce too low, already known transaction....
-							if isRPCErr {
-								ec.transactionsMutex.Unlock()
-								err = ec.changeTransactionStatus(hash,types.FAILED)
-								if err != nil {
-									// This error will never happen since only stored transaction are sent and the transaition from STORED to FAILED is allowed
-									log.Error(err.Error())
-								}
-							}
-						} else {
-							ec.transactionsMutex.Unlock()
-							err = ec.changeTransactionStatus(hash,types.BROADCASTED)
-							if err != nil {
-								// This error will never happen since only stored transaction are sent and the transaition from STORED to BROADCASTED is allowed
-								log.Error(err.Error())
-							}
-						}
-					}
-			}
-		case <-ctx.Done():
-			return
+// nextNonce returns the nonce following the sender's STORED transactions, or its pending on-chain nonce if higher.
+func (ec *EthClient) nextNonce(ctx context.Context, from common.Address) (uint64, error) {
+	next, err := ec.getTransactionCount(ctx, from)
+	if err != nil {
+		return 0, err
+	}
+
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+	for _, hash := range ec.senderIndex[from] {
+		tx := ec.storedTransactions[hash]
+		if tx.Status != types.STORED {
+			continue
+		}
+		tx = ec.expand(hash, tx)
+		if tx.Nonce() >= next {
+			next = tx.Nonce() + 1
 		}
 	}
+	return next, nil
+}
+
+// NextNonce returns the nonce a sender should use for its next transaction, so it doesn't collide with the queued ones.
+// It's the nonce following the sender's STORED transactions, or its pending on-chain nonce if higher.
+func (ec *EthClient) NextNonce(ctx context.Context, from common.Address) (uint64, error) {
+	next, err := ec.nextNonce(ctx, from)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the next nonce: %w", err)
+	}
+	return next, nil
+}
+
+// checkNonceGap rejects a transaction whose nonce is more than maxNonceGap ahead of the sender's next expected nonce.
+// The nonces of earlier, the transactions to be stored before it, are expected like the STORED ones.
+func (ec *EthClient) checkNonceGap(tx *types.Transaction, earlier []types.Transaction) error {
+	from, err := sender(tx)
+	if err != nil {
+		return fmt.Errorf("failed to get sender address: %w", err)
+	}
+	next, err := ec.nextNonce(context.Background(), from)
+	if err != nil {
+		return fmt.Errorf("failed to get the next nonce: %w", err)
+	}
+	for i := range earlier {
+		earlierFrom, err := sender(&earlier[i])
+		if err == nil && earlierFrom == from && earlier[i].Nonce() >= next {
+			next = earlier[i].Nonce() + 1
+		}
+	}
+	if tx.Nonce() > next+ec.maxNonceGap {
+		return fmt.Errorf("nonce gap too large: nonce %d, next expected nonce %d, max gap %d", tx.Nonce(), next, ec.maxNonceGap)
+	}
+	return nil
+}
+
+// checkSenderBalance rejects a transaction whose maximum cost, value + gas limit * fee cap, exceeds the sender's balance.
+func (ec *EthClient) checkSenderBalance(tx *types.Transaction) error {
+	from, err := sender(tx)
+	if err != nil {
+		return fmt.Errorf("failed to get sender address: %w", err)
+	}
+	balance, err := ec.getBalance(context.Background(), from)
+	if err != nil {
+		return fmt.Errorf("failed to get the sender balance: %w", err)
+	}
+	// The fee cap of a legacy transaction is its gas price.
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	cost.Add(cost, tx.Value())
+	if balance.Cmp(cost) < 0 {
+		return fmt.Errorf("insufficient balance: balance %s, max cost %s", balance, cost)
+	}
+	return nil
+}
+
+// StoreTransaction stores a transaction in memory.
+func (ec *EthClient) StoreTransaction( tx types.Transaction) error {
+	err := ec.storeTransaction(tx)
+	if err != nil {
+		return err
+	}
+	ec.checkStored(tx.Hash().String())
+	return nil
+}
+
+// checkStored checks in the background the just-stored transactions when broadcastOnStore is set, and the replacements
+// of broadcast transactions in any case, like CheckTransaction but with a single gas price fetch for all of them.
+// Shutdown cancels these checks and waits for them like for the monitor.
+func (ec *EthClient) checkStored(hashes ...string) {
+	if !ec.broadcastOnStore {
+		ec.transactionsMutex.RLock()
+		replacements := []string{}
+		for _, hash := range hashes {
+			if ec.storedTransactions[hash].ReplacesBroadcast {
+				replacements = append(replacements, hash)
+			}
+		}
+		ec.transactionsMutex.RUnlock()
+		hashes = replacements
+	}
+	if len(hashes) == 0 {
+		return
+	}
+	ec.monitorWG.Add(1)
+	go func() {
+		defer ec.monitorWG.Done()
+		// The gas price is fetched once for the whole batch.
+		ctx := ec.checksContext()
+		gasPrice, baseFee, err := ec.fetchGas(ctx)
+		if err != nil {
+			log.Error(err.Error())
+			return
+		}
+		for _, hash := range hashes {
+			tx, ok := ec.storedTransaction(hash)
+			if !ok {
+				log.WithField(txHashField, hash).Error("transaction not found")
+				continue
+			}
+			ec.checkTransaction(ctx, tx, gasPrice, baseFee)
+		}
+	}()
+}
+
+// checksContext returns the context of the checks started by checkStored, canceled by Shutdown.
+func (ec *EthClient) checksContext() context.Context {
+	if ec.checksCtx == nil {
+		return context.Background()
+	}
+	return ec.checksCtx
+}
+
+// storeTransaction validates a transaction and stores it.
+func (ec *EthClient) storeTransaction(tx types.Transaction) error {
+	checked, err := ec.validateTransaction(&tx, nil)
+	if err != nil {
+		return err
+	}
+
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+	return ec.storeValidated(tx, checked, nil)
+}
+
+// validation is what the checks of validateTransaction leave to the storing of a transaction.
+type validation struct {
+	// token is the sender limiter token taken by the transaction, nil without a sender limit.
+	token *senderToken
+	// replacementErr is the outcome of the replacement fee check, returned if the transaction turns out to be a speed-up.
+	replacementErr error
+}
+
+// validateTransaction runs the checks of a transaction that don't need the store lock, the calls to the node included.
+// earlier are the transactions submitted along with it and stored before it, they count like the stored ones.
+func (ec *EthClient) validateTransaction(tx *types.Transaction, earlier []types.Transaction) (validation, error) {
+	checked := validation{}
+	// A transaction can decode without a valid signature, it would never be accepted by the node.
+	_, err := sender(tx)
+	if err != nil {
+		return checked, errors.New("unsigned transaction")
+	}
+
+	// Reject legacy transactions without EIP-155 replay protection since they are valid on any chain.
+	if !tx.Protected() && !ec.allowUnprotected {
+		return checked, errors.New("missing replay protection")
+	}
+
+	// Guard against transactions signed for another chain, e.g. when the node URL is a generic one.
+	// Unprotected transactions have no chain id, they're only accepted when allowUnprotected is set.
+	if ec.allowedChainIDs != nil && tx.Protected() {
+		chainID := tx.ChainId()
+		if !chainID.IsUint64() || !ec.allowedChainIDs[chainID.Uint64()] {
+			return checked, fmt.Errorf("chain id %s not allowed", chainID)
+		}
+	}
+
+	// The raw hex is what gets broadcast, it must be the exact encoding of the decoded transaction.
+	err = checkEncoding(tx)
+	if err != nil {
+		return checked, err
+	}
+
+	// The tip cap of a legacy transaction is its gas price.
+	if ec.rejectZeroTip && tx.GasTipCap().Sign() == 0 {
+		return checked, errors.New("zero priority fee")
+	}
+
+	// Bound the calldata kept in memory for each transaction.
+	if ec.maxTxDataBytes > 0 && len(tx.Data()) > ec.maxTxDataBytes {
+		return checked, fmt.Errorf("transaction data too large: %d bytes, max %d", len(tx.Data()), ec.maxTxDataBytes)
+	}
+
+	// Throttle the senders before any call to the node.
+	if ec.senderLimit > 0 {
+		checked.token, err = ec.takeSenderToken(tx)
+		if err != nil {
+			return checked, err
+		}
+	}
+
+	// Reject transactions that would wait forever for the intermediate nonces.
+	if ec.maxNonceGap > 0 {
+		err := ec.checkNonceGap(tx, earlier)
+		if err != nil {
+			return checked, err
+		}
+	}
+
+	// Don't queue transactions the sender can't afford, the node would reject them anyway.
+	if ec.checkBalance {
+		err := ec.checkSenderBalance(tx)
+		if err != nil {
+			return checked, err
+		}
+	}
+
+	// The fee of a speed-up is checked against the node, before the store is locked.
+	if ec.sharesNonce(tx, earlier) {
+		checked.replacementErr = ec.checkReplacementFee(tx)
+	}
+	return checked, nil
+}
+
+// storeValidated stores a transaction that passed validateTransaction, recording its changes to the store in journal
+// when not nil so they can be undone. The caller holds the store lock.
+func (ec *EthClient) storeValidated(tx types.Transaction, checked validation, journal *storeJournal) error {
+	hash := tx.Hash().String()
+	isCancelingTx := false
+	for oldHash, oldTx := range ec.storedTransactions{
+
+		if oldHash == hash  {
+			// This returns an error because an Ethereum node will return an error as well with a message: "already known".
+			return fmt.Errorf("already %s",oldTx.Status.String())	
+		}
+
+		// If the transaction is SPEDUP it means that there is another transaction stored that the user wanted to cancel or even speed up.
+		if oldTx.Status == types.SPEDUP {
+			continue
+		}
+		oldTx = ec.expand(oldHash, oldTx)
+		// Get the sender address from the oldtx.
+		oldFromAddress, err := ethTypes.Sender(ethTypes.LatestSignerForChainID(tx.ChainId()), &oldTx.Transaction)
+		if err != nil {
+			log.Error("failed to get sender address from stored transaction ",err)
+			continue
+		}	
+
+		// Get the sender address from the new tx.
+		newFromAddress, err := ethTypes.Sender(ethTypes.LatestSignerForChainID(tx.ChainId()), &tx.Transaction)
+		if err != nil {
+			log.Error("failed to get sender address from new transaction ",err)
+			break
+		}
+		// If the same wallet is sending a transaction with the same nonce usually it's to either cancel or speed up a transaction.
+		if	oldFromAddress == newFromAddress && tx.Nonce() == oldTx.Nonce()  {
+			// the gas caps
+			gasCap := tx.GasFeeCap().Int64() + tx.GasTipCap().Int64()
+			oldGasCap := oldTx.GasFeeCap().Int64() + oldTx.GasTipCap().Int64()
+			// In case of a cancel transaction in a metamask way.
+			if  newFromAddress == *tx.To() && tx.Value().Int64() == 0  &&  gasCap > oldGasCap && len(tx.Data())== 0  {
+				isCancelingTx = true
+				// The same cancel was submitted before. Since cancels are never stored, resubmitting one is a no-op
+				// that succeeds like the first submission, whatever the order the stored transactions are visited in.
+				if oldTx.Status == types.CANCELED {
+					continue
+				}
+				err = ec.setStatus(oldHash, types.CANCELED, false, journal)
+				// A transaction being sent can't be canceled anymore.
+				if errors.Is(err, ErrAlreadyBroadcast) {
+					return err
+				}
+				// This a way to ensure that all the transaction from the same sender are being cancelled in the scenario of a user
+				// cancelling a transaction then sending another one with the same nonce then trying to cancel it again.
+				if err != nil {
+					continue 
+				}
+				journal.recordStatus(oldHash, oldTx)
+				log.WithField(txHashField,oldHash).Info("Canceled transaction")
+			return nil
+			}
+			// In case of a speed up transaction in a metamask way.
+			if *tx.To() == *oldTx.To() && tx.Value().Int64() == oldTx.Value().Int64() &&  gasCap > oldGasCap && bytes.Equal(tx.Data(),oldTx.Data()) {
+				if checked.replacementErr != nil {
+					return checked.replacementErr
+				}
+				err = ec.setStatus(oldHash, types.SPEDUP, false, journal)
+				if err != nil {
+					return err
+				}
+				journal.recordStatus(oldHash, oldTx)
+				tx.Status = types.STORED
+				// The original is in the mempool already, waiting for the gas price would only delay its replacement.
+				tx.ReplacesBroadcast = oldTx.Status == types.BROADCASTED
+				ec.insertTransaction(hash, tx, journal)
+				journal.recordAdded(hash)
+				log.WithField(txHashField,oldHash).Info("Sped up transaction")
+				return nil
+			}
+			
+		}
+	}
+
+	// No need to store cancelling transactions since subbmitting them will be a total loss of gas.
+	// This is also the path of a resubmitted cancel, whose target is already CANCELED.
+	if isCancelingTx {
+		return nil
+	}
+	if ec.maxStoredTx > 0 {
+		err := ec.makeRoom(journal)
+		if err != nil {
+			return err
+		}
+	}
+	tx.Status = types.STORED
+	ec.insertTransaction(hash, tx, journal)
+	journal.recordAdded(hash)
+	log.WithField(txHashField,hash).Info("Stored transaction")
+	return nil
+}
+
+// sharesNonce reports whether a transaction has the nonce of a stored transaction of its sender or of one of earlier,
+// i.e. may replace it.
+func (ec *EthClient) sharesNonce(tx *types.Transaction, earlier []types.Transaction) bool {
+	from, err := sender(tx)
+	if err != nil {
+		return false
+	}
+	for i := range earlier {
+		earlierFrom, err := sender(&earlier[i])
+		if err == nil && earlierFrom == from && earlier[i].Nonce() == tx.Nonce() {
+			return true
+		}
+	}
+
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+	for _, hash := range ec.senderIndex[from] {
+		stored := ec.storedTransactions[hash]
+		if stored.Status == types.SPEDUP {
+			continue
+		}
+		stored = ec.expand(hash, stored)
+		if stored.Nonce() == tx.Nonce() {
+			return true
+		}
+	}
+	return false
+}
+
+// addTransaction stores a transaction and indexes it by sender.
+func (ec *EthClient) addTransaction(hash string, tx types.Transaction) {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+	ec.insertTransaction(hash, tx, nil)
+}
+
+// insertTransaction stores a transaction and indexes it by sender, counting it as stored through journal. The caller holds the store lock.
+func (ec *EthClient) insertTransaction(hash string, tx types.Transaction, journal *storeJournal) {
+	tx.StoredAt = time.Now()
+	// A deadline already bounds how long the transaction is queued.
+	if ec.txTTL > 0 && tx.Deadline.IsZero() {
+		tx.ExpiresAt = tx.StoredAt.Add(ec.txTTL)
+	}
+	tx.StoreGasPrice = ec.LastGasPrice()
+	ec.storedTransactions[hash] = ec.compact(tx)
+	ec.countStored(journal)
+
+	from, err := sender(&tx)
+	if err != nil {
+		log.WithField(txHashField, hash).Error("failed to index transaction by sender: ", err)
+		return
+	}
+	if ec.senderIndex == nil {
+		ec.senderIndex = make(map[common.Address][]string)
+	}
+	ec.senderIndex[from] = append(ec.senderIndex[from], hash)
+}
+
+// checkEncoding verifies that a transaction re-encodes to its submitted raw hex, when it has one.
+func checkEncoding(tx *types.Transaction) error {
+	if tx.RawHex == "" {
+		return nil
+	}
+	encoded, err := tx.MarshalBinary()
+	if err != nil || !strings.EqualFold(hexutil.Encode(encoded), tx.RawHex) {
+		return errors.New("malformed transaction encoding")
+	}
+	return nil
+}
+
+// senderToken is a token taken from the bucket of a sender.
+type senderToken struct {
+	reservation *rate.Reservation
+	takenAt     time.Time
+}
+
+// giveBack puts the token back in the bucket. A reservation that acted at once can only be canceled as of when it was made.
+func (t *senderToken) giveBack() {
+	if t == nil {
+		return
+	}
+	t.reservation.CancelAt(t.takenAt)
+}
+
+// takeSenderToken takes a token from the bucket of the transaction sender.
+func (ec *EthClient) takeSenderToken(tx *types.Transaction) (*senderToken, error) {
+	from, err := sender(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sender address: %w", err)
+	}
+
+	ec.limitersMutex.Lock()
+	if ec.senderLimiters == nil {
+		ec.senderLimiters = make(map[common.Address]*rate.Limiter)
+	}
+	limiter, ok := ec.senderLimiters[from]
+	if !ok {
+		limiter = rate.NewLimiter(ec.senderLimit, ec.senderBurst)
+		ec.senderLimiters[from] = limiter
+	}
+	ec.limitersMutex.Unlock()
+
+	now := time.Now()
+	reservation := limiter.ReserveN(now, 1)
+	if retryAfter := reservation.DelayFrom(now); retryAfter > 0 {
+		// Don't keep a token that isn't available yet.
+		reservation.CancelAt(now)
+		return nil, &SenderRateLimitError{RetryAfter: retryAfter}
+	}
+	return &senderToken{reservation: reservation, takenAt: now}, nil
+}
+
+// makeRoom checks that another transaction can be stored, evicting the oldest STORED transaction at capacity when configured to.
+// A transaction being broadcast is not evicted. The caller holds the store lock.
+func (ec *EthClient) makeRoom(journal *storeJournal) error {
+	stored := 0
+	oldestHash := ""
+	var oldest types.Transaction
+	for hash, tx := range ec.storedTransactions {
+		if tx.Status != types.STORED {
+			continue
+		}
+		stored++
+		if tx.InFlight {
+			continue
+		}
+		if oldestHash == "" || tx.StoredAt.Before(oldest.StoredAt) {
+			oldestHash, oldest = hash, tx
+		}
+	}
+	if stored < ec.maxStoredTx {
+		return nil
+	}
+	// All the STORED transactions may be being broadcast.
+	if !ec.evictOldest || oldestHash == "" {
+		return errors.New("transaction store is full")
+	}
+
+	journal.recordStatus(oldestHash, oldest)
+	oldest.Status = types.FAILED
+	oldest.Reason = evictedReason
+	ec.storedTransactions[oldestHash] = oldest
+	ec.notifyStatusChange(journal, oldestHash, types.STORED, types.FAILED)
+	log.WithField(txHashField, oldestHash).Warn("Evicted transaction")
+	return nil
+}
+
+// CancelTransaction changes the status of a transaction to canceled.
+func (ec *EthClient) CancelTransaction(hash string) error {
+err := ec.changeTransactionStatus(hash,types.CANCELED)
+if err != nil {
+	return err
+}
+log.WithField(txHashField,hash).Info("Canceled transaction")
+return nil
+}
+
+// GetTransaction returns the view of a stored transaction.
+func (ec *EthClient) GetTransaction(hash string) (types.TransactionView, error) {
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return types.TransactionView{}, errors.New("transaction not found")
+	}
+	tx = ec.expand(hash, tx)
+	view := newTransactionView(hash, &tx)
+	for _, stored := range ec.storedTransactions {
+		if stored.Status == types.STORED {
+			view.QueueDepth++
+		}
+	}
+	if tx.Status == types.STORED {
+		view.SenderPosition = ec.senderPosition(&tx)
+	}
+	return view, nil
+}
+
+// TransactionStatuses returns the status of each given transaction by hash, StatusNotFound for the unknown ones.
+// The statuses are read at once, so they are consistent with each other.
+func (ec *EthClient) TransactionStatuses(hashes []string) map[string]string {
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+
+	statuses := make(map[string]string, len(hashes))
+	for _, hash := range hashes {
+		tx, ok := ec.storedTransactions[hash]
+		if !ok {
+			statuses[hash] = StatusNotFound
+			continue
+		}
+		statuses[hash] = tx.Status.String()
+	}
+	return statuses
+}
+
+// StatusNotFound is the status returned by TransactionStatuses for the transactions that aren't stored.
+const StatusNotFound = "not_found"
+
+// newTransactionView returns the view of a transaction, without its queue position.
+func newTransactionView(hash string, tx *types.Transaction) types.TransactionView {
+	nonce := hexutil.Uint64(tx.Nonce())
+	view := types.TransactionView{
+		Hash:      hash,
+		Status:    tx.Status.String(),
+		RawHex:    tx.RawHex,
+		Reason:    tx.Reason,
+		Nonce:     &nonce,
+		GasFeeCap: (*hexutil.Big)(tx.GasFeeCap()),
+		GasTipCap: (*hexutil.Big)(tx.GasTipCap()),
+		Label:     tx.Label,
+		Attempts:  tx.Attempts,
+	}
+	if !tx.ExpiresAt.IsZero() {
+		expiresAt := tx.ExpiresAt
+		view.ExpiresAt = &expiresAt
+	}
+	if from, err := sender(tx); err == nil {
+		view.From = from.Hex()
+	}
+	return view
+}
+
+// ListTransactions returns the views of every stored transaction, ordered by sender then nonce.
+// The transactions are copied under the lock and the views built once it's released.
+func (ec *EthClient) ListTransactions() []types.TransactionView {
+	transactions := ec.transactionsSnapshot()
+
+	views := make([]types.TransactionView, 0, len(transactions))
+	// The nonces of the STORED transactions of each sender give their positions, like senderPosition.
+	storedNonces := map[string][]uint64{}
+	for i := range transactions {
+		tx := &transactions[i]
+		view := newTransactionView(tx.Hash().String(), tx)
+		if tx.Status == types.STORED && view.From != "" {
+			storedNonces[view.From] = append(storedNonces[view.From], tx.Nonce())
+		}
+		views = append(views, view)
+	}
+
+	queueDepth := 0
+	for i := range transactions {
+		if transactions[i].Status == types.STORED {
+			queueDepth++
+		}
+	}
+	for i := range views {
+		views[i].QueueDepth = queueDepth
+		if transactions[i].Status != types.STORED || views[i].From == "" {
+			continue
+		}
+		views[i].SenderPosition = 1
+		for _, nonce := range storedNonces[views[i].From] {
+			if nonce < transactions[i].Nonce() {
+				views[i].SenderPosition++
+			}
+		}
+	}
+	return views
+}
+
+// senderPosition returns the 1-based position of a STORED transaction among the STORED transactions of its sender, by nonce.
+// The caller must hold transactionsMutex.
+func (ec *EthClient) senderPosition(tx *types.Transaction) int {
+	from, err := sender(tx)
+	if err != nil {
+		return 0
+	}
+	position := 1
+	for _, hash := range ec.senderIndex[from] {
+		other := ec.storedTransactions[hash]
+		if other.Status != types.STORED {
+			continue
+		}
+		other = ec.expand(hash, other)
+		if other.Nonce() < tx.Nonce() {
+			position++
+		}
+	}
+	return position
+}
+
+// CancelTransactionByNonce cancels the STORED transaction of the sender with the given nonce and returns its hash.
+// The lookup and the cancellation hold the same lock, so the transaction can't change in between.
+func (ec *EthClient) CancelTransactionByNonce(from common.Address, nonce uint64) (string, error) {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	hash := ""
+	for _, h := range ec.senderIndex[from] {
+		tx := ec.storedTransactions[h]
+		if tx.Status != types.STORED {
+			continue
+		}
+		tx = ec.expand(h, tx)
+		if tx.Nonce() == nonce {
+			hash = h
+			break
+		}
+	}
+	if hash == "" {
+		return "", errors.New("transaction not found")
+	}
+	if err := ec.setStatus(hash, types.CANCELED, false, nil); err != nil {
+		return "", err
+	}
+	log.WithField(txHashField, hash).Info("Canceled transaction")
+	return hash, nil
+}
+
+// CancelableTransactions returns the hashes of the transactions of a sender that can still be canceled, ordered by nonce:
+// the STORED ones that aren't being broadcast.
+func (ec *EthClient) CancelableTransactions(from common.Address) []string {
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+
+	cancelable := []types.Transaction{}
+	for _, hash := range ec.senderIndex[from] {
+		tx := ec.storedTransactions[hash]
+		if tx.Status != types.STORED || tx.InFlight {
+			continue
+		}
+		cancelable = append(cancelable, ec.expand(hash, tx))
+	}
+	sort.Slice(cancelable, func(i, j int) bool {
+		return cancelable[i].Nonce() < cancelable[j].Nonce()
+	})
+
+	hashes := make([]string, 0, len(cancelable))
+	for i := range cancelable {
+		hashes = append(hashes, cancelable[i].Hash().String())
+	}
+	return hashes
+}
+
+// ErrAlreadyBroadcast is returned by CancelIfStored when the transaction was sent, or is being sent, to the network,
+// and by the status changes of a transaction being sent.
+var ErrAlreadyBroadcast = errors.New("already broadcast")
+
+// CancelIfStored cancels a transaction only if it's still STORED and not being broadcast, checking and cancelling it in one locked operation.
+// Unlike CancelTransaction, a transaction picked by the monitor in between is never reported as canceled.
+func (ec *EthClient) CancelIfStored(hash string) error {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return errors.New("transaction not found")
+	}
+	if tx.InFlight || tx.Status == types.BROADCASTED {
+		return ErrAlreadyBroadcast
+	}
+	if tx.Status != types.STORED {
+		return fmt.Errorf("transaction is %s, not STORED", tx.Status.String())
+	}
+	tx.Status = types.CANCELED
+	ec.storedTransactions[hash] = tx
+	ec.publishStatusChange(hash, types.STORED, types.CANCELED)
+	log.WithField(txHashField, hash).Info("Canceled transaction")
+	return nil
+}
+
+// changeTransactionStatus is a helper function that changes the status of a transaction.
+func  (ec *EthClient) changeTransactionStatus(hash string, newStatus types.TransactionStatus) error {
+
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+	return ec.setStatus(hash, newStatus, false, nil)
+}
+
+// settleBroadcast changes the status of a transaction claimed by claimBroadcast once it was sent or rejected.
+func (ec *EthClient) settleBroadcast(hash string, newStatus types.TransactionStatus) error {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+	return ec.setStatus(hash, newStatus, true, nil)
+}
+
+// setStatus changes the status of a transaction if the transition is allowed. A STORED transaction being broadcast can
+// only become BROADCASTED, unless the change comes from its broadcaster, i.e. claimed is set. The change is published through
+// journal. The caller holds the store lock.
+func (ec *EthClient) setStatus(hash string, newStatus types.TransactionStatus, claimed bool, journal *storeJournal) error {
+	trx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return errors.New("transaction not found")
+	}
+	if trx.InFlight && !claimed && trx.Status == types.STORED && newStatus != types.BROADCASTED {
+		return fmt.Errorf("cannot move transaction %s to %s: %w", hash, newStatus.String(), ErrAlreadyBroadcast)
+	}
+
+	// Check if the new status is an allowed transition
+	for _, allowedStatus := range allowedTransitions[trx.Status] {
+		if newStatus == allowedStatus {
+			oldStatus := trx.Status
+			trx.Status = newStatus
+			ec.storedTransactions[hash] = trx
+			ec.notifyStatusChange(journal, hash, oldStatus, newStatus)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid status transition from %s to %s for transaction: %s", trx.Status.String(), newStatus.String(), hash)
+}
+
+// SetTransactionStatus moves a transaction to another status on an operator's request, e.g. to fail a stuck transaction.
+// It's subject to the same allowed transitions as the automatic changes.
+func (ec *EthClient) SetTransactionStatus(hash string, status types.TransactionStatus) error {
+	err := ec.changeTransactionStatus(hash, status)
+	if err != nil {
+		return err
+	}
+	log.WithField(txHashField, hash).Warn("Transaction status set manually to ", status.String())
+	return nil
+}
+
+// CompareAndSetStatus moves a transaction to status next only if its current status is expected, checking and changing it
+// in one locked operation, and reports whether it did. A STORED transaction being broadcast doesn't match, its status is about to change.
+// An unknown transaction or a transition that isn't allowed from expected is an error.
+func (ec *EthClient) CompareAndSetStatus(hash string, expected, next types.TransactionStatus) (bool, error) {
+	if !transitionAllowed(expected, next) {
+		return false, fmt.Errorf("invalid status transition from %s to %s for transaction: %s", expected.String(), next.String(), hash)
+	}
+
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return false, errors.New("transaction not found")
+	}
+	if tx.Status != expected || tx.InFlight {
+		return false, nil
+	}
+	tx.Status = next
+	ec.storedTransactions[hash] = tx
+	ec.publishStatusChange(hash, expected, next)
+	return true, nil
+}
+
+// transitionAllowed reports whether a transaction can move from status from to status to.
+func transitionAllowed(from, to types.TransactionStatus) bool {
+	for _, allowed := range allowedTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// isPermanentBroadcastError reports whether a node error means the transaction will never be accepted.
+func isPermanentBroadcastError(err error) bool {
+	message := strings.ToLower(err.Error())
+	for _, permanent := range permanentBroadcastErrors {
+		if strings.Contains(message, permanent) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordBroadcastError counts a transient broadcast error of a transaction and returns its total.
+func (ec *EthClient) recordBroadcastError(hash string) int {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return 0
+	}
+	tx.BroadcastErrors++
+	ec.storedTransactions[hash] = tx
+	return tx.BroadcastErrors
+}
+
+// recordAttempt appends the outcome of sending a transaction at gasPrice to its attempt history, dropping the oldest attempts beyond attemptHistorySize.
+func (ec *EthClient) recordAttempt(hash string, gasPrice float64, isRPCErr bool, sendErr error) {
+	if ec.attemptHistorySize <= 0 {
+		return
+	}
+	gasPriceInt, _ := big.NewFloat(gasPrice).Int(nil)
+	attempt := types.BroadcastAttempt{Time: time.Now().UTC(), GasPrice: (*hexutil.Big)(gasPriceInt), Outcome: types.AttemptSent}
+	if sendErr != nil {
+		attempt.Outcome = types.AttemptUnreachable
+		if isRPCErr {
+			attempt.Outcome = types.AttemptRejected
+		}
+		attempt.Error = sendErr.Error()
+	}
+
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return
+	}
+	// A new slice, so that the copies of the transaction handed out don't see the history change.
+	attempts := append(make([]types.BroadcastAttempt, 0, len(tx.Attempts)+1), tx.Attempts...)
+	attempts = append(attempts, attempt)
+	if len(attempts) > ec.attemptHistorySize {
+		attempts = attempts[len(attempts)-ec.attemptHistorySize:]
+	}
+	tx.Attempts = attempts
+	ec.storedTransactions[hash] = tx
+}
+
+// publishStatusChange notifies the status change subscribers, it's called with transactionsMutex held so events keep the order of the changes.
+func (ec *EthClient) publishStatusChange(hash string, from, to types.TransactionStatus) {
+	ec.statusTotals[to].Add(1)
+	ec.events.publish(types.StatusChange{
+		Hash: hash,
+		From: from.String(),
+		To:   to.String(),
+		Time: time.Now(),
+	}, isFinalStatus(to))
+}
+
+// StatusTotals returns the number of transactions moved to each status since the client started, by status name.
+// A transaction is counted once per status it went through, e.g. both as STORED and BROADCASTED.
+func (ec *EthClient) StatusTotals() map[string]uint64 {
+	totals := make(map[string]uint64, len(ec.statusTotals))
+	for status := range ec.statusTotals {
+		totals[types.TransactionStatus(status).String()] = ec.statusTotals[status].Load()
+	}
+	return totals
+}
+
+// isFinalStatus reports whether a transaction is done with the queue: broadcast, or in a status it can't leave.
+// A broadcast transaction can still be sped up, the replacement is tracked as a transaction of its own.
+func isFinalStatus(status types.TransactionStatus) bool {
+	return status == types.BROADCASTED || len(allowedTransitions[status]) == 0
+}
+
+// SubscribeStatusChanges returns a channel receiving every status change, and the function to call once done with it.
+// The channel is closed on unsubscribe, or when the subscriber falls behind and slow subscribers are disconnected.
+func (ec *EthClient) SubscribeStatusChanges() (<-chan types.StatusChange, func()) {
+	sub := ec.events.subscribe("")
+	return sub.ch, func() { ec.events.unsubscribe(sub) }
+}
+
+// SubscribeTransaction returns a channel receiving the status changes of a stored transaction, and the function to call once done with it.
+// The channel is closed after the change to a final status, right away if the transaction already has one.
+func (ec *EthClient) SubscribeTransaction(hash string) (<-chan types.StatusChange, func(), error) {
+	// Holding the lock guarantees no change is published between the status check and the subscription.
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return nil, nil, errors.New("transaction not found")
+	}
+	sub := ec.events.subscribe(hash)
+	unsubscribe := func() { ec.events.unsubscribe(sub) }
+	if isFinalStatus(tx.Status) {
+		unsubscribe()
+	}
+	return sub.ch, unsubscribe, nil
+}
+
+// SubscriberStats returns the number of status change subscribers and the transactions they watch.
+func (ec *EthClient) SubscriberStats() types.SubscriberStats {
+	return ec.events.stats()
+}
+
+// StatusTransitions returns the allowed status transitions as status name to allowed next status names.
+func (ec *EthClient) StatusTransitions() map[string][]string {
+	transitions := make(map[string][]string, len(allowedTransitions))
+	for from, allowed := range allowedTransitions {
+		names := make([]string, 0, len(allowed))
+		for _, to := range allowed {
+			names = append(names, to.String())
+		}
+		transitions[from.String()] = names
+	}
+	return transitions
+}
+
+// sender recovers the address that signed the transaction.
+func sender(tx *types.Transaction) (common.Address, error) {
+	return ethTypes.Sender(ethTypes.LatestSignerForChainID(tx.ChainId()), &tx.Transaction)
+}
+
+// transactionsSnapshot returns a copy of the stored transactions ordered by sender, then nonce, then hash.
+// Iterating the map directly gives a random order, this one is stable between calls and broadcasts a sender's transactions in nonce order.
+func (ec *EthClient) transactionsSnapshot() []types.Transaction {
+	ec.transactionsMutex.RLock()
+	transactions := make([]types.Transaction, 0, len(ec.storedTransactions))
+	hashes := make([]string, 0, len(ec.storedTransactions))
+	for hash, tx := range ec.storedTransactions {
+		transactions = append(transactions, tx)
+		hashes = append(hashes, hash)
+	}
+	ec.transactionsMutex.RUnlock()
+
+	// The decoding of a compact store is done without holding the lock.
+	for i := range transactions {
+		transactions[i] = ec.expand(hashes[i], transactions[i])
+	}
+
+	// Transactions whose sender can't be recovered are sorted under the zero address.
+	senders := make(map[common.Hash]common.Address, len(transactions))
+	for i := range transactions {
+		senders[transactions[i].Hash()], _ = sender(&transactions[i])
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := &transactions[i], &transactions[j]
+		if cmp := bytes.Compare(senders[a.Hash()].Bytes(), senders[b.Hash()].Bytes()); cmp != 0 {
+			return cmp < 0
+		}
+		if a.Nonce() != b.Nonce() {
+			return a.Nonce() < b.Nonce()
+		}
+		return bytes.Compare(a.Hash().Bytes(), b.Hash().Bytes()) < 0
+	})
+	return transactions
+}
+
+// Transactions returns a copy of the stored transactions, ordered like transactionsSnapshot.
+func (ec *EthClient) Transactions() []types.Transaction {
+	return ec.transactionsSnapshot()
+}
+
+// setLastBaseFee caches the pending base fee observed by the monitor, nil when it wasn't.
+func (ec *EthClient) setLastBaseFee(baseFee *big.Int) {
+	ec.gasPriceMutex.Lock()
+	defer ec.gasPriceMutex.Unlock()
+	ec.lastBaseFee = baseFee
+}
+
+// lastObservedBaseFee returns the pending base fee observed by the last monitor tick, nil if none was.
+func (ec *EthClient) lastObservedBaseFee() *big.Int {
+	ec.gasPriceMutex.RLock()
+	defer ec.gasPriceMutex.RUnlock()
+	return ec.lastBaseFee
+}
+
+// setLastGasPrice caches the gas price observed by the monitor and records it in the gas history.
+func (ec *EthClient) setLastGasPrice(gasPrice float64) {
+	ec.gasHistory.add(gasPrice, time.Now())
+	ec.gasPriceMutex.Lock()
+	defer ec.gasPriceMutex.Unlock()
+	ec.lastGasPrice = gasPrice
+}
+
+// GasHistory returns the last gas prices observed by the monitor, oldest first.
+func (ec *EthClient) GasHistory() []types.GasObservation {
+	return ec.gasHistory.snapshot()
+}
+
+// RefreshGasPrice fetches the gas price without waiting for the next monitor tick and caches it.
+func (ec *EthClient) RefreshGasPrice(ctx context.Context) (float64, error) {
+	gasPrice, err := ec.getGasPrice(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	ec.setLastGasPrice(gasPrice)
+	return gasPrice, nil
+}
+
+// LastGasPrice returns the gas price observed by the last monitor tick, 0 if none was observed yet.
+func (ec *EthClient) LastGasPrice() float64 {
+	ec.gasPriceMutex.RLock()
+	defer ec.gasPriceMutex.RUnlock()
+	return ec.lastGasPrice
+}
+
+// isEligible reports whether the transaction's gas caps meet the network pricing, i.e. whether it should be broadcast.
+// With a base fee, a dynamic fee transaction is eligible once its fee cap covers the base fee plus its tip,
+// the legacy transactions and the networks without base fee compare the caps to the gas price.
+func isEligible(tx *types.Transaction, gasPrice float64, baseFee *big.Int) bool {
+	if baseFee != nil && tx.Type() >= ethTypes.DynamicFeeTxType {
+		return tx.GasFeeCap().Cmp(new(big.Int).Add(baseFee, tx.GasTipCap())) >= 0
+	}
+	return tx.GasFeeCap().Int64()+tx.GasTipCap().Int64() >= int64(gasPrice)
+}
+
+// eligibilityBaseFee returns the base fee isEligible checks against, nil unless the broadcasts are base fee aware.
+func (ec *EthClient) eligibilityBaseFee(baseFee *big.Int) *big.Int {
+	if !ec.baseFeeAware {
+		return nil
+	}
+	return baseFee
+}
+
+// effectiveGasPrice returns the price per gas a transaction pays at a base fee: the gas price of a legacy transaction,
+// the lesser of the fee cap and the base fee plus the tip of a dynamic fee one. Without a base fee, it's the fee cap, the most it can pay.
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil || tx.Type() < ethTypes.DynamicFeeTxType {
+		return tx.GasFeeCap()
+	}
+	price := new(big.Int).Add(baseFee, tx.GasTipCap())
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		return tx.GasFeeCap()
+	}
+	return price
+}
+
+// exceedsMaxGasPrice reports whether the price per gas a transaction pays at a base fee is above maxGasPrice.
+func (ec *EthClient) exceedsMaxGasPrice(tx *types.Transaction, baseFee *big.Int) bool {
+	return ec.maxGasPrice != nil && effectiveGasPrice(tx, baseFee).Cmp(ec.maxGasPrice) > 0
+}
+
+// EligibleTransactions returns the hashes of the STORED transactions that the next monitor tick would broadcast at the last observed gas price.
+// A read-only instance broadcasts none, and with broadcastConfirmTicks the next tick must complete the consecutive eligible checks.
+func (ec *EthClient) EligibleTransactions() ([]string, error) {
+	if ec.readOnly {
+		return []string{}, nil
+	}
+	gasPrice := ec.LastGasPrice()
+	if gasPrice == 0 {
+		return nil, errors.New("gas price not observed yet")
+	}
+
+	baseFee := ec.lastObservedBaseFee()
+	hashes := []string{}
+	now := time.Now()
+	for _, tx := range ec.transactionsSnapshot() {
+		if tx.Status != types.STORED || ec.expired(&tx, now) || ec.exceedsMaxGasPrice(&tx, baseFee) {
+			continue
+		}
+		confirmed := ec.broadcastConfirmTicks <= 1 || tx.EligibleTicks+1 >= ec.broadcastConfirmTicks
+		if tx.ReplacesBroadcast || deadlineReached(&tx, now) || (isEligible(&tx, gasPrice, ec.eligibilityBaseFee(baseFee)) && confirmed) {
+			hashes = append(hashes, tx.Hash().String())
+		}
+	}
+	return hashes, nil
+}
+
+// TransactionsByStatus returns the sorted hashes of the stored transactions with a status.
+func (ec *EthClient) TransactionsByStatus(status types.TransactionStatus) []string {
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+
+	hashes := []string{}
+	for hash, tx := range ec.storedTransactions {
+		if tx.Status == status {
+			hashes = append(hashes, hash)
+		}
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+// GasStats returns the minimum, maximum and average gas caps of the STORED transactions along with the last observed gas price.
+func (ec *EthClient) GasStats() types.GasStats {
+	stats := types.GasStats{}
+	if gasPrice := ec.LastGasPrice(); gasPrice > 0 {
+		gasPriceInt, _ := big.NewFloat(gasPrice).Int(nil)
+		stats.GasPrice = (*hexutil.Big)(gasPriceInt)
+	}
+
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+
+	var min, max *big.Int
+	sum := new(big.Int)
+	for hash, tx := range ec.storedTransactions {
+		if tx.Status != types.STORED {
+			continue
+		}
+		tx = ec.expand(hash, tx)
+		// Same cap as the one compared to the gas price by isEligible.
+		gasCap := new(big.Int).Add(tx.GasFeeCap(), tx.GasTipCap())
+		if min == nil || gasCap.Cmp(min) < 0 {
+			min = gasCap
+		}
+		if max == nil || gasCap.Cmp(max) > 0 {
+			max = gasCap
+		}
+		sum.Add(sum, gasCap)
+		stats.Stored++
+	}
+	if stats.Stored == 0 {
+		return stats
+	}
+	stats.MinGasCap = (*hexutil.Big)(min)
+	stats.MaxGasCap = (*hexutil.Big)(max)
+	stats.AvgGasCap = (*hexutil.Big)(sum.Div(sum, big.NewInt(int64(stats.Stored))))
+	return stats
+}
+
+// Stats returns the queue depth by status, the last gas price and the counters of the client.
+// The uptime and the request count are the server's, they're left to the caller.
+func (ec *EthClient) Stats() types.ServerStats {
+	stats := types.ServerStats{
+		Queue:          make(map[string]int, len(allowedTransitions)),
+		UpstreamErrors: ec.upstreamErrors.Load(),
+		Checks:         ec.checks.Load(),
+	}
+	for status := range allowedTransitions {
+		stats.Queue[status.String()] = 0
+	}
+	if gasPrice := ec.LastGasPrice(); gasPrice > 0 {
+		gasPriceInt, _ := big.NewFloat(gasPrice).Int(nil)
+		stats.GasPrice = (*hexutil.Big)(gasPriceInt)
+	}
+
+	ec.transactionsMutex.RLock()
+	defer ec.transactionsMutex.RUnlock()
+	for _, tx := range ec.storedTransactions {
+		stats.Queue[tx.Status.String()]++
+	}
+	return stats
+}
+
+// monitoringInterval returns the time between two gas price checks.
+func (ec *EthClient) monitoringInterval() time.Duration {
+	if reloaded := ec.reloadedFrequence.Load(); reloaded != 0 {
+		return time.Duration(reloaded)
+	}
+	return ec.gasMonitoringFrequence
+}
+
+// StartMonitor runs MonitorGas in the background, Shutdown waiting for it to return.
+func (ec *EthClient) StartMonitor(ctx context.Context) {
+	// Added before the goroutine starts, so a Shutdown right after can't miss it.
+	ec.monitorWG.Add(1)
+	go func() {
+		defer ec.monitorWG.Done()
+		ec.MonitorGas(ctx)
+	}()
+}
+
+// StartSnapshots flushes the stored transactions every snapshotInterval in the background until ctx is done, batching the
+// changes made in between to limit the writes. Shutdown waits for it, then makes the final flush.
+// It's a no-op without a flusher or without an interval.
+func (ec *EthClient) StartSnapshots(ctx context.Context) {
+	if ec.flusher == nil || ec.snapshotInterval <= 0 {
+		return
+	}
+	// Added before the goroutine starts, so a Shutdown right after can't miss it.
+	ec.monitorWG.Add(1)
+	go func() {
+		defer ec.monitorWG.Done()
+		ticker := time.NewTicker(ec.snapshotInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				err := ec.flusher.Flush(ctx, ec.transactionsSnapshot())
+				if err != nil {
+					log.Error("failed to flush the stored transactions: ", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// MonitorGas monitors gas prices and submits transactions when the gas price is low enough, running CheckOnce on every tick until ctx is done.
+func (ec *EthClient) MonitorGas(ctx context.Context) {
+	interval := ec.monitoringInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := ec.CheckOnce(ctx)
+			if err != nil {
+				log.Error(err.Error())
+			}
+			// Pick up a reloaded interval.
+			if next := ec.monitoringInterval(); next != interval {
+				interval = next
+				ticker.Reset(interval)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// CheckOnce fetches the gas price and broadcasts the eligible STORED transactions, synchronously.
+// It's the evaluation pass of MonitorGas and can also be triggered on demand.
+func (ec *EthClient) CheckOnce(ctx context.Context) error {
+	ec.checks.Add(1)
+	gasPrice, baseFee, err := ec.fetchGas(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tx := range ec.transactionsSnapshot() {
+		ec.checkTransaction(ctx, tx, gasPrice, baseFee)
+	}
+	return nil
+}
+
+// CheckTransaction is CheckOnce for a single transaction: it fetches the gas price and broadcasts the transaction if it's STORED and eligible.
+func (ec *EthClient) CheckTransaction(ctx context.Context, hash string) error {
+	gasPrice, baseFee, err := ec.fetchGas(ctx)
+	if err != nil {
+		return err
+	}
+	tx, ok := ec.storedTransaction(hash)
+	if !ok {
+		return errors.New("transaction not found")
+	}
+	ec.checkTransaction(ctx, tx, gasPrice, baseFee)
+	return nil
+}
+
+// fetchGas fetches the gas price and the pending base fee, when base fee aware, and records them as the last observed ones.
+func (ec *EthClient) fetchGas(ctx context.Context) (float64, *big.Int, error) {
+	gasPrice, err := ec.getGasPrice(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	ec.setLastGasPrice(gasPrice)
+	baseFee := ec.pendingBaseFee(ctx)
+	ec.setLastBaseFee(baseFee)
+	return gasPrice, baseFee, nil
+}
+
+// storedTransaction returns a stored transaction, expanded if compacted.
+func (ec *EthClient) storedTransaction(hash string) (types.Transaction, bool) {
+	ec.transactionsMutex.RLock()
+	tx, ok := ec.storedTransactions[hash]
+	ec.transactionsMutex.RUnlock()
+	if !ok {
+		return tx, false
+	}
+	return ec.expand(hash, tx), true
+}
+
+// checkTransaction broadcasts the transaction if it's STORED and eligible at gasPrice and baseFee, unless the instance is read-only.
+func (ec *EthClient) checkTransaction(ctx context.Context, tx types.Transaction, gasPrice float64, baseFee *big.Int) {
+	if ec.readOnly {
+		return
+	}
+	hash := tx.Hash().String()
+	if tx.Status != types.STORED {
+		return
+	}
+	if ec.expired(&tx, time.Now()) {
+		err := ec.expireTransaction(hash)
+		if err != nil {
+			log.Error(err.Error())
+		}
+		return
+	}
+	if !tx.ReplacesBroadcast && !deadlineReached(&tx, time.Now()) {
+		eligible := isEligible(&tx, gasPrice, ec.eligibilityBaseFee(baseFee))
+		if ec.broadcastConfirmTicks > 1 && ec.recordEligibility(hash, eligible) < ec.broadcastConfirmTicks {
+			return
+		}
+		if !eligible {
+			return
+		}
+	}
+	// The max gas price is a hard ceiling, whatever the gas price and the deadline.
+	if ec.exceedsMaxGasPrice(&tx, baseFee) {
+		if deadlineReached(&tx, time.Now()) {
+			err := ec.failTransaction(hash, deadlineExpiredReason)
+			if err != nil {
+				log.Error(err.Error())
+			}
+			return
+		}
+		log.WithField(txHashField, hash).Warnf("Transaction held back, its gas price %s is above the max gas price %s", effectiveGasPrice(&tx, baseFee), ec.maxGasPrice)
+		return
+	}
+	// A concurrent check may be sending the transaction already.
+	if !ec.claimBroadcast(hash) {
+		return
+	}
+	ec.broadcastTransaction(ctx, hash, tx, gasPrice)
+}
+
+// broadcastTransaction sends a transaction claimed by claimBroadcast at the given gas price and updates its status, releasing it once done.
+func (ec *EthClient) broadcastTransaction(ctx context.Context, hash string, tx types.Transaction, gasPrice float64) {
+	defer ec.releaseBroadcast(hash)
+
+	// Already sent before a restart, or being sent when the server stopped.
+	if ec.broadcasts.contains(hash) {
+		log.WithField(txHashField, hash).Info("Transaction found in the broadcast log, not sending it again")
+		err := ec.settleBroadcast(hash, types.BROADCASTED)
+		if err != nil {
+			log.Error(err.Error())
+		}
+		return
+	}
+	// The hash is recorded before the transaction is sent, so a restart during the send can't send it twice.
+	err := ec.broadcasts.append(hash)
+	if err != nil {
+		log.WithField(txHashField, hash).Error("failed to append to the broadcast log, not sending the transaction: ", err)
+		return
+	}
+	// The transaction is claimed, no other check can send it meanwhile, so the store isn't locked during the network call.
+	isRPCErr, err := ec.sendTransaction(ctx, tx.RawHex)
+	ec.recordAttempt(hash, gasPrice, isRPCErr, err)
+	if err != nil {
+		log.Error("failed to send transaction: ", err)
+		// The transaction may be sent again.
+		retractErr := ec.broadcasts.retract(hash)
+		if retractErr != nil {
+			log.WithField(txHashField, hash).Error("failed to retract from the broadcast log: ", retractErr)
+		}
+		// There's no next check to wait for once the deadline is reached.
+		if deadlineReached(&tx, time.Now()) {
+			err = ec.failTransaction(hash, deadlineExpiredReason)
+			if err != nil {
+				log.Error(err.Error())
+			}
+			return
+		}
+		// If invalid transaction e.g: nonce too low, already known transaction....
+		if isRPCErr {
+			// Give transient node errors a few more chances before failing the transaction for good.
+			if !isPermanentBroadcastError(err) && ec.recordBroadcastError(hash) <= ec.broadcastErrorGrace {
+				log.WithField(txHashField, hash).Warn("Broadcast failed, will retry: ", err)
+				return
+			}
+			err = ec.settleBroadcast(hash, types.FAILED)
+			if err != nil {
+				// This error will never happen since only stored transaction are sent and the transaition from STORED to FAILED is allowed
+				log.Error(err.Error())
+			}
+		}
+		return
+	}
+	ec.recordGasSaved(&tx, gasPrice)
+	err = ec.settleBroadcast(hash, types.BROADCASTED)
+	if err != nil {
+		// This error will never happen since only stored transaction are sent and the transaition from STORED to BROADCASTED is allowed
+		log.Error(err.Error())
+	}
+}
+
+// BroadcastReason explains why a transaction hasn't been broadcast yet, by running the checks of the monitor and reporting the first failing one.
+func (ec *EthClient) BroadcastReason(ctx context.Context, hash string) (string, error) {
+	ec.transactionsMutex.RLock()
+	tx, ok := ec.storedTransactions[hash]
+	ec.transactionsMutex.RUnlock()
+	if !ok {
+		return "", errors.New("transaction not found")
+	}
+	tx = ec.expand(hash, tx)
+
+	observedBaseFee := ec.lastObservedBaseFee()
+	switch {
+	case tx.Status == types.BROADCASTED:
+		return "already broadcast", nil
+	case tx.Status != types.STORED:
+		return fmt.Sprintf("not queued, the transaction is %s", tx.Status.String()), nil
+	case tx.InFlight:
+		return "being broadcast", nil
+	case ec.readOnly:
+		return "read-only mode, transactions are never broadcast", nil
+	case ec.expired(&tx, time.Now()):
+		return "expired, it stayed queued longer than the time to live and will be expired by the next check", nil
+	case ec.exceedsMaxGasPrice(&tx, observedBaseFee):
+		return fmt.Sprintf("gas price above the max gas price (price %s > max %s)", effectiveGasPrice(&tx, observedBaseFee), ec.maxGasPrice), nil
+	case tx.ReplacesBroadcast:
+		return "eligible, it replaces a broadcast transaction and will be broadcast by the next check", nil
+	case deadlineReached(&tx, time.Now()):
+		return "eligible, its deadline is reached and it will be broadcast by the next check", nil
+	}
+
+	gasPrice := ec.LastGasPrice()
+	if gasPrice == 0 {
+		return "gas price not observed yet", nil
+	}
+	baseFee := ec.eligibilityBaseFee(observedBaseFee)
+	if !isEligible(&tx, gasPrice, baseFee) {
+		if baseFee != nil && tx.Type() >= ethTypes.DynamicFeeTxType {
+			return fmt.Sprintf("base fee too high (fee cap %s < base fee %s + tip %s)", tx.GasFeeCap(), baseFee, tx.GasTipCap()), nil
+		}
+		gasCap := new(big.Int).Add(tx.GasFeeCap(), tx.GasTipCap())
+		return fmt.Sprintf("gas price too high (cap %s < price %.0f)", gasCap, gasPrice), nil
+	}
+	if ec.broadcastConfirmTicks > 1 && tx.EligibleTicks < ec.broadcastConfirmTicks {
+		return fmt.Sprintf("waiting for confirmation (eligible at %d of %d consecutive checks)", tx.EligibleTicks, ec.broadcastConfirmTicks), nil
+	}
+
+	from, err := sender(&tx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get sender address: %w", err)
+	}
+	onChain, err := ec.getTransactionCount(ctx, from)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the sender's nonce: %w", err)
+	}
+	if tx.Nonce() > onChain {
+		return fmt.Sprintf("nonce gap (nonce %d, next on-chain nonce %d), it will be broadcast but not mined before the missing nonces", tx.Nonce(), onChain), nil
+	}
+	return "eligible, it will be broadcast by the next check", nil
+}
+
+// TestBroadcast sends a stored transaction to the node and returns the outcome, to probe why a broadcast fails.
+// It goes through the guards of the monitor: a read-only instance, a transaction that isn't STORED, is being broadcast or
+// is above the max gas price is refused, and the send is recorded in the broadcast log.
+// A successful send puts the transaction in the mempool, so it's moved to BROADCASTED. A failed one leaves its status untouched.
+func (ec *EthClient) TestBroadcast(ctx context.Context, hash string) (types.TestBroadcastResult, error) {
+	if ec.readOnly {
+		return types.TestBroadcastResult{}, errors.New("read-only mode, transactions are never broadcast")
+	}
+	tx, ok := ec.storedTransaction(hash)
+	if !ok {
+		return types.TestBroadcastResult{}, errors.New("transaction not found")
+	}
+	if tx.Status != types.STORED {
+		return types.TestBroadcastResult{}, fmt.Errorf("transaction is %s, not STORED", tx.Status.String())
+	}
+	baseFee := ec.lastObservedBaseFee()
+	if ec.exceedsMaxGasPrice(&tx, baseFee) {
+		return types.TestBroadcastResult{}, fmt.Errorf("gas price above the max gas price (price %s > max %s)", effectiveGasPrice(&tx, baseFee), ec.maxGasPrice)
+	}
+	if !ec.claimBroadcast(hash) {
+		return types.TestBroadcastResult{}, fmt.Errorf("transaction is being broadcast: %w", ErrAlreadyBroadcast)
+	}
+	defer ec.releaseBroadcast(hash)
+	if ec.broadcasts.contains(hash) {
+		return types.TestBroadcastResult{}, errors.New("transaction found in the broadcast log, not sending it again")
+	}
+	err := ec.broadcasts.append(hash)
+	if err != nil {
+		return types.TestBroadcastResult{}, fmt.Errorf("failed to append to the broadcast log: %w", err)
+	}
+
+	isRPCErr, err := ec.sendTransaction(ctx, tx.RawHex)
+	ec.recordAttempt(hash, ec.LastGasPrice(), isRPCErr, err)
+	if err != nil {
+		retractErr := ec.broadcasts.retract(hash)
+		if retractErr != nil {
+			log.WithField(txHashField, hash).Error("failed to retract from the broadcast log: ", retractErr)
+		}
+		result := types.TestBroadcastResult{
+			Error:     err.Error(),
+			NodeError: isRPCErr,
+			Permanent: isRPCErr && isPermanentBroadcastError(err),
+		}
+		var nodeErr *types.JSONRPCError
+		if errors.As(err, &nodeErr) {
+			result.Code = nodeErr.Code
+		}
+		return result, nil
+	}
+	// The next check would otherwise send it again and fail it on the node's "already known".
+	err = ec.settleBroadcast(hash, types.BROADCASTED)
+	if err != nil {
+		log.Error(err.Error())
+	}
+	log.WithField(txHashField, hash).Warn("Transaction sent by a test broadcast")
+	return types.TestBroadcastResult{Sent: true}, nil
+}
+
+// recordGasSaved adds the gas price drop between the storing and the broadcast of a transaction, times its gas limit, to the gas saved.
+// A transaction broadcast at a higher gas price, e.g. by its deadline, lowers it. Transactions stored before any gas price was observed are skipped.
+func (ec *EthClient) recordGasSaved(tx *types.Transaction, gasPrice float64) {
+	if tx.StoreGasPrice == 0 || gasPrice == 0 {
+		return
+	}
+	drop, _ := big.NewFloat(tx.StoreGasPrice - gasPrice).Int(nil)
+	saved := drop.Mul(drop, new(big.Int).SetUint64(tx.Gas()))
+
+	ec.gasSavedMutex.Lock()
+	defer ec.gasSavedMutex.Unlock()
+	ec.gasSaved.Add(&ec.gasSaved, saved)
+}
+
+// GasSaved returns the gas saved in wei by waiting for a cheaper gas price before broadcasting the transactions.
+func (ec *EthClient) GasSaved() *big.Int {
+	ec.gasSavedMutex.Lock()
+	defer ec.gasSavedMutex.Unlock()
+	return new(big.Int).Set(&ec.gasSaved)
+}
+
+// deadlineReached reports whether the transaction has a deadline and it's passed.
+func deadlineReached(tx *types.Transaction, now time.Time) bool {
+	return !tx.Deadline.IsZero() && !now.Before(tx.Deadline)
+}
+
+// expired reports whether the transaction has an expiry and it's passed, by more than the clock skew tolerance.
+func (ec *EthClient) expired(tx *types.Transaction, now time.Time) bool {
+	return !tx.ExpiresAt.IsZero() && now.After(tx.ExpiresAt.Add(ec.clockSkew))
+}
+
+// expireTransaction changes the status of a STORED transaction that isn't being broadcast to EXPIRED.
+func (ec *EthClient) expireTransaction(hash string) error {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return errors.New("transaction not found")
+	}
+	if tx.Status != types.STORED || tx.InFlight {
+		return fmt.Errorf("invalid status transition from %s to %s for transaction: %s", tx.Status.String(), types.EXPIRED.String(), hash)
+	}
+	tx.Status = types.EXPIRED
+	ec.storedTransactions[hash] = tx
+	ec.publishStatusChange(hash, types.STORED, types.EXPIRED)
+	log.WithField(txHashField, hash).Warn("Expired transaction, stored at ", tx.StoredAt.Format(time.RFC3339))
+	return nil
+}
+
+// failTransaction changes the status of a STORED transaction to FAILED, recording why.
+func (ec *EthClient) failTransaction(hash string, reason string) error {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return errors.New("transaction not found")
+	}
+	if tx.Status != types.STORED {
+		return fmt.Errorf("invalid status transition from %s to %s for transaction: %s", tx.Status.String(), types.FAILED.String(), hash)
+	}
+	tx.Status = types.FAILED
+	tx.Reason = reason
+	ec.storedTransactions[hash] = tx
+	ec.publishStatusChange(hash, types.STORED, types.FAILED)
+	log.WithField(txHashField, hash).Warn("Failed transaction: ", reason)
+	return nil
+}
+
+// recordEligibility counts the consecutive checks a transaction was eligible at, resetting the count when it's not, and returns it.
+func (ec *EthClient) recordEligibility(hash string, eligible bool) int {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return 0
+	}
+	if eligible {
+		tx.EligibleTicks++
+	} else {
+		tx.EligibleTicks = 0
+	}
+	ec.storedTransactions[hash] = tx
+	return tx.EligibleTicks
+}
+
+// claimBroadcast marks a STORED transaction as in flight so that concurrent checks skip it.
+// It reports false when the transaction is no longer STORED or another check is already sending it.
+func (ec *EthClient) claimBroadcast(hash string) bool {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok || tx.Status != types.STORED || tx.InFlight {
+		return false
+	}
+	tx.InFlight = true
+	ec.storedTransactions[hash] = tx
+	return true
+}
+
+// releaseBroadcast clears the in flight flag set by claimBroadcast.
+func (ec *EthClient) releaseBroadcast(hash string) {
+	ec.transactionsMutex.Lock()
+	defer ec.transactionsMutex.Unlock()
+
+	tx, ok := ec.storedTransactions[hash]
+	if !ok {
+		return
+	}
+	tx.InFlight = false
+	ec.storedTransactions[hash] = tx
+}
+
+// Shutdown waits for the gas monitor and the snapshots to stop, their context must be canceled beforehand, then flushes the stored transactions.
+// The checks of the just-stored transactions are canceled. It gives up when ctx is done, so a slow flush can't block the exit forever.
+func (ec *EthClient) Shutdown(ctx context.Context) error {
+	if ec.cancelChecks != nil {
+		ec.cancelChecks()
+	}
+	done := make(chan error, 1)
+	go func() {
+		ec.monitorWG.Wait()
+		defer ec.broadcasts.close()
+		if ec.flusher == nil {
+			done <- nil
+			return
+		}
+		done <- ec.flusher.Flush(ctx, ec.transactionsSnapshot())
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("shutdown interrupted: %w", ctx.Err())
+	}
 }
@@ -1,20 +1,35 @@
 package ethclient
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/safwentrabelsi/tx-json-rpc-server/config"
 	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -66,6 +81,23 @@ func TestDoRequest(t *testing.T) {
 	})
 }
 
+// SlowDoer answers {"result":"0x1"} after a delay, unless the request context is done first.
+type SlowDoer struct {
+	Delay time.Duration
+}
+
+func (m *SlowDoer) Do(req *http.Request) (*http.Response, error) {
+	select {
+	case <-time.After(m.Delay):
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)),
+		}, nil
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+}
+
 // Tests sendTransaction function.
 func TestSendTransaction(t *testing.T) {
 	t.Run("it sends a transaction successfully", func(t *testing.T) {
@@ -124,6 +156,211 @@ func TestSendTransaction(t *testing.T) {
 	})
 }
 
+// CountingDoer fails every request and counts the attempts.
+type CountingDoer struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (m *CountingDoer) Do(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	return nil, errors.New("connection refused")
+}
+
+// tests the retries of the internal RPC helpers.
+func TestDoRequestWithRetry(t *testing.T) {
+	t.Run("it retries failed requests", func(t *testing.T) {
+		doer := &CountingDoer{}
+		client := &EthClient{Client: doer, retries: 2, retryBackoff: time.Millisecond}
+
+		_, err := client.getGasPrice(context.Background())
+
+		require.Error(t, err)
+		require.Equal(t, 3, doer.calls)
+	})
+
+	t.Run("getGasPrice returns promptly when the context is cancelled mid-retry", func(t *testing.T) {
+		doer := &CountingDoer{}
+		client := &EthClient{Client: doer, retries: 5, retryBackoff: time.Hour}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(20*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err := client.getGasPrice(ctx)
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.Less(t, time.Since(start), time.Second)
+		require.Equal(t, 1, doer.calls)
+	})
+
+	t.Run("sendTransaction doesn't retry once the context is cancelled", func(t *testing.T) {
+		doer := &CountingDoer{}
+		client := &EthClient{Client: doer, retries: 5, retryBackoff: time.Millisecond}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		isRPCError, err := client.sendTransaction(ctx, validTransactionRawHex)
+
+		require.False(t, isRPCError)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 1, doer.calls)
+	})
+}
+
+// tests the outbound rate limiter of SendRequest.
+func TestSendRequestRateLimit(t *testing.T) {
+	t.Run("calls are paced under the configured rate", func(t *testing.T) {
+		client := &EthClient{
+			Client:  &MonitorGasMockDoer{},
+			limiter: rate.NewLimiter(20, 1),
+		}
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			resp, err := client.SendRequest(context.Background(), strings.NewReader("{}"), http.Header{})
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		// The first call uses the burst, the 4 others wait 50ms each.
+		require.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+	})
+
+	t.Run("a waiting call returns when the context is done", func(t *testing.T) {
+		client := &EthClient{
+			Client:  &MonitorGasMockDoer{},
+			limiter: rate.NewLimiter(0.1, 1),
+		}
+		resp, err := client.SendRequest(context.Background(), strings.NewReader("{}"), http.Header{})
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = client.SendRequest(ctx, strings.NewReader("{}"), http.Header{})
+
+		require.Error(t, err)
+	})
+}
+
+// RateLimitedDoer answers with HTTP 429 and a Retry-After header for the first calls, then like the node.
+type RateLimitedDoer struct {
+	RateLimited int
+	RetryAfter  string
+	calls       int
+	bodies      []string
+}
+
+func (m *RateLimitedDoer) Do(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	m.bodies = append(m.bodies, string(body))
+	m.calls++
+	if m.calls <= m.RateLimited {
+		return &http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": []string{m.RetryAfter}},
+			Body:       io.NopCloser(strings.NewReader("rate limited")),
+		}, nil
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"jsonrpc": "2.0", "result": "0x5f5e100", "id":1}`)),
+	}, nil
+}
+
+// tests the retries of the requests rate limited by the node.
+func TestSendRequestRateLimited(t *testing.T) {
+	t.Run("a 429 response is retried after the Retry-After delay", func(t *testing.T) {
+		doer := &RateLimitedDoer{RateLimited: 1, RetryAfter: "1"}
+		client := &EthClient{Client: doer, rateLimitRetries: 2, rateLimitMaxDelay: 50 * time.Millisecond}
+
+		start := time.Now()
+		gasPrice, err := client.getGasPrice(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, float64(100000000), gasPrice)
+		require.Equal(t, 2, doer.calls)
+		// The second request waited for the delay, capped by the maximum delay, and carried the same body.
+		require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		require.Equal(t, doer.bodies[0], doer.bodies[1])
+	})
+
+	t.Run("the last 429 response is returned once the retries are exhausted", func(t *testing.T) {
+		doer := &RateLimitedDoer{RateLimited: 5, RetryAfter: "0"}
+		client := &EthClient{Client: doer, rateLimitRetries: 2, rateLimitMaxDelay: time.Second}
+
+		resp, err := client.SendRequest(context.Background(), strings.NewReader("{}"), http.Header{})
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Equal(t, 3, doer.calls)
+	})
+
+	t.Run("without retries, a 429 response is returned right away", func(t *testing.T) {
+		doer := &RateLimitedDoer{RateLimited: 1, RetryAfter: "0"}
+		client := &EthClient{Client: doer}
+
+		_, err := client.getGasPrice(context.Background())
+		require.EqualError(t, err, "unexpected http status code: 429")
+		require.Equal(t, 1, doer.calls)
+	})
+
+	t.Run("a wait for a retry returns when the context is done", func(t *testing.T) {
+		doer := &RateLimitedDoer{RateLimited: 1, RetryAfter: "10"}
+		client := &EthClient{Client: doer, rateLimitRetries: 1, rateLimitMaxDelay: time.Minute}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := client.SendRequest(ctx, strings.NewReader("{}"), http.Header{})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, 1, doer.calls)
+	})
+}
+
+// tests the configured User-Agent is sent to the node.
+func TestSendRequestUserAgent(t *testing.T) {
+	userAgents := make(chan string, 2)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.Header.Get("User-Agent")
+		w.Write([]byte(`{"jsonrpc": "2.0", "result": "0x5f5e100", "id":1}`))
+	}))
+	defer upstream.Close()
+	client := &EthClient{URL: upstream.URL, Client: upstream.Client(), userAgent: "tx-json-rpc-server/1.2.3"}
+
+	t.Run("on the internal requests", func(t *testing.T) {
+		_, err := client.getGasPrice(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "tx-json-rpc-server/1.2.3", <-userAgents)
+	})
+
+	t.Run("on the proxied requests, in place of the client's", func(t *testing.T) {
+		headers := http.Header{"User-Agent": {"curl/8.0"}}
+		resp, err := client.SendRequest(context.Background(), strings.NewReader("{}"), headers)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, "tx-json-rpc-server/1.2.3", <-userAgents)
+		// The caller's headers are left untouched.
+		require.Equal(t, "curl/8.0", headers.Get("User-Agent"))
+	})
+}
+
+// tests the parsing of the Retry-After header.
+func TestRetryAfterDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 2*time.Second, retryAfterDelay("2", now, time.Minute))
+	require.Equal(t, 30*time.Second, retryAfterDelay(now.Add(30*time.Second).Format(http.TimeFormat), now, time.Minute))
+	require.Equal(t, time.Duration(0), retryAfterDelay(now.Add(-time.Second).Format(http.TimeFormat), now, time.Minute))
+	require.Equal(t, defaultRateLimitDelay, retryAfterDelay("", now, time.Minute))
+	require.Equal(t, 5*time.Second, retryAfterDelay("120", now, 5*time.Second))
+}
+
 // tests the get getGasPrice function.
 func TestGetGasPrice(t *testing.T) {
 
@@ -190,6 +427,67 @@ func TestGetGasPrice(t *testing.T) {
 			t.Fatalf("expected invalid syntax error, got %v", err)
 		}
 	})
+
+	t.Run("it times out at the gas fetch deadline without affecting other calls", func(t *testing.T) {
+		client := &EthClient{
+			Client:          &SlowDoer{Delay: time.Millisecond * 100},
+			gasFetchTimeout: time.Millisecond * 20,
+		}
+
+		start := time.Now()
+		_, err := client.getGasPrice(context.Background())
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Millisecond*100)
+
+		_, err = client.getTransactionCount(context.Background(), common.Address{})
+		require.NoError(t, err)
+	})
+}
+
+// tests the BlockNumber function.
+func TestBlockNumber(t *testing.T) {
+	t.Run("return the latest block number", func(t *testing.T) {
+		ec := &EthClient{Client: &SequenceDoer{Bodies: []string{`{"jsonrpc":"2.0","id":1,"result":"0x10"}`}}}
+		blockNumber, err := ec.BlockNumber(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(16), blockNumber)
+	})
+
+	t.Run("return the node error", func(t *testing.T) {
+		ec := &EthClient{Client: &SequenceDoer{Bodies: []string{`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`}}}
+		_, err := ec.BlockNumber(context.Background())
+		require.EqualError(t, err, "header not found")
+	})
+}
+
+// tests the RefreshGasPrice function.
+func TestRefreshGasPrice(t *testing.T) {
+	t.Run("it updates the cached gas price", func(t *testing.T) {
+		client := &EthClient{
+			Client: &MockDoer{
+				Response: &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"jsonrpc": "2.0", "result": "0x5f5e100", "id":1}`)),
+				},
+			},
+		}
+
+		gasPrice, err := client.RefreshGasPrice(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, float64(100000000), gasPrice)
+		require.Equal(t, float64(100000000), client.LastGasPrice())
+	})
+
+	t.Run("it keeps the cached gas price when the fetch fails", func(t *testing.T) {
+		client := &EthClient{
+			Client:       &MockDoer{Err: errors.New("net/http: request canceled")},
+			lastGasPrice: 42,
+		}
+
+		_, err := client.RefreshGasPrice(context.Background())
+		require.Error(t, err)
+		require.Equal(t, float64(42), client.LastGasPrice())
+	})
 }
 
 // tests the storeTransaction Function.
@@ -226,7 +524,7 @@ func TestStoreTransaction(t *testing.T) {
 		storedTransactions: map[string]types.Transaction{
 			tx1.Hash().String(): *tx1,
 		},
-		transactionsMutex: &sync.Mutex{},
+		transactionsMutex: &sync.RWMutex{},
 
 	}
 
@@ -265,175 +563,2292 @@ func TestStoreTransaction(t *testing.T) {
     })
 }
 
-// tests the cancelTransaction function.
-func TestCancelTransaction(t *testing.T) {
-    // Test data
-	// tx1
-	tx1, err := getTxFromRaw(existingTransactionRaw) 
-	if err != nil {
-		t.Fatalf("Failed to decode transaction data: %v", err)
-	}
-	
-	  // Initialize EthClient
-    client := &EthClient{
+// tests submitting the same cancel transaction twice.
+func TestStoreTransactionResubmittedCancel(t *testing.T) {
+	tx1, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	tx1Cancel, err := getTxFromRaw(tx1CancelRaw)
+	require.NoError(t, err)
+	client := &EthClient{
 		storedTransactions: map[string]types.Transaction{
 			tx1.Hash().String(): *tx1,
 		},
-		transactionsMutex: &sync.Mutex{},
+		transactionsMutex: &sync.RWMutex{},
+		events:            newEventHub(10, false),
+	}
+	events, unsubscribe := client.SubscribeStatusChanges()
+	defer unsubscribe()
 
+	for i := 0; i < 2; i++ {
+		require.NoError(t, client.StoreTransaction(*tx1Cancel))
+		require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
+		require.NotContains(t, client.storedTransactions, tx1Cancel.Hash().String())
 	}
 
-	client.storedTransactions[tx1.Hash().String()] = *tx1
+	// Only the first submission canceled the transaction.
+	require.Len(t, events, 1)
+	require.Equal(t, "CANCELED", (<-events).To)
+}
 
-  
+// tests the speed-up of a transaction already broadcast is broadcast right away as its replacement.
+func TestStoreTransactionSpeedUpBroadcast(t *testing.T) {
+	tx1, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	tx1.Status = types.BROADCASTED
+	tx1SpeedUp, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+
+	// The gas price is far above the caps of the speed-up.
+	doer := &SequenceDoer{Bodies: []string{
+		`{"jsonrpc":"2.0","id":1,"result":"0xe8d4a51000"}`,
+		`{"jsonrpc":"2.0","id":1,"result":"` + tx1SpeedUp.Hash().String() + `"}`,
+	}}
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{
+			tx1.Hash().String(): *tx1,
+		},
+		transactionsMutex: &sync.RWMutex{},
+		Client:            doer,
+	}
 
-    t.Run("cancel an existing transaction", func(t *testing.T) {
-        err := client.CancelTransaction(tx1.Hash().String())
-        require.NoError(t, err)
-        require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
-    })
+	require.NoError(t, client.StoreTransaction(*tx1SpeedUp))
+	client.monitorWG.Wait()
 
-    t.Run("attempt to cancel a non-existing transaction", func(t *testing.T) {
-        err := client.CancelTransaction("non-existing")
-        require.Error(t, err)
-        require.Contains(t, err.Error(), "transaction not found")
-    })
+	require.Equal(t, 2, doer.calls)
+	require.Equal(t, types.SPEDUP, client.storedTransactions[tx1.Hash().String()].Status)
+	replacement := client.storedTransactions[tx1SpeedUp.Hash().String()]
+	require.Equal(t, types.BROADCASTED, replacement.Status)
+	require.True(t, replacement.ReplacesBroadcast)
 }
 
-
-// tests the changeTransactionStatus function
-func TestChangeTransactionStatus(t *testing.T) {
-    // Test data
-	
-    // tx1
-	tx1, err := getTxFromRaw(existingTransactionRaw) 
-	if err != nil {
-		t.Fatalf("Failed to decode transaction data: %v", err)
+// tests concurrent calls to StoreTransaction, run with -race.
+func TestStoreTransactionConcurrent(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	txs := make([]*types.Transaction, 5)
+	for i := range txs {
+		txs[i], err = getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: uint64(i), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 21000, To: &common.Address{1},
+		}))
+		require.NoError(t, err)
+	}
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
 	}
 
-    client := &EthClient{
-		storedTransactions: map[string]types.Transaction{
-			tx1.Hash().String(): *tx1,
-		},
-		transactionsMutex: &sync.Mutex{},
-
+	// Each transaction is submitted by several goroutines at once.
+	const submissions = 4
+	stored := make([]atomic.Int32, len(txs))
+	var wg sync.WaitGroup
+	for i := range txs {
+		for j := 0; j < submissions; j++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if client.StoreTransaction(*txs[i]) == nil {
+					stored[i].Add(1)
+				}
+			}(i)
+		}
 	}
+	wg.Wait()
 
+	for i := range txs {
+		require.Equal(t, int32(1), stored[i].Load())
+	}
+	require.Len(t, client.storedTransactions, len(txs))
+	from, err := sender(txs[0])
+	require.NoError(t, err)
+	require.Len(t, client.senderIndex[from], len(txs))
+}
 
+// tests the replay protection check of StoreTransaction.
+func TestStoreTransactionReplayProtection(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	legacyTx := &ethTypes.LegacyTx{Nonce: 0, GasPrice: big.NewInt(1000000000), Gas: 21000, To: &common.Address{}, Value: big.NewInt(1)}
 
-    t.Run("valid status transition", func(t *testing.T) {
-        err := client.changeTransactionStatus(tx1.Hash().String(), types.CANCELED)
-        require.NoError(t, err)
-        require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
-    })
+	unprotected, err := getTxFromRaw(signRawTx(t, key, ethTypes.HomesteadSigner{}, legacyTx))
+	require.NoError(t, err)
+	protected, err := getTxFromRaw(signRawTx(t, key, ethTypes.NewEIP155Signer(big.NewInt(5)), legacyTx))
+	require.NoError(t, err)
 
-    t.Run("invalid status transition", func(t *testing.T) {
+	t.Run("reject a legacy transaction without EIP-155 replay protection", func(t *testing.T) {
+		client := &EthClient{storedTransactions: map[string]types.Transaction{}, transactionsMutex: &sync.RWMutex{}}
 
-		// Prepare the transaction
-		tx1.Status = types.CANCELED
-		client.storedTransactions[tx1.Hash().String()] = *tx1
+		err := client.StoreTransaction(*unprotected)
+		require.EqualError(t, err, "missing replay protection")
+		require.Empty(t, client.storedTransactions)
+	})
 
+	t.Run("store a legacy transaction with EIP-155 replay protection", func(t *testing.T) {
+		client := &EthClient{storedTransactions: map[string]types.Transaction{}, transactionsMutex: &sync.RWMutex{}}
 
-        err := client.changeTransactionStatus(tx1.Hash().String(), types.STORED)
-        require.Error(t, err)
-        require.Contains(t, err.Error(), "invalid status transition")
-        require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
-    })
+		err := client.StoreTransaction(*protected)
+		require.NoError(t, err)
+		require.Contains(t, client.storedTransactions, protected.Hash().String())
+	})
 
-    t.Run("non-existing transaction", func(t *testing.T) {
-        err := client.changeTransactionStatus("non-existing", types.CANCELED)
-        require.Error(t, err)
-        require.Contains(t, err.Error(), "transaction not found")
-    })
-}
+	t.Run("store an unprotected transaction when explicitly allowed", func(t *testing.T) {
+		client := &EthClient{storedTransactions: map[string]types.Transaction{}, transactionsMutex: &sync.RWMutex{}, allowUnprotected: true}
 
-// For the gasMonitor test I will to mock the do function to be able to read the body twice.
-type MonitorGasMockDoer struct {
-	Response *http.Response
-	Err      error
-}
-func (m *MonitorGasMockDoer) Do(req *http.Request) (*http.Response, error) {
-	body := io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
-	return &http.Response{
-		StatusCode: http.StatusOK,
-		Body: body,
-	}, nil
+		err := client.StoreTransaction(*unprotected)
+		require.NoError(t, err)
+		require.Contains(t, client.storedTransactions, unprotected.Hash().String())
+	})
 }
-func TestMonitorGas(t *testing.T) {
 
-	t.Run("broadcast the transaction at the right gas price", func(t *testing.T) {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-
-		// prepare data
-		tx, err := getTxFromRaw(tx1SpeedUpRaw) 
-		if err != nil {
-			t.Fatalf("Failed to decode transaction data: %v", err)
+// tests the encoding check of StoreTransaction.
+func TestStoreTransactionEncoding(t *testing.T) {
+	newClient := func() *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+			allowUnprotected:   true,
 		}
+	}
 
-		
-		ec := &EthClient{
-				storedTransactions: map[string]types.Transaction{
-					tx.Hash().String(): *tx,
-				},
-				transactionsMutex: &sync.Mutex{},
-				gasMonitoringFrequence: time.Millisecond * 50,
-				Client: &MonitorGasMockDoer{},
-			}
-		
-		go ec.MonitorGas(ctx)
+	t.Run("reject a transaction whose raw hex isn't its encoding", func(t *testing.T) {
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+		tx.RawHex = tx1CancelRaw
+
+		err = newClient().StoreTransaction(*tx)
+		require.EqualError(t, err, "malformed transaction encoding")
+	})
 
-		// Give the MonitorGas method some time to run
-		time.Sleep(time.Millisecond * 60)
+	t.Run("store a transaction whose raw hex round-trips, whatever its case", func(t *testing.T) {
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+		tx.RawHex = "0x" + strings.ToUpper(tx1SpeedUpRaw[2:])
 
-		require.Equal(t, types.BROADCASTED, ec.storedTransactions[tx.Hash().String()].Status)
+		err = newClient().StoreTransaction(*tx)
+		require.NoError(t, err)
 	})
+}
 
-	t.Run("gas price isn't low enough to broadcast transaction.", func(t *testing.T) {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+// tests StoreTransaction rejects the transactions decoding without a valid signature.
+func TestStoreTransactionUnsigned(t *testing.T) {
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		allowUnprotected:   true,
+	}
+	for name, data := range map[string]ethTypes.TxData{
+		"dynamic fee": &ethTypes.DynamicFeeTx{ChainID: big.NewInt(5), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{}},
+		"legacy":      &ethTypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000, To: &common.Address{}},
+	} {
+		t.Run("reject an unsigned "+name+" transaction", func(t *testing.T) {
+			raw, err := ethTypes.NewTx(data).MarshalBinary()
+			require.NoError(t, err)
+			tx, err := getTxFromRaw(hexutil.Encode(raw))
+			require.NoError(t, err)
+
+			err = client.StoreTransaction(*tx)
+			require.EqualError(t, err, "unsigned transaction")
+			require.Empty(t, client.storedTransactions)
+		})
+	}
+}
 
-		// prepare data
-		tx, err := getTxFromRaw(existingTransactionRaw) 
-		if err != nil {
-			t.Fatalf("Failed to decode transaction data: %v", err)
-		}
+// tests the REJECT_ZERO_TIP check of StoreTransaction.
+func TestStoreTransactionZeroTip(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(data ethTypes.TxData) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, data))
+		require.NoError(t, err)
+		return *tx
+	}
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		rejectZeroTip:      true,
+	}
 
-		
-		ec := &EthClient{
-				storedTransactions: map[string]types.Transaction{
-					tx.Hash().String(): *tx,
-				},
-				transactionsMutex: &sync.Mutex{},
-				gasMonitoringFrequence: time.Millisecond * 50,
-				Client: &MonitorGasMockDoer{},
-			}
-		
-		go ec.MonitorGas(ctx)
+	t.Run("reject a transaction with a zero tip", func(t *testing.T) {
+		err := client.StoreTransaction(newTx(&ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: 1, GasTipCap: big.NewInt(0), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.EqualError(t, err, "zero priority fee")
+	})
 
-		// Give the MonitorGas method some time to run
-		time.Sleep(time.Millisecond * 60)
+	t.Run("reject a legacy transaction with a zero gas price", func(t *testing.T) {
+		err := client.StoreTransaction(newTx(&ethTypes.LegacyTx{
+			Nonce: 2, GasPrice: big.NewInt(0), Gas: 21000, To: &common.Address{},
+		}))
+		require.EqualError(t, err, "zero priority fee")
+	})
 
-		require.Equal(t, types.STORED, ec.storedTransactions[tx.Hash().String()].Status)
+	t.Run("store transactions with a tip", func(t *testing.T) {
+		require.NoError(t, client.StoreTransaction(newTx(&ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		})))
+		require.NoError(t, client.StoreTransaction(newTx(&ethTypes.LegacyTx{
+			Nonce: 4, GasPrice: big.NewInt(1), Gas: 21000, To: &common.Address{},
+		})))
 	})
+}
 
-	t.Run("try to broadcast the transaction but sendTransaction return an error but not rpcError", func(t *testing.T) {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+// tests the chain id allowlist of StoreTransaction.
+func TestStoreTransactionChainID(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	newTx := func(chainID int64) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, ethTypes.LatestSignerForChainID(big.NewInt(chainID)), &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(chainID), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return *tx
+	}
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		allowedChainIDs:    map[uint64]bool{11155111: true},
+	}
 
-		// prepare data
-		tx, err := getTxFromRaw(tx1SpeedUpRaw) 
-		if err != nil {
-			t.Fatalf("Failed to decode transaction data: %v", err)
-		}
+	t.Run("reject a transaction for another chain", func(t *testing.T) {
+		err := client.StoreTransaction(newTx(1))
+		require.EqualError(t, err, "chain id 1 not allowed")
+		require.Empty(t, client.storedTransactions)
+	})
 
-		
-		ec := &EthClient{
-				storedTransactions: map[string]types.Transaction{
+	t.Run("store a transaction for an allowed chain", func(t *testing.T) {
+		require.NoError(t, client.StoreTransaction(newTx(11155111)))
+	})
+}
+
+// tests the calldata size check of StoreTransaction.
+func TestStoreTransactionDataSize(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64, dataSize int) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 1000000, To: &common.Address{}, Data: make([]byte, dataSize),
+		}))
+		require.NoError(t, err)
+		return *tx
+	}
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		maxTxDataBytes:     64,
+	}
+
+	t.Run("reject a transaction with oversized calldata", func(t *testing.T) {
+		err := client.StoreTransaction(newTx(1, 65))
+		require.EqualError(t, err, "transaction data too large: 65 bytes, max 64")
+		require.Empty(t, client.storedTransactions)
+	})
+
+	t.Run("store a transaction with calldata up to the limit", func(t *testing.T) {
+		require.NoError(t, client.StoreTransaction(newTx(2, 64)))
+	})
+}
+
+// tests a cancel transaction sent by a wallet with a "0x" data field.
+func TestStoreTransactionEmptyDataCancel(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	// Wallets encode the data of a simple transfer as "0x".
+	var emptyData hexutil.Bytes
+	require.NoError(t, json.Unmarshal([]byte(`"0x"`), &emptyData))
+
+	original, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+		ChainID: big.NewInt(5), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{1}, Value: big.NewInt(1), Data: emptyData,
+	}))
+	require.NoError(t, err)
+	cancel, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+		ChainID: big.NewInt(5), Nonce: 1, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(3), Gas: 21000, To: &from, Value: big.NewInt(0), Data: emptyData,
+	}))
+	require.NoError(t, err)
+	require.Empty(t, cancel.Data())
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+	require.NoError(t, client.StoreTransaction(*original))
+	require.NoError(t, client.StoreTransaction(*cancel))
+
+	view, err := client.GetTransaction(original.Hash().String())
+	require.NoError(t, err)
+	require.Equal(t, "CANCELED", view.Status)
+	_, err = client.GetTransaction(cancel.Hash().String())
+	require.EqualError(t, err, "transaction not found")
+}
+
+// tests the nonce gap check of StoreTransaction.
+func TestStoreTransactionNonceGap(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64) *types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+
+	// The mocked node returns 0x1 as pending nonce.
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		Client:             &MonitorGasMockDoer{},
+		maxNonceGap:        2,
+	}
+
+	t.Run("reject a transaction with too large a gap from the on-chain nonce", func(t *testing.T) {
+		err := client.StoreTransaction(*newTx(4))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "nonce gap too large")
+	})
+
+	t.Run("store a transaction within the gap", func(t *testing.T) {
+		err := client.StoreTransaction(*newTx(3))
+		require.NoError(t, err)
+	})
+
+	t.Run("the gap is counted from the highest stored nonce", func(t *testing.T) {
+		err := client.StoreTransaction(*newTx(6))
+		require.NoError(t, err)
+
+		err = client.StoreTransaction(*newTx(10))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "nonce gap too large")
+	})
+}
+
+// BalanceMockDoer answers every request with Balance.
+type BalanceMockDoer struct {
+	Balance string
+}
+
+func (m *BalanceMockDoer) Do(req *http.Request) (*http.Response, error) {
+	body := io.NopCloser(strings.NewReader(fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"result":"%s"}`, m.Balance)))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       body,
+	}, nil
+}
+
+// tests the CHECK_BALANCE behavior of StoreTransaction.
+func TestStoreTransactionBalance(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	// The transaction costs at most 1000 + 21000 * 2 = 43000 wei.
+	tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+		ChainID: big.NewInt(5), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{}, Value: big.NewInt(1000),
+	}))
+	require.NoError(t, err)
+	newClient := func(balance string) *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &BalanceMockDoer{Balance: balance},
+			checkBalance:       true,
+		}
+	}
+
+	t.Run("reject a transaction costing more than the balance", func(t *testing.T) {
+		client := newClient("0xa7f7")
+		err := client.StoreTransaction(*tx)
+		require.EqualError(t, err, "insufficient balance: balance 42999, max cost 43000")
+		require.Empty(t, client.storedTransactions)
+	})
+
+	t.Run("store a transaction the balance covers", func(t *testing.T) {
+		client := newClient("0xa7f8")
+		require.NoError(t, client.StoreTransaction(*tx))
+	})
+}
+
+// tests NextNonce counts the stored transactions of the sender.
+func TestNextNonce(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return *tx
+	}
+
+	// The mocked node returns 0x1 as pending nonce.
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		senderIndex:        map[common.Address][]string{},
+		transactionsMutex:  &sync.RWMutex{},
+		Client:             &MonitorGasMockDoer{},
+	}
+
+	t.Run("without stored transactions, return the on-chain nonce", func(t *testing.T) {
+		next, err := client.NextNonce(context.Background(), from)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), next)
+	})
+
+	t.Run("return the nonce following the highest stored one", func(t *testing.T) {
+		require.NoError(t, client.StoreTransaction(newTx(3)))
+		require.NoError(t, client.StoreTransaction(newTx(2)))
+
+		next, err := client.NextNonce(context.Background(), from)
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), next)
+	})
+
+	t.Run("the transactions of other senders don't count", func(t *testing.T) {
+		next, err := client.NextNonce(context.Background(), common.HexToAddress("0x8d7526216e3c4294345ecf45ad57f9aebacfb0c4"))
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), next)
+	})
+}
+
+// tests the QUEUE_FULL_POLICY behaviors of StoreTransaction.
+func TestStoreTransactionAtCapacity(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64) *types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+	newClient := func(evictOldest bool) *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+			maxStoredTx:        2,
+			evictOldest:        evictOldest,
+		}
+	}
+
+	t.Run("with the reject policy, reject new transactions at capacity", func(t *testing.T) {
+		client := newClient(false)
+		require.NoError(t, client.StoreTransaction(*newTx(1)))
+		require.NoError(t, client.StoreTransaction(*newTx(2)))
+
+		err := client.StoreTransaction(*newTx(3))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "transaction store is full")
+		require.Len(t, client.storedTransactions, 2)
+	})
+
+	t.Run("with the reject policy, accept new transactions once a stored one left the queue", func(t *testing.T) {
+		client := newClient(false)
+		first := newTx(1)
+		require.NoError(t, client.StoreTransaction(*first))
+		require.NoError(t, client.StoreTransaction(*newTx(2)))
+		require.NoError(t, client.CancelTransaction(first.Hash().String()))
+
+		require.NoError(t, client.StoreTransaction(*newTx(3)))
+	})
+
+	t.Run("with the evict_oldest policy, fail the oldest stored transaction to make room", func(t *testing.T) {
+		client := newClient(true)
+		oldest, second, newest := newTx(1), newTx(2), newTx(3)
+		require.NoError(t, client.StoreTransaction(*oldest))
+		require.NoError(t, client.StoreTransaction(*second))
+
+		require.NoError(t, client.StoreTransaction(*newest))
+
+		view, err := client.GetTransaction(oldest.Hash().String())
+		require.NoError(t, err)
+		require.Equal(t, types.FAILED.String(), view.Status)
+		require.Equal(t, "evicted", view.Reason)
+		require.Equal(t, types.STORED, client.storedTransactions[second.Hash().String()].Status)
+		require.Equal(t, types.STORED, client.storedTransactions[newest.Hash().String()].Status)
+	})
+
+	t.Run("with the evict_oldest policy, don't evict a transaction being broadcast", func(t *testing.T) {
+		client := newClient(true)
+		oldest, second, newest := newTx(1), newTx(2), newTx(3)
+		require.NoError(t, client.StoreTransaction(*oldest))
+		require.NoError(t, client.StoreTransaction(*second))
+		require.True(t, client.claimBroadcast(oldest.Hash().String()))
+
+		require.NoError(t, client.StoreTransaction(*newest))
+
+		require.Equal(t, types.STORED, client.storedTransactions[oldest.Hash().String()].Status)
+		require.Equal(t, types.FAILED, client.storedTransactions[second.Hash().String()].Status)
+	})
+
+	t.Run("with the evict_oldest policy, reject new transactions when all the stored ones are being broadcast", func(t *testing.T) {
+		client := newClient(true)
+		first, second := newTx(1), newTx(2)
+		require.NoError(t, client.StoreTransaction(*first))
+		require.NoError(t, client.StoreTransaction(*second))
+		require.True(t, client.claimBroadcast(first.Hash().String()))
+		require.True(t, client.claimBroadcast(second.Hash().String()))
+
+		err := client.StoreTransaction(*newTx(3))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "transaction store is full")
+	})
+}
+
+// BaseFeeMockDoer answers every request with the latest block, whose base fee is 100 wei.
+type BaseFeeMockDoer struct{}
+
+func (m *BaseFeeMockDoer) Do(req *http.Request) (*http.Response, error) {
+	body := io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":{"number":"0x10","baseFeePerGas":"0x64"}}`))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       body,
+	}, nil
+}
+
+// tests the UNDERPRICED_REPLACEMENT policies of StoreTransaction.
+func TestStoreTransactionUnderpricedReplacement(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(feeCap int64) *types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(feeCap), Gas: 21000, To: &common.Address{1},
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+	newClient := func(policy string, original *types.Transaction) *EthClient {
+		client := &EthClient{
+			storedTransactions:     map[string]types.Transaction{},
+			transactionsMutex:      &sync.RWMutex{},
+			Client:                 &BaseFeeMockDoer{},
+			underpricedReplacement: policy,
+		}
+		require.NoError(t, client.StoreTransaction(*original))
+		return client
+	}
+
+	t.Run("with the reject policy, reject a speed-up below the base fee", func(t *testing.T) {
+		original := newTx(10)
+		client := newClient(config.UnderpricedReject, original)
+
+		err := client.StoreTransaction(*newTx(50))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "replacement underpriced")
+		require.Equal(t, types.STORED, client.storedTransactions[original.Hash().String()].Status)
+		require.Len(t, client.storedTransactions, 1)
+	})
+
+	t.Run("with the reject policy, accept a speed-up above the base fee", func(t *testing.T) {
+		original := newTx(10)
+		client := newClient(config.UnderpricedReject, original)
+
+		err := client.StoreTransaction(*newTx(200))
+		require.NoError(t, err)
+		require.Equal(t, types.SPEDUP, client.storedTransactions[original.Hash().String()].Status)
+	})
+
+	t.Run("with the warn policy, accept a speed-up below the base fee", func(t *testing.T) {
+		original := newTx(10)
+		client := newClient(config.UnderpricedWarn, original)
+
+		err := client.StoreTransaction(*newTx(50))
+		require.NoError(t, err)
+		require.Equal(t, types.SPEDUP, client.storedTransactions[original.Hash().String()].Status)
+	})
+}
+
+// tests the per-sender rate limit of StoreTransaction.
+func TestStoreTransactionSenderRateLimit(t *testing.T) {
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(key *ecdsa.PrivateKey, nonce uint64) *types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+	spammer, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		// One transaction per minute after a burst of two.
+		senderLimit: rate.Every(time.Minute),
+		senderBurst: 2,
+	}
+
+	t.Run("throttle rapid submissions from the same sender", func(t *testing.T) {
+		require.NoError(t, client.StoreTransaction(*newTx(spammer, 1)))
+		require.NoError(t, client.StoreTransaction(*newTx(spammer, 2)))
+
+		err := client.StoreTransaction(*newTx(spammer, 3))
+		require.ErrorIs(t, err, ErrSenderRateLimited)
+		require.Len(t, client.storedTransactions, 2)
+
+		// The bucket refills one token per minute.
+		var rateLimitErr *SenderRateLimitError
+		require.ErrorAs(t, err, &rateLimitErr)
+		require.InDelta(t, time.Minute.Seconds(), rateLimitErr.RetryAfter.Seconds(), 1)
+
+		// Computing the delay doesn't consume a token.
+		err = client.StoreTransaction(*newTx(spammer, 3))
+		require.InDelta(t, time.Minute.Seconds(), err.(*SenderRateLimitError).RetryAfter.Seconds(), 1)
+	})
+
+	t.Run("don't throttle another sender", func(t *testing.T) {
+		require.NoError(t, client.StoreTransaction(*newTx(other, 1)))
+	})
+}
+
+// tests the cancelTransaction function.
+func TestCancelTransaction(t *testing.T) {
+    // Test data
+	// tx1
+	tx1, err := getTxFromRaw(existingTransactionRaw) 
+	if err != nil {
+		t.Fatalf("Failed to decode transaction data: %v", err)
+	}
+	
+	  // Initialize EthClient
+    client := &EthClient{
+		storedTransactions: map[string]types.Transaction{
+			tx1.Hash().String(): *tx1,
+		},
+		transactionsMutex: &sync.RWMutex{},
+
+	}
+
+	client.storedTransactions[tx1.Hash().String()] = *tx1
+
+  
+
+    t.Run("cancel an existing transaction", func(t *testing.T) {
+        err := client.CancelTransaction(tx1.Hash().String())
+        require.NoError(t, err)
+        require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
+    })
+
+    t.Run("attempt to cancel a non-existing transaction", func(t *testing.T) {
+        err := client.CancelTransaction("non-existing")
+        require.Error(t, err)
+        require.Contains(t, err.Error(), "transaction not found")
+    })
+}
+
+
+// tests the GetTransaction function.
+func TestGetTransaction(t *testing.T) {
+	tx1, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{
+			tx1.Hash().String(): *tx1,
+		},
+		transactionsMutex: &sync.RWMutex{},
+	}
+
+	t.Run("get an existing transaction", func(t *testing.T) {
+		view, err := client.GetTransaction(tx1.Hash().String())
+		require.NoError(t, err)
+		require.Equal(t, tx1.Hash().String(), view.Hash)
+		require.Equal(t, "STORED", view.Status)
+		require.Equal(t, existingTransactionRaw, view.RawHex)
+	})
+
+	t.Run("attempt to get a non-existing transaction", func(t *testing.T) {
+		_, err := client.GetTransaction("non-existing")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "transaction not found")
+	})
+}
+
+// tests GetTransaction returns the position of a transaction in the queue.
+func TestGetTransactionPosition(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return *tx
+	}
+	other, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		allowUnprotected:   true,
+	}
+	txs := []types.Transaction{newTx(7), newTx(5), newTx(6), *other}
+	for _, tx := range txs {
+		require.NoError(t, client.StoreTransaction(tx))
+	}
+
+	t.Run("the position follows the nonce order of the sender", func(t *testing.T) {
+		for i, position := range []int{3, 1, 2} {
+			view, err := client.GetTransaction(txs[i].Hash().String())
+			require.NoError(t, err)
+			require.Equal(t, position, view.SenderPosition)
+			require.Equal(t, 4, view.QueueDepth)
+		}
+	})
+
+	t.Run("the transactions of another sender have their own positions", func(t *testing.T) {
+		view, err := client.GetTransaction(other.Hash().String())
+		require.NoError(t, err)
+		require.Equal(t, 1, view.SenderPosition)
+	})
+
+	t.Run("a transaction leaving the queue moves the next ones up", func(t *testing.T) {
+		require.NoError(t, client.CancelTransaction(txs[1].Hash().String()))
+
+		view, err := client.GetTransaction(txs[1].Hash().String())
+		require.NoError(t, err)
+		require.Zero(t, view.SenderPosition)
+		require.Equal(t, 3, view.QueueDepth)
+
+		view, err = client.GetTransaction(txs[2].Hash().String())
+		require.NoError(t, err)
+		require.Equal(t, 1, view.SenderPosition)
+	})
+}
+
+// tests ListTransactions returns the views of every transaction, like GetTransaction.
+func TestListTransactions(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return *tx
+	}
+	other, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		allowUnprotected:   true,
+	}
+	txs := []types.Transaction{newTx(7), newTx(5), newTx(6), *other}
+	for _, tx := range txs {
+		require.NoError(t, client.StoreTransaction(tx))
+	}
+	require.NoError(t, client.CancelTransaction(txs[1].Hash().String()))
+
+	views := client.ListTransactions()
+	require.Len(t, views, 4)
+	for _, view := range views {
+		expected, err := client.GetTransaction(view.Hash)
+		require.NoError(t, err)
+		require.Equal(t, expected, view)
+	}
+
+	view, err := client.GetTransaction(txs[0].Hash().String())
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey).Hex(), view.From)
+	require.Equal(t, hexutil.Uint64(7), *view.Nonce)
+	require.Equal(t, big.NewInt(2), view.GasFeeCap.ToInt())
+	require.Equal(t, big.NewInt(1), view.GasTipCap.ToInt())
+	require.Equal(t, 2, view.SenderPosition)
+	require.Equal(t, 3, view.QueueDepth)
+}
+
+// tests the label of a transaction round-trips through the store and the queries.
+func TestTransactionLabel(t *testing.T) {
+	tx, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	tx.Label = "payroll batch"
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+	require.NoError(t, client.StoreTransaction(*tx))
+
+	view, err := client.GetTransaction(tx.Hash().String())
+	require.NoError(t, err)
+	require.Equal(t, "payroll batch", view.Label)
+	require.Equal(t, "payroll batch", client.ListTransactions()[0].Label)
+}
+
+// tests the CancelTransactionByNonce function.
+func TestCancelTransactionByNonce(t *testing.T) {
+	tx1, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	from, err := sender(tx1)
+	require.NoError(t, err)
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+	require.NoError(t, client.StoreTransaction(*tx1))
+
+	t.Run("attempt to cancel with a nonce matching no transaction", func(t *testing.T) {
+		_, err := client.CancelTransactionByNonce(from, tx1.Nonce()+1)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "transaction not found")
+	})
+
+	t.Run("cancel a transaction by sender and nonce", func(t *testing.T) {
+		hash, err := client.CancelTransactionByNonce(from, tx1.Nonce())
+		require.NoError(t, err)
+		require.Equal(t, tx1.Hash().String(), hash)
+		require.Equal(t, types.CANCELED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("attempt to cancel a transaction that isn't STORED anymore", func(t *testing.T) {
+		_, err := client.CancelTransactionByNonce(from, tx1.Nonce())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "transaction not found")
+	})
+
+	t.Run("concurrent cancellations of a nonce cancel the transaction once", func(t *testing.T) {
+		client := &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+		}
+		require.NoError(t, client.StoreTransaction(*tx1))
+
+		errs := make(chan error, 10)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.CancelTransactionByNonce(from, tx1.Nonce())
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		canceled := 0
+		for err := range errs {
+			if err == nil {
+				canceled++
+				continue
+			}
+			require.Contains(t, err.Error(), "transaction not found")
+		}
+		require.Equal(t, 1, canceled)
+		require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
+	})
+}
+
+// tests the CancelableTransactions function.
+func TestCancelableTransactions(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		return *tx
+	}
+	other, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+	txs := []types.Transaction{newTx(8), newTx(5), newTx(6), newTx(7), *other}
+	for _, tx := range txs {
+		require.NoError(t, client.StoreTransaction(tx))
+	}
+	broadcast, inFlight := txs[2].Hash().String(), txs[3].Hash().String()
+	require.NoError(t, client.changeTransactionStatus(broadcast, types.BROADCASTED))
+	require.True(t, client.claimBroadcast(inFlight))
+
+	require.Equal(t, []string{txs[1].Hash().String(), txs[0].Hash().String()}, client.CancelableTransactions(from))
+	require.Empty(t, client.CancelableTransactions(common.HexToAddress("0x01")))
+}
+
+func TestTransactionStatuses(t *testing.T) {
+	tx1, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	tx2, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+	hash1, hash2 := tx1.Hash().String(), tx2.Hash().String()
+	client.addTransaction(hash1, *tx1)
+	client.addTransaction(hash2, *tx2)
+	require.NoError(t, client.changeTransactionStatus(hash2, types.BROADCASTED))
+	unknown := common.HexToHash("0x01").String()
+
+	require.Equal(t, map[string]string{hash1: "STORED", hash2: "BROADCASTED", unknown: StatusNotFound},
+		client.TransactionStatuses([]string{hash1, hash2, unknown}))
+}
+
+// tests the changeTransactionStatus function
+func TestChangeTransactionStatus(t *testing.T) {
+    // Test data
+	
+    // tx1
+	tx1, err := getTxFromRaw(existingTransactionRaw) 
+	if err != nil {
+		t.Fatalf("Failed to decode transaction data: %v", err)
+	}
+
+    client := &EthClient{
+		storedTransactions: map[string]types.Transaction{
+			tx1.Hash().String(): *tx1,
+		},
+		transactionsMutex: &sync.RWMutex{},
+
+	}
+
+
+
+    t.Run("valid status transition", func(t *testing.T) {
+        err := client.changeTransactionStatus(tx1.Hash().String(), types.CANCELED)
+        require.NoError(t, err)
+        require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
+    })
+
+    t.Run("invalid status transition", func(t *testing.T) {
+
+		// Prepare the transaction
+		tx1.Status = types.CANCELED
+		client.storedTransactions[tx1.Hash().String()] = *tx1
+
+
+        err := client.changeTransactionStatus(tx1.Hash().String(), types.STORED)
+        require.Error(t, err)
+        require.Contains(t, err.Error(), "invalid status transition")
+        require.Equal(t, types.CANCELED, client.storedTransactions[tx1.Hash().String()].Status)
+    })
+
+    t.Run("non-existing transaction", func(t *testing.T) {
+        err := client.changeTransactionStatus("non-existing", types.CANCELED)
+        require.Error(t, err)
+        require.Contains(t, err.Error(), "transaction not found")
+    })
+}
+
+// tests the status changes of a transaction being broadcast.
+func TestChangeTransactionStatusInFlight(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(to common.Address, feeCap int64) *types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(feeCap), Gas: 21000, To: &to,
+		}))
+		require.NoError(t, err)
+		return tx
+	}
+	newClient := func() (*EthClient, string) {
+		client := &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+		}
+		tx := newTx(common.Address{1}, 10)
+		require.NoError(t, client.StoreTransaction(*tx))
+		require.True(t, client.claimBroadcast(tx.Hash().String()))
+		return client, tx.Hash().String()
+	}
+
+	t.Run("reject the cancellation of a transaction being broadcast", func(t *testing.T) {
+		client, hash := newClient()
+
+		require.ErrorIs(t, client.CancelTransaction(hash), ErrAlreadyBroadcast)
+		_, err := client.CancelTransactionByNonce(from, 1)
+		require.ErrorIs(t, err, ErrAlreadyBroadcast)
+		require.ErrorIs(t, client.SetTransactionStatus(hash, types.FAILED), ErrAlreadyBroadcast)
+		require.Equal(t, types.STORED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("reject a cancel or a speed-up of a transaction being broadcast", func(t *testing.T) {
+		client, hash := newClient()
+
+		require.ErrorIs(t, client.StoreTransaction(*newTx(from, 20)), ErrAlreadyBroadcast)
+		require.ErrorIs(t, client.StoreTransaction(*newTx(common.Address{1}, 20)), ErrAlreadyBroadcast)
+		require.Equal(t, types.STORED, client.storedTransactions[hash].Status)
+		require.Len(t, client.storedTransactions, 1)
+	})
+
+	t.Run("let the transaction become BROADCASTED", func(t *testing.T) {
+		client, hash := newClient()
+
+		require.NoError(t, client.changeTransactionStatus(hash, types.BROADCASTED))
+	})
+
+	t.Run("let its broadcaster fail it", func(t *testing.T) {
+		client, hash := newClient()
+
+		require.NoError(t, client.settleBroadcast(hash, types.FAILED))
+		require.Equal(t, types.FAILED, client.storedTransactions[hash].Status)
+	})
+}
+
+// tests the SetTransactionStatus function.
+func TestSetTransactionStatus(t *testing.T) {
+	tx, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{hash: *tx},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+
+	t.Run("an allowed transition", func(t *testing.T) {
+		require.NoError(t, client.SetTransactionStatus(hash, types.FAILED))
+		require.Equal(t, types.FAILED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("a disallowed transition", func(t *testing.T) {
+		err := client.SetTransactionStatus(hash, types.STORED)
+		require.EqualError(t, err, "invalid status transition from FAILED to STORED for transaction: "+hash)
+		require.Equal(t, types.FAILED, client.storedTransactions[hash].Status)
+	})
+}
+
+// tests TestBroadcast goes through the guards of the monitor.
+func TestTestBroadcast(t *testing.T) {
+	tx, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(doer HTTPDoer) *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             doer,
+		}
+	}
+
+	t.Run("a rejected broadcast returns the node error", func(t *testing.T) {
+		client := newClient(&SequenceDoer{Bodies: []string{`{"jsonrpc":"2.0","id":1,"error":{"code":-32003,"message":"nonce too low"}}`}})
+
+		result, err := client.TestBroadcast(context.Background(), hash)
+		require.NoError(t, err)
+		require.Equal(t, types.TestBroadcastResult{Error: "nonce too low", NodeError: true, Code: -32003, Permanent: true}, result)
+		require.Equal(t, types.STORED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("a successful broadcast moves the transaction to BROADCASTED", func(t *testing.T) {
+		client := newClient(&MonitorGasMockDoer{})
+
+		result, err := client.TestBroadcast(context.Background(), hash)
+		require.NoError(t, err)
+		require.True(t, result.Sent)
+		require.Equal(t, types.BROADCASTED, client.storedTransactions[hash].Status)
+		require.False(t, client.storedTransactions[hash].InFlight)
+	})
+
+	t.Run("the next tick doesn't send the transaction again", func(t *testing.T) {
+		doer := &MethodRecordingDoer{}
+		client := newClient(doer)
+
+		_, err := client.TestBroadcast(context.Background(), hash)
+		require.NoError(t, err)
+		require.NoError(t, client.CheckOnce(context.Background()))
+		require.Equal(t, []string{"eth_sendRawTransaction", "eth_gasPrice"}, doer.Methods)
+		require.Equal(t, types.BROADCASTED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("a test broadcast is recorded in the broadcast log", func(t *testing.T) {
+		broadcasts, err := openBroadcastLog(filepath.Join(t.TempDir(), "broadcasts.log"), 10)
+		require.NoError(t, err)
+		defer broadcasts.close()
+		client := newClient(&MonitorGasMockDoer{})
+		client.broadcasts = broadcasts
+
+		_, err = client.TestBroadcast(context.Background(), hash)
+		require.NoError(t, err)
+		require.True(t, broadcasts.contains(hash))
+	})
+
+	t.Run("a read-only instance refuses the call", func(t *testing.T) {
+		doer := &MethodRecordingDoer{}
+		client := newClient(doer)
+		client.readOnly = true
+
+		_, err := client.TestBroadcast(context.Background(), hash)
+		require.EqualError(t, err, "read-only mode, transactions are never broadcast")
+		require.Empty(t, doer.Methods)
+		require.Equal(t, types.STORED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("a transaction being broadcast is refused", func(t *testing.T) {
+		doer := &MethodRecordingDoer{}
+		client := newClient(doer)
+		require.True(t, client.claimBroadcast(hash))
+
+		_, err := client.TestBroadcast(context.Background(), hash)
+		require.ErrorIs(t, err, ErrAlreadyBroadcast)
+		require.Empty(t, doer.Methods)
+	})
+
+	t.Run("an unknown transaction", func(t *testing.T) {
+		_, err := newClient(&MonitorGasMockDoer{}).TestBroadcast(context.Background(), "0x01")
+		require.EqualError(t, err, "transaction not found")
+	})
+}
+
+func TestCompareAndSetStatus(t *testing.T) {
+	tx, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func() *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			events:             newEventHub(10, false),
+		}
+	}
+
+	t.Run("set the status when it's the expected one", func(t *testing.T) {
+		client := newClient()
+		changes, unsubscribe := client.SubscribeStatusChanges()
+		defer unsubscribe()
+
+		swapped, err := client.CompareAndSetStatus(hash, types.STORED, types.CANCELED)
+		require.NoError(t, err)
+		require.True(t, swapped)
+		require.Equal(t, types.CANCELED, client.storedTransactions[hash].Status)
+		change := <-changes
+		require.Equal(t, "STORED", change.From)
+		require.Equal(t, "CANCELED", change.To)
+	})
+
+	t.Run("leave the status when it's not the expected one", func(t *testing.T) {
+		client := newClient()
+		require.NoError(t, client.changeTransactionStatus(hash, types.BROADCASTED))
+
+		swapped, err := client.CompareAndSetStatus(hash, types.STORED, types.CANCELED)
+		require.NoError(t, err)
+		require.False(t, swapped)
+		require.Equal(t, types.BROADCASTED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("leave a transaction being broadcast", func(t *testing.T) {
+		client := newClient()
+		require.True(t, client.claimBroadcast(hash))
+
+		swapped, err := client.CompareAndSetStatus(hash, types.STORED, types.FAILED)
+		require.NoError(t, err)
+		require.False(t, swapped)
+		require.Equal(t, types.STORED, client.storedTransactions[hash].Status)
+	})
+
+	t.Run("reject an unknown transaction or a transition that isn't allowed", func(t *testing.T) {
+		client := newClient()
+
+		_, err := client.CompareAndSetStatus("0x01", types.STORED, types.CANCELED)
+		require.EqualError(t, err, "transaction not found")
+		_, err = client.CompareAndSetStatus(hash, types.STORED, types.STORED)
+		require.EqualError(t, err, "invalid status transition from STORED to STORED for transaction: "+hash)
+		require.Equal(t, types.STORED, client.storedTransactions[hash].Status)
+	})
+}
+
+func TestStatusTotals(t *testing.T) {
+	tx1, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	tx2, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+	client.addTransaction(tx1.Hash().String(), *tx1)
+	client.addTransaction(tx2.Hash().String(), *tx2)
+	require.NoError(t, client.changeTransactionStatus(tx1.Hash().String(), types.BROADCASTED))
+	require.NoError(t, client.failTransaction(tx2.Hash().String(), evictedReason))
+
+	require.Equal(t, map[string]uint64{"STORED": 2, "CANCELED": 0, "SPEDUP": 0, "FAILED": 1, "BROADCASTED": 1, "EXPIRED": 0}, client.StatusTotals())
+}
+
+// tests the StatusTransitions function
+func TestStatusTransitions(t *testing.T) {
+	client := &EthClient{}
+
+	transitions := client.StatusTransitions()
+
+	require.ElementsMatch(t, []string{"CANCELED", "SPEDUP", "FAILED", "BROADCASTED", "EXPIRED"}, transitions["STORED"])
+	require.Equal(t, []string{"SPEDUP"}, transitions["CANCELED"])
+	require.Equal(t, []string{"SPEDUP"}, transitions["BROADCASTED"])
+	require.Len(t, transitions, len(allowedTransitions))
+}
+
+// tests the transactionsSnapshot function
+func TestTransactionsSnapshot(t *testing.T) {
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+	}
+	for _, raw := range []string{existingTransactionRaw, validTransactionRawHex, tx1SpeedUpRaw, tx1CancelRaw} {
+		tx, err := getTxFromRaw(raw)
+		require.NoError(t, err)
+		client.storedTransactions[tx.Hash().String()] = *tx
+	}
+
+	t.Run("the order is stable across repeated calls", func(t *testing.T) {
+		first := snapshotHashes(client.transactionsSnapshot())
+		require.Len(t, first, 4)
+		for i := 0; i < 20; i++ {
+			require.Equal(t, first, snapshotHashes(client.transactionsSnapshot()))
+		}
+	})
+
+	t.Run("transactions are ordered by sender then nonce", func(t *testing.T) {
+		snapshot := client.transactionsSnapshot()
+		for i := 1; i < len(snapshot); i++ {
+			prevSender, err := sender(&snapshot[i-1])
+			require.NoError(t, err)
+			curSender, err := sender(&snapshot[i])
+			require.NoError(t, err)
+
+			require.True(t, bytes.Compare(prevSender.Bytes(), curSender.Bytes()) <= 0)
+			if prevSender == curSender {
+				require.LessOrEqual(t, snapshot[i-1].Nonce(), snapshot[i].Nonce())
+			}
+		}
+	})
+}
+
+// tests the EligibleTransactions function
+func TestEligibleTransactions(t *testing.T) {
+	eligible, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	ineligible, err := getTxFromRaw(validTransactionRawHex)
+	require.NoError(t, err)
+	canceled, err := getTxFromRaw(tx1CancelRaw)
+	require.NoError(t, err)
+	canceled.Status = types.CANCELED
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{
+			eligible.Hash().String():   *eligible,
+			ineligible.Hash().String(): *ineligible,
+			canceled.Hash().String():   *canceled,
+		},
+		transactionsMutex: &sync.RWMutex{},
+	}
+
+	t.Run("return an error before the gas price is observed", func(t *testing.T) {
+		_, err := client.EligibleTransactions()
+		require.Error(t, err)
+	})
+
+	t.Run("return only the STORED transactions meeting the last gas price", func(t *testing.T) {
+		client.setLastGasPrice(1000000000)
+
+		hashes, err := client.EligibleTransactions()
+		require.NoError(t, err)
+		require.Equal(t, []string{eligible.Hash().String()}, hashes)
+	})
+
+	t.Run("return only the transactions the next tick confirms with BROADCAST_CONFIRM_TICKS", func(t *testing.T) {
+		client.broadcastConfirmTicks = 3
+		defer func() { client.broadcastConfirmTicks = 0 }()
+		hash := eligible.Hash().String()
+
+		hashes, err := client.EligibleTransactions()
+		require.NoError(t, err)
+		require.Empty(t, hashes)
+
+		client.recordEligibility(hash, true)
+		client.recordEligibility(hash, true)
+		hashes, err = client.EligibleTransactions()
+		require.NoError(t, err)
+		require.Equal(t, []string{hash}, hashes)
+	})
+
+	t.Run("return none on a read-only instance", func(t *testing.T) {
+		client.readOnly = true
+		defer func() { client.readOnly = false }()
+
+		hashes, err := client.EligibleTransactions()
+		require.NoError(t, err)
+		require.Empty(t, hashes)
+	})
+}
+
+// tests the list methods return empty lists, which encode as JSON arrays, when the queue is empty.
+func TestListsEmptyQueue(t *testing.T) {
+	ec := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		lastGasPrice:       1,
+	}
+	eligible, err := ec.EligibleTransactions()
+	require.NoError(t, err)
+
+	for name, list := range map[string]interface{}{
+		"ListTransactions":       ec.ListTransactions(),
+		"TransactionsByStatus":   ec.TransactionsByStatus(types.STORED),
+		"EligibleTransactions":   eligible,
+		"CancelableTransactions": ec.CancelableTransactions(common.Address{}),
+		"GasHistory":             ec.GasHistory(),
+		"Transactions":           ec.Transactions(),
+	} {
+		encoded, err := json.Marshal(list)
+		require.NoError(t, err)
+		require.Equal(t, "[]", string(encoded), name)
+	}
+}
+
+// tests the TransactionsByStatus function.
+func TestTransactionsByStatus(t *testing.T) {
+	first, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	second, err := getTxFromRaw(validTransactionRawHex)
+	require.NoError(t, err)
+	canceled, err := getTxFromRaw(tx1CancelRaw)
+	require.NoError(t, err)
+	canceled.Status = types.CANCELED
+
+	client := &EthClient{
+		storedTransactions: map[string]types.Transaction{
+			first.Hash().String():    *first,
+			second.Hash().String():   *second,
+			canceled.Hash().String(): *canceled,
+		},
+		transactionsMutex: &sync.RWMutex{},
+	}
+
+	stored := []string{first.Hash().String(), second.Hash().String()}
+	sort.Strings(stored)
+	require.Equal(t, stored, client.TransactionsByStatus(types.STORED))
+	require.Equal(t, []string{canceled.Hash().String()}, client.TransactionsByStatus(types.CANCELED))
+	require.Equal(t, []string{}, client.TransactionsByStatus(types.BROADCASTED))
+}
+
+// tests the GasStats function.
+func TestGasStats(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+	newTx := func(nonce uint64, feeCap int64, status types.TransactionStatus) types.Transaction {
+		tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(feeCap), Gas: 21000, To: &common.Address{},
+		}))
+		require.NoError(t, err)
+		tx.Status = status
+		return *tx
+	}
+
+	t.Run("without stored transactions, the gas caps are empty", func(t *testing.T) {
+		client := &EthClient{storedTransactions: map[string]types.Transaction{}, transactionsMutex: &sync.RWMutex{}}
+
+		stats := client.GasStats()
+		require.Zero(t, stats.Stored)
+		require.Nil(t, stats.MinGasCap)
+		require.Nil(t, stats.GasPrice)
+	})
+
+	t.Run("aggregate the gas caps of the stored transactions only", func(t *testing.T) {
+		client := &EthClient{storedTransactions: map[string]types.Transaction{}, transactionsMutex: &sync.RWMutex{}}
+		for _, tx := range []types.Transaction{
+			newTx(1, 9, types.STORED),
+			newTx(2, 19, types.STORED),
+			newTx(3, 39, types.STORED),
+			newTx(4, 1000, types.BROADCASTED),
+		} {
+			client.storedTransactions[tx.Hash().String()] = tx
+		}
+		client.setLastGasPrice(25)
+
+		stats := client.GasStats()
+		require.Equal(t, 3, stats.Stored)
+		require.Equal(t, big.NewInt(10), stats.MinGasCap.ToInt())
+		require.Equal(t, big.NewInt(40), stats.MaxGasCap.ToInt())
+		require.Equal(t, big.NewInt(23), stats.AvgGasCap.ToInt())
+		require.Equal(t, big.NewInt(25), stats.GasPrice.ToInt())
+	})
+}
+
+// tests Stats counts the stored transactions by status, the checks and the upstream errors.
+func TestStats(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	failed, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	failed.Status = types.FAILED
+	ec := &EthClient{
+		storedTransactions: map[string]types.Transaction{
+			tx.Hash().String():     *tx,
+			failed.Hash().String(): *failed,
+		},
+		transactionsMutex: &sync.RWMutex{},
+		Client:            &MonitorGasMockDoer{},
+	}
+
+	stats := ec.Stats()
+	require.Equal(t, map[string]int{"STORED": 1, "CANCELED": 0, "SPEDUP": 0, "FAILED": 1, "BROADCASTED": 0, "EXPIRED": 0}, stats.Queue)
+	require.Nil(t, stats.GasPrice)
+	require.Zero(t, stats.Checks)
+
+	require.NoError(t, ec.CheckOnce(context.Background()))
+	ec.Client = &CountingDoer{}
+	require.Error(t, ec.CheckOnce(context.Background()))
+
+	stats = ec.Stats()
+	require.Equal(t, 0, stats.Queue["STORED"])
+	require.Equal(t, 1, stats.Queue["BROADCASTED"])
+	require.Equal(t, big.NewInt(1), stats.GasPrice.ToInt())
+	require.Equal(t, uint64(2), stats.Checks)
+	require.Equal(t, uint64(1), stats.UpstreamErrors)
+}
+
+// For the gasMonitor test I will to mock the do function to be able to read the body twice.
+type MonitorGasMockDoer struct {
+	Response *http.Response
+	Err      error
+}
+func (m *MonitorGasMockDoer) Do(req *http.Request) (*http.Response, error) {
+	body := io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body: body,
+	}, nil
+}
+
+// BarrierDoer holds the gas price requests until all the concurrent checks fetched it, then answers the broadcasts slowly.
+type BarrierDoer struct {
+	gasPrices  sync.WaitGroup
+	mu         sync.Mutex
+	broadcasts int
+}
+
+func (m *BarrierDoer) Do(req *http.Request) (*http.Response, error) {
+	var rpcReq types.JSONRPCRequest
+	err := json.NewDecoder(req.Body).Decode(&rpcReq)
+	if err != nil {
+		return nil, err
+	}
+	if rpcReq.Method == "eth_gasPrice" {
+		m.gasPrices.Done()
+		m.gasPrices.Wait()
+	} else {
+		m.mu.Lock()
+		m.broadcasts++
+		m.mu.Unlock()
+		// Leave the other checks time to consider the transaction.
+		time.Sleep(50 * time.Millisecond)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"jsonrpc":"2.0","id":1,"result":"0x1"}`)),
+	}, nil
+}
+
+// tests concurrent checks, e.g. a monitor tick and a manual trigger, broadcast a transaction once.
+func TestCheckOnceConcurrent(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(doer HTTPDoer) *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{
+				hash: *tx,
+			},
+			transactionsMutex: &sync.RWMutex{},
+			Client:            doer,
+		}
+	}
+
+	t.Run("simultaneous checks send the transaction once", func(t *testing.T) {
+		doer := &BarrierDoer{}
+		ec := newClient(doer)
+
+		const checks = 3
+		doer.gasPrices.Add(checks)
+		var wg sync.WaitGroup
+		for i := 0; i < checks; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				require.NoError(t, ec.CheckOnce(context.Background()))
+			}()
+		}
+		wg.Wait()
+
+		require.Equal(t, 1, doer.broadcasts)
+		stored := ec.storedTransactions[hash]
+		require.Equal(t, types.BROADCASTED, stored.Status)
+		require.False(t, stored.InFlight)
+	})
+
+	t.Run("a transaction in flight is skipped", func(t *testing.T) {
+		doer := &MethodRecordingDoer{}
+		ec := newClient(doer)
+		require.True(t, ec.claimBroadcast(hash))
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, []string{"eth_gasPrice"}, doer.Methods)
+		require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+
+		ec.releaseBroadcast(hash)
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, []string{"eth_gasPrice", "eth_gasPrice", "eth_sendRawTransaction"}, doer.Methods)
+	})
+
+	t.Run("a check working on a stale snapshot doesn't send a broadcast transaction again", func(t *testing.T) {
+		ec := newClient(&MethodRecordingDoer{})
+		stale := ec.transactionsSnapshot()
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.STORED, stale[0].Status)
+		require.False(t, ec.claimBroadcast(stale[0].Hash().String()))
+	})
+}
+
+// tests a transaction must stay eligible for broadcastConfirmTicks consecutive checks to be broadcast.
+func TestCheckOnceConfirmTicks(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	low := `{"jsonrpc":"2.0","id":1,"result":"0x1"}`
+	high := `{"jsonrpc":"2.0","id":1,"result":"0xe8d4a51000"}`
+	sent := `{"jsonrpc":"2.0","id":1,"result":"` + hash + `"}`
+	newClient := func(doer HTTPDoer) *EthClient {
+		return &EthClient{
+			storedTransactions:    map[string]types.Transaction{hash: *tx},
+			transactionsMutex:     &sync.RWMutex{},
+			Client:                doer,
+			broadcastConfirmTicks: 2,
+		}
+	}
+
+	t.Run("broadcast on the second eligible tick", func(t *testing.T) {
+		ec := newClient(&SequenceDoer{Bodies: []string{low, low, sent}})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("a dip in between doesn't count", func(t *testing.T) {
+		ec := newClient(&SequenceDoer{Bodies: []string{low, high, low, low, sent}})
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, ec.CheckOnce(context.Background()))
+			require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+		}
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+}
+
+// tests a transaction with a deadline is broadcast when the gas price is favorable or the deadline is reached, whichever comes first.
+func TestCheckOnceDeadline(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	low := `{"jsonrpc":"2.0","id":1,"result":"0x1"}`
+	high := `{"jsonrpc":"2.0","id":1,"result":"0xe8d4a51000"}`
+	sent := `{"jsonrpc":"2.0","id":1,"result":"` + hash + `"}`
+	rejected := `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"txpool is full"}}`
+	newClient := func(deadline time.Time, doer HTTPDoer) *EthClient {
+		withDeadline := *tx
+		withDeadline.Deadline = deadline
+		return &EthClient{
+			storedTransactions:  map[string]types.Transaction{hash: withDeadline},
+			transactionsMutex:   &sync.RWMutex{},
+			Client:              doer,
+			broadcastErrorGrace: 3,
+		}
+	}
+
+	t.Run("broadcast when the gas price is favorable before the deadline", func(t *testing.T) {
+		ec := newClient(time.Now().Add(time.Hour), &SequenceDoer{Bodies: []string{low, sent}})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("wait while the gas price is too high and the deadline isn't reached", func(t *testing.T) {
+		ec := newClient(time.Now().Add(time.Hour), &SequenceDoer{Bodies: []string{high}})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("broadcast whatever the gas price once the deadline is reached", func(t *testing.T) {
+		ec := newClient(time.Now().Add(-time.Second), &SequenceDoer{Bodies: []string{high, sent}})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("fail the transaction when it can't be broadcast once the deadline is reached", func(t *testing.T) {
+		ec := newClient(time.Now().Add(-time.Second), &SequenceDoer{Bodies: []string{high, rejected}})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.FAILED, ec.storedTransactions[hash].Status)
+		require.Equal(t, "deadline expired", ec.storedTransactions[hash].Reason)
+	})
+}
+
+// tests the gas saved is estimated from the gas prices at store and broadcast times.
+func TestGasSaved(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	ec := &EthClient{
+		storedTransactions: map[string]types.Transaction{},
+		transactionsMutex:  &sync.RWMutex{},
+		Client: &SequenceDoer{Bodies: []string{
+			`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+			`{"jsonrpc":"2.0","id":1,"result":"` + hash + `"}`,
+		}},
+		lastGasPrice: 1000,
+	}
+
+	require.NoError(t, ec.StoreTransaction(*tx))
+	require.Equal(t, float64(1000), ec.storedTransactions[hash].StoreGasPrice)
+	require.Equal(t, big.NewInt(0), ec.GasSaved())
+
+	require.NoError(t, ec.CheckOnce(context.Background()))
+	require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	require.Equal(t, new(big.Int).SetUint64(999*tx.Gas()), ec.GasSaved())
+	require.Positive(t, ec.GasSaved().Sign())
+}
+
+// stubBroadcaster records the broadcast transactions instead of sending them.
+type stubBroadcaster struct {
+	sent []string
+	err  error
+}
+
+func (b *stubBroadcaster) Broadcast(ctx context.Context, rawHex string) (string, bool, error) {
+	if b.err != nil {
+		return "", true, b.err
+	}
+	b.sent = append(b.sent, rawHex)
+	tx, err := getTxFromRaw(rawHex)
+	if err != nil {
+		return "", true, err
+	}
+	return tx.Hash().String(), false, nil
+}
+
+// tests the monitor never broadcasts a transaction paying a gas price above the max gas price.
+func TestCheckOnceMaxGasPrice(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	// The fee cap of the transaction is 2982525096 wei.
+	newClient := func(maxGasPrice int64, deadline time.Time) (*EthClient, *stubBroadcaster) {
+		stored := *tx
+		stored.Deadline = deadline
+		broadcaster := &stubBroadcaster{}
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: stored},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &MethodRecordingDoer{},
+			maxGasPrice:        big.NewInt(maxGasPrice),
+		}
+		ec.SetBroadcaster(broadcaster)
+		return ec, broadcaster
+	}
+
+	t.Run("hold back an eligible transaction above the max gas price", func(t *testing.T) {
+		ec, broadcaster := newClient(2e9, time.Time{})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+		require.Empty(t, broadcaster.sent)
+		require.Equal(t, log.WarnLevel, hook.LastEntry().Level)
+		require.Contains(t, hook.LastEntry().Message, "above the max gas price 2000000000")
+
+		eligible, err := ec.EligibleTransactions()
+		require.NoError(t, err)
+		require.Empty(t, eligible)
+		reason, err := ec.BroadcastReason(context.Background(), hash)
+		require.NoError(t, err)
+		require.Equal(t, "gas price above the max gas price (price 2982525096 > max 2000000000)", reason)
+	})
+
+	t.Run("broadcast a transaction within the max gas price", func(t *testing.T) {
+		ec, broadcaster := newClient(3e9, time.Time{})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+		require.Len(t, broadcaster.sent, 1)
+	})
+
+	t.Run("broadcast a transaction whose fee cap is above the max gas price but not the price it pays", func(t *testing.T) {
+		ec, broadcaster := newClient(2e9, time.Time{})
+		// The pending base fee is 100 wei, so the transaction pays its tip of 1 gwei plus 100 wei.
+		ec.Client = &SequenceDoer{Bodies: []string{
+			`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+			`{"jsonrpc":"2.0","id":1,"result":{"number":"0x10","baseFeePerGas":"0x64"}}`,
+		}}
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+		require.Len(t, broadcaster.sent, 1)
+	})
+
+	t.Run("fail a transaction above the max gas price once its deadline is reached", func(t *testing.T) {
+		ec, broadcaster := newClient(2e9, time.Now().Add(-time.Second))
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.FAILED, ec.storedTransactions[hash].Status)
+		require.Equal(t, "deadline expired", ec.storedTransactions[hash].Reason)
+		require.Empty(t, broadcaster.sent)
+	})
+}
+
+// tests the monitor expires the transactions STORED longer than the time to live instead of broadcasting them.
+func TestCheckOnceTxTTL(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(expiresAt time.Time) (*EthClient, *stubBroadcaster) {
+		stored := *tx
+		stored.ExpiresAt = expiresAt
+		broadcaster := &stubBroadcaster{}
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: stored},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &MethodRecordingDoer{},
+			clockSkew:          time.Minute,
+		}
+		ec.SetBroadcaster(broadcaster)
+		return ec, broadcaster
+	}
+
+	t.Run("expire a transaction past its expiry and the clock skew tolerance", func(t *testing.T) {
+		ec, broadcaster := newClient(time.Now().Add(-2 * time.Minute))
+		ec.setLastGasPrice(1)
+
+		eligible, err := ec.EligibleTransactions()
+		require.NoError(t, err)
+		require.Empty(t, eligible)
+		reason, err := ec.BroadcastReason(context.Background(), hash)
+		require.NoError(t, err)
+		require.Contains(t, reason, "expired")
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.EXPIRED, ec.storedTransactions[hash].Status)
+		require.Empty(t, broadcaster.sent)
+	})
+
+	t.Run("broadcast a transaction within the clock skew tolerance", func(t *testing.T) {
+		ec, broadcaster := newClient(time.Now().Add(-time.Second))
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+		require.Len(t, broadcaster.sent, 1)
+	})
+
+	t.Run("set the expiry of the transactions without deadline when they're stored", func(t *testing.T) {
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+			txTTL:              time.Hour,
+		}
+		withDeadline := *tx
+		withDeadline.Deadline = time.Now().Add(2 * time.Hour)
+		ec.addTransaction("0x01", *tx)
+		ec.addTransaction("0x02", withDeadline)
+
+		stored := ec.storedTransactions["0x01"]
+		require.Equal(t, stored.StoredAt.Add(time.Hour), stored.ExpiresAt)
+		require.True(t, ec.storedTransactions["0x02"].ExpiresAt.IsZero())
+
+		view, err := ec.GetTransaction("0x01")
+		require.NoError(t, err)
+		require.Equal(t, stored.ExpiresAt, *view.ExpiresAt)
+		view, err = ec.GetTransaction("0x02")
+		require.NoError(t, err)
+		require.Nil(t, view.ExpiresAt)
+	})
+}
+
+// tests the monitor broadcasts through the configured Broadcaster.
+func TestCheckOnceBroadcaster(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(broadcaster Broadcaster) (*EthClient, *MethodRecordingDoer) {
+		doer := &MethodRecordingDoer{}
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             doer,
+		}
+		ec.SetBroadcaster(broadcaster)
+		return ec, doer
+	}
+
+	t.Run("the transaction is sent by the broadcaster, not the node", func(t *testing.T) {
+		broadcaster := &stubBroadcaster{}
+		ec, doer := newClient(broadcaster)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+		require.Equal(t, []string{tx1SpeedUpRaw}, broadcaster.sent)
+		require.Equal(t, []string{"eth_gasPrice"}, doer.Methods)
+	})
+
+	t.Run("a rejection by the broadcaster fails the transaction", func(t *testing.T) {
+		ec, _ := newClient(&stubBroadcaster{err: errors.New("nonce too low")})
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.FAILED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("the store isn't locked while the transaction is being sent", func(t *testing.T) {
+		broadcaster := &blockingBroadcaster{started: make(chan struct{}), release: make(chan struct{})}
+		ec, _ := newClient(broadcaster)
+
+		done := make(chan error)
+		go func() { done <- ec.CheckOnce(context.Background()) }()
+		<-broadcaster.started
+
+		view, err := ec.GetTransaction(hash)
+		require.NoError(t, err)
+		require.Equal(t, "STORED", view.Status)
+		require.ErrorIs(t, ec.CancelIfStored(hash), ErrAlreadyBroadcast)
+
+		close(broadcaster.release)
+		require.NoError(t, <-done)
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+}
+
+// blockingBroadcaster accepts the transactions once released, signaling when a send starts.
+type blockingBroadcaster struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBroadcaster) Broadcast(ctx context.Context, rawHex string) (string, bool, error) {
+	close(b.started)
+	<-b.release
+	return "", false, nil
+}
+
+// tests cancelling a transaction only if it's still STORED.
+func TestCancelIfStored(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(status types.TransactionStatus) *EthClient {
+		stored := *tx
+		stored.Status = status
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: stored},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &MethodRecordingDoer{},
+		}
+	}
+
+	t.Run("a STORED transaction is canceled", func(t *testing.T) {
+		ec := newClient(types.STORED)
+		require.NoError(t, ec.CancelIfStored(hash))
+		require.Equal(t, types.CANCELED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("a broadcast transaction isn't", func(t *testing.T) {
+		ec := newClient(types.BROADCASTED)
+		require.ErrorIs(t, ec.CancelIfStored(hash), ErrAlreadyBroadcast)
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("neither is a transaction being broadcast", func(t *testing.T) {
+		ec := newClient(types.STORED)
+		require.True(t, ec.claimBroadcast(hash))
+		require.ErrorIs(t, ec.CancelIfStored(hash), ErrAlreadyBroadcast)
+		require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("nor a transaction in another status", func(t *testing.T) {
+		ec := newClient(types.FAILED)
+		require.EqualError(t, ec.CancelIfStored(hash), "transaction is FAILED, not STORED")
+		require.EqualError(t, ec.CancelIfStored(common.Hash{}.String()), "transaction not found")
+	})
+
+	t.Run("racing the monitor, the transaction is either canceled or broadcast", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			ec := newClient(types.STORED)
+			doer := ec.Client.(*MethodRecordingDoer)
+
+			var wg sync.WaitGroup
+			var checkErr, cancelErr error
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				checkErr = ec.CheckOnce(context.Background())
+			}()
+			go func() {
+				defer wg.Done()
+				cancelErr = ec.CancelIfStored(hash)
+			}()
+			wg.Wait()
+			require.NoError(t, checkErr)
+
+			if cancelErr == nil {
+				require.Equal(t, types.CANCELED, ec.storedTransactions[hash].Status)
+				require.NotContains(t, doer.Methods, "eth_sendRawTransaction")
+			} else {
+				require.ErrorIs(t, cancelErr, ErrAlreadyBroadcast)
+				require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+			}
+		}
+	})
+}
+
+// tests the explanations of why a transaction isn't broadcast.
+func TestBroadcastReason(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(gasPrice float64, bodies ...string) *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &SequenceDoer{Bodies: bodies},
+			lastGasPrice:       gasPrice,
+		}
+	}
+
+	t.Run("the gas price is too high", func(t *testing.T) {
+		ec := newClient(4000000000)
+
+		reason, err := ec.BroadcastReason(context.Background(), hash)
+		require.NoError(t, err)
+		require.Equal(t, "gas price too high (cap 3982525096 < price 4000000000)", reason)
+	})
+
+	t.Run("the sender's previous nonces are missing", func(t *testing.T) {
+		ec := newClient(1, `{"jsonrpc":"2.0","id":1,"result":"0x10"}`)
+
+		reason, err := ec.BroadcastReason(context.Background(), hash)
+		require.NoError(t, err)
+		require.Equal(t, "nonce gap (nonce 24, next on-chain nonce 16), it will be broadcast but not mined before the missing nonces", reason)
+	})
+
+	t.Run("nothing holds the transaction", func(t *testing.T) {
+		ec := newClient(1, `{"jsonrpc":"2.0","id":1,"result":"0x18"}`)
+
+		reason, err := ec.BroadcastReason(context.Background(), hash)
+		require.NoError(t, err)
+		require.Equal(t, "eligible, it will be broadcast by the next check", reason)
+	})
+
+	t.Run("the transaction isn't queued anymore", func(t *testing.T) {
+		ec := newClient(1)
+		require.NoError(t, ec.changeTransactionStatus(hash, types.BROADCASTED))
+
+		reason, err := ec.BroadcastReason(context.Background(), hash)
+		require.NoError(t, err)
+		require.Equal(t, "already broadcast", reason)
+
+		_, err = ec.BroadcastReason(context.Background(), common.Hash{}.String())
+		require.EqualError(t, err, "transaction not found")
+	})
+}
+
+// tests base fee aware broadcasting of a dynamic fee transaction, whose fee cap must cover the pending base fee plus its tip.
+func TestCheckOnceBaseFee(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	require.Equal(t, uint8(ethTypes.DynamicFeeTxType), tx.Type())
+	hash := tx.Hash().String()
+	low := `{"jsonrpc":"2.0","id":1,"result":"0x1"}`
+	high := `{"jsonrpc":"2.0","id":1,"result":"0xe8d4a51000"}`
+	block := func(baseFee string) string {
+		return `{"jsonrpc":"2.0","id":1,"result":{"number":"0x1","baseFeePerGas":"` + baseFee + `"}}`
+	}
+	sent := `{"jsonrpc":"2.0","id":1,"result":"` + hash + `"}`
+	newClient := func(bodies ...string) *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &SequenceDoer{Bodies: bodies},
+			baseFeeAware:       true,
+		}
+	}
+
+	t.Run("broadcast when the fee cap covers the base fee and the tip, whatever the gas price", func(t *testing.T) {
+		// 1 gwei base fee + 1 gwei tip <= 2.98 gwei fee cap.
+		ec := newClient(high, block("0x3b9aca00"), sent)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("wait while the base fee and the tip exceed the fee cap, whatever the gas price", func(t *testing.T) {
+		// 2 gwei base fee + 1 gwei tip > 2.98 gwei fee cap.
+		ec := newClient(low, block("0x77359400"))
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+
+		reason, err := ec.BroadcastReason(context.Background(), hash)
+		require.NoError(t, err)
+		require.Equal(t, "base fee too high (fee cap 2982525096 < base fee 2000000000 + tip 1000000000)", reason)
+	})
+
+	t.Run("fall back to the gas price on networks without base fee", func(t *testing.T) {
+		ec := newClient(low, `{"jsonrpc":"2.0","id":1,"result":{"number":"0x1"}}`, sent)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+	})
+}
+
+// tests the broadcast attempts of a transaction are recorded.
+func TestBroadcastAttempts(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(historySize int, bodies ...string) *EthClient {
+		return &EthClient{
+			storedTransactions:  map[string]types.Transaction{hash: *tx},
+			transactionsMutex:   &sync.RWMutex{},
+			Client:              &SequenceDoer{Bodies: bodies},
+			broadcastErrorGrace: 1,
+			attemptHistorySize:  historySize,
+		}
+	}
+	gasPrice := `{"jsonrpc":"2.0","id":1,"result":"0x5"}`
+	rejected := `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"txpool is full"}}`
+	sent := `{"jsonrpc":"2.0","id":1,"result":"` + hash + `"}`
+
+	t.Run("a retried broadcast records both attempts", func(t *testing.T) {
+		ec := newClient(10, gasPrice, rejected, gasPrice, sent)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+
+		view, err := ec.GetTransaction(hash)
+		require.NoError(t, err)
+		require.Len(t, view.Attempts, 2)
+		require.Equal(t, types.AttemptRejected, view.Attempts[0].Outcome)
+		require.Equal(t, "txpool is full", view.Attempts[0].Error)
+		require.Equal(t, big.NewInt(5), view.Attempts[0].GasPrice.ToInt())
+		require.Equal(t, types.AttemptSent, view.Attempts[1].Outcome)
+		require.Empty(t, view.Attempts[1].Error)
+		require.False(t, view.Attempts[1].Time.Before(view.Attempts[0].Time))
+	})
+
+	t.Run("only the last attempts are kept", func(t *testing.T) {
+		ec := newClient(1, gasPrice, rejected, gasPrice, sent)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		attempts := ec.storedTransactions[hash].Attempts
+		require.Len(t, attempts, 1)
+		require.Equal(t, types.AttemptSent, attempts[0].Outcome)
+	})
+
+	t.Run("no attempt is kept when the history is disabled", func(t *testing.T) {
+		ec := newClient(0, gasPrice, sent)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Empty(t, ec.storedTransactions[hash].Attempts)
+	})
+}
+
+// tests a read-only instance never broadcasts.
+func TestCheckOnceReadOnly(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	doer := &MethodRecordingDoer{}
+	ec := &EthClient{
+		storedTransactions: map[string]types.Transaction{hash: *tx},
+		transactionsMutex:  &sync.RWMutex{},
+		Client:             doer,
+		readOnly:           true,
+	}
+
+	require.NoError(t, ec.CheckOnce(context.Background()))
+	require.NoError(t, ec.CheckTransaction(context.Background(), hash))
+
+	require.Equal(t, []string{"eth_gasPrice", "eth_gasPrice"}, doer.Methods)
+	require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+	require.Equal(t, float64(1), ec.LastGasPrice())
+}
+
+// tests a stored transaction is broadcast right away when broadcastOnStore is set.
+func TestBroadcastOnStore(t *testing.T) {
+	newClient := func(broadcastOnStore bool) *EthClient {
+		return &EthClient{
+			storedTransactions:     map[string]types.Transaction{},
+			transactionsMutex:      &sync.RWMutex{},
+			Client:                 &MonitorGasMockDoer{},
+			gasMonitoringFrequence: time.Hour,
+			allowUnprotected:       true,
+			broadcastOnStore:       broadcastOnStore,
+		}
+	}
+
+	t.Run("an eligible transaction is broadcast without waiting for a tick", func(t *testing.T) {
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+		ec := newClient(true)
+
+		require.NoError(t, ec.StoreTransaction(*tx))
+		ec.monitorWG.Wait()
+
+		view, err := ec.GetTransaction(tx.Hash().String())
+		require.NoError(t, err)
+		require.Equal(t, types.BROADCASTED.String(), view.Status)
+		require.Equal(t, float64(1), ec.LastGasPrice())
+	})
+
+	t.Run("the transaction waits for the next tick by default", func(t *testing.T) {
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+		ec := newClient(false)
+
+		require.NoError(t, ec.StoreTransaction(*tx))
+		ec.monitorWG.Wait()
+
+		view, err := ec.GetTransaction(tx.Hash().String())
+		require.NoError(t, err)
+		require.Equal(t, types.STORED.String(), view.Status)
+	})
+
+	t.Run("the gas price is fetched once for a batch", func(t *testing.T) {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+		txs := make([]types.Transaction, 3)
+		for i := range txs {
+			tx, err := getTxFromRaw(signRawTx(t, key, signer, &ethTypes.DynamicFeeTx{
+				ChainID: big.NewInt(5), Nonce: uint64(i), GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 21000, To: &common.Address{1},
+			}))
+			require.NoError(t, err)
+			txs[i] = *tx
+		}
+		doer := &MethodRecordingDoer{}
+		ec := newClient(true)
+		ec.Client = doer
+		ec.readOnly = true
+
+		_, err = ec.StoreTransactions(txs)
+		require.NoError(t, err)
+		ec.monitorWG.Wait()
+
+		require.Equal(t, []string{"eth_gasPrice"}, doer.Methods)
+	})
+
+	t.Run("shutdown cancels the checks", func(t *testing.T) {
+		ec := newClient(true)
+		ec.checksCtx, ec.cancelChecks = context.WithCancel(context.Background())
+
+		require.NoError(t, ec.Shutdown(context.Background()))
+		require.ErrorIs(t, ec.checksContext().Err(), context.Canceled)
+	})
+
+	t.Run("an unknown transaction", func(t *testing.T) {
+		err := newClient(true).CheckTransaction(context.Background(), "0x01")
+		require.EqualError(t, err, "transaction not found")
+	})
+}
+
+// SequenceDoer answers the requests with the given bodies in order.
+type SequenceDoer struct {
+	Bodies []string
+	calls  int
+}
+
+func (m *SequenceDoer) Do(req *http.Request) (*http.Response, error) {
+	body := m.Bodies[m.calls]
+	m.calls++
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestCheckOnce(t *testing.T) {
+
+	t.Run("broadcast the transaction at the right gas price", func(t *testing.T) {
+		// prepare data
+		tx, err := getTxFromRaw(tx1SpeedUpRaw) 
+		if err != nil {
+			t.Fatalf("Failed to decode transaction data: %v", err)
+		}
+
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{
+					tx.Hash().String(): *tx,
+				},
+				transactionsMutex: &sync.RWMutex{},
+				Client: &MonitorGasMockDoer{},
+			}
+
+		err = ec.CheckOnce(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[tx.Hash().String()].Status)
+		require.Equal(t, float64(1), ec.LastGasPrice())
+	})
+
+	t.Run("gas price isn't low enough to broadcast transaction.", func(t *testing.T) {
+		// prepare data
+		tx, err := getTxFromRaw(existingTransactionRaw) 
+		if err != nil {
+			t.Fatalf("Failed to decode transaction data: %v", err)
+		}
+
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{
+					tx.Hash().String(): *tx,
+				},
+				transactionsMutex: &sync.RWMutex{},
+				Client: &MonitorGasMockDoer{},
+			}
+
+		err = ec.CheckOnce(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, types.STORED, ec.storedTransactions[tx.Hash().String()].Status)
+	})
+
+	t.Run("try to broadcast the transaction but sendTransaction return an error but not rpcError", func(t *testing.T) {
+		// prepare data
+		tx, err := getTxFromRaw(tx1SpeedUpRaw) 
+		if err != nil {
+			t.Fatalf("Failed to decode transaction data: %v", err)
+		}
+
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{
 					tx.Hash().String(): *tx,
 				},
-				transactionsMutex: &sync.Mutex{},
-				gasMonitoringFrequence: time.Millisecond * 50,
+				transactionsMutex: &sync.RWMutex{},
 				Client: &MockDoer{
 					Response: &http.Response{
 						StatusCode: http.StatusOK,
@@ -441,20 +2856,145 @@ func TestMonitorGas(t *testing.T) {
 					},
 				},
 			}
-		
-		go ec.MonitorGas(ctx)
 
-		// Give the MonitorGas method some time to run
-		time.Sleep(time.Millisecond * 60)
+		err = ec.CheckOnce(context.Background())
+		require.NoError(t, err)
+
+		require.Equal(t, types.STORED, ec.storedTransactions[tx.Hash().String()].Status)
+	})
+
+	t.Run("a transaction survives a transient RPC error within the grace period then gets broadcast", func(t *testing.T) {
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{
+					tx.Hash().String(): *tx,
+				},
+				transactionsMutex: &sync.RWMutex{},
+				broadcastErrorGrace: 1,
+				Client: &SequenceDoer{Bodies: []string{
+					`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+					`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`,
+					`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+					`{"jsonrpc":"2.0","id":1,"result":"0xabc"}`,
+				}},
+			}
 
+		require.NoError(t, ec.CheckOnce(context.Background()))
 		require.Equal(t, types.STORED, ec.storedTransactions[tx.Hash().String()].Status)
+		require.Equal(t, 1, ec.storedTransactions[tx.Hash().String()].BroadcastErrors)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[tx.Hash().String()].Status)
+	})
+
+	t.Run("a transaction is failed once the grace period is exhausted", func(t *testing.T) {
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{
+					tx.Hash().String(): *tx,
+				},
+				transactionsMutex: &sync.RWMutex{},
+				broadcastErrorGrace: 1,
+				Client: &SequenceDoer{Bodies: []string{
+					`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+					`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`,
+					`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+					`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`,
+				}},
+			}
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.FAILED, ec.storedTransactions[tx.Hash().String()].Status)
+	})
+
+	t.Run("a permanent RPC error fails the transaction despite the grace period", func(t *testing.T) {
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{
+					tx.Hash().String(): *tx,
+				},
+				transactionsMutex: &sync.RWMutex{},
+				broadcastErrorGrace: 3,
+				Client: &SequenceDoer{Bodies: []string{
+					`{"jsonrpc":"2.0","id":1,"result":"0x1"}`,
+					`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"nonce too low"}}`,
+				}},
+			}
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.FAILED, ec.storedTransactions[tx.Hash().String()].Status)
+	})
+
+	t.Run("when the gas price can't be fetched, return an error", func(t *testing.T) {
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{},
+				transactionsMutex: &sync.RWMutex{},
+				Client: &CountingDoer{},
+			}
+
+		err := ec.CheckOnce(context.Background())
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to get gas price")
+		require.Equal(t, float64(0), ec.LastGasPrice())
 	})
+}
+
+func TestMonitorGas(t *testing.T) {
+
+	t.Run("run a check on every tick", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		tx, err := getTxFromRaw(tx1SpeedUpRaw)
+		require.NoError(t, err)
+
+		ec := &EthClient{
+				storedTransactions: map[string]types.Transaction{
+					tx.Hash().String(): *tx,
+				},
+				transactionsMutex: &sync.RWMutex{},
+				gasMonitoringFrequence: time.Millisecond * 10,
+				Client: &MonitorGasMockDoer{},
+			}
 
+		ec.StartMonitor(ctx)
+		require.Eventually(t, func() bool {
+			return ec.LastGasPrice() == 1
+		}, time.Second, time.Millisecond*10)
+
+		// Wait for the loop to exit before reading the store.
+		cancel()
+		require.NoError(t, ec.Shutdown(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[tx.Hash().String()].Status)
+	})
 }
 
 
 
 // Test helpers.
+// signRawTx signs the transaction data with the key and returns its raw hex.
+func signRawTx(t *testing.T, key *ecdsa.PrivateKey, signer ethTypes.Signer, data ethTypes.TxData) string {
+	tx, err := ethTypes.SignNewTx(key, signer, data)
+	require.NoError(t, err)
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return hexutil.Encode(raw)
+}
+
+func snapshotHashes(transactions []types.Transaction) []string {
+	hashes := make([]string, 0, len(transactions))
+	for _, tx := range transactions {
+		hashes = append(hashes, tx.Hash().String())
+	}
+	return hashes
+}
+
 func getTxFromRaw(rawHex string) (*types.Transaction,error){
 	bytesTx, err := hex.DecodeString(rawHex[2:]) 
 	 if err != nil {
@@ -467,7 +3007,124 @@ func getTxFromRaw(rawHex string) (*types.Transaction,error){
 	 }
  
 	 tx.Status = types.STORED
-	 tx.RawHex = existingTransactionRaw
+	 tx.RawHex = rawHex
 
 	 return tx,nil
-}
\ No newline at end of file
+}
+// slowFlusher blocks until its context is done or the delay elapses.
+type slowFlusher struct {
+	delay   time.Duration
+	flushed []types.Transaction
+}
+
+func (f *slowFlusher) Flush(ctx context.Context, transactions []types.Transaction) error {
+	select {
+	case <-time.After(f.delay):
+		f.flushed = transactions
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// countingFlusher counts its flushes.
+type countingFlusher struct {
+	flushes atomic.Int32
+}
+
+func (f *countingFlusher) Flush(ctx context.Context, transactions []types.Transaction) error {
+	f.flushes.Add(1)
+	return nil
+}
+
+// tests StartSnapshots flushes at the configured interval, then Shutdown flushes once more.
+func TestStartSnapshots(t *testing.T) {
+	newClient := func(flusher Flusher, interval time.Duration) *EthClient {
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{},
+			transactionsMutex:  &sync.RWMutex{},
+			snapshotInterval:   interval,
+		}
+		ec.SetFlusher(flusher)
+		return ec
+	}
+
+	t.Run("flush every interval and on shutdown", func(t *testing.T) {
+		flusher := &countingFlusher{}
+		ec := newClient(flusher, 50*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ec.StartSnapshots(ctx)
+		time.Sleep(275 * time.Millisecond)
+		cancel()
+		require.NoError(t, ec.Shutdown(context.Background()))
+
+		// 5 ticks, give or take one for the scheduling, and the final flush.
+		require.InDelta(t, 6, flusher.flushes.Load(), 1)
+	})
+
+	t.Run("without an interval, only flush on shutdown", func(t *testing.T) {
+		flusher := &countingFlusher{}
+		ec := newClient(flusher, 0)
+
+		ec.StartSnapshots(context.Background())
+		require.NoError(t, ec.Shutdown(context.Background()))
+		require.Equal(t, int32(1), flusher.flushes.Load())
+	})
+}
+
+// tests the Shutdown function.
+func TestShutdown(t *testing.T) {
+	tx, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+
+	newClient := func(flusher Flusher) *EthClient {
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{
+				tx.Hash().String(): *tx,
+			},
+			transactionsMutex:      &sync.RWMutex{},
+			gasMonitoringFrequence: time.Millisecond * 50,
+			Client:                 &MonitorGasMockDoer{},
+		}
+		ec.SetFlusher(flusher)
+		return ec
+	}
+
+	t.Run("wait for the monitor to stop, then flush the stored transactions", func(t *testing.T) {
+		flusher := &slowFlusher{delay: time.Millisecond * 10}
+		ec := newClient(flusher)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		ec.StartMonitor(ctx)
+		time.Sleep(time.Millisecond * 10)
+		cancel()
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second)
+		defer cancelShutdown()
+
+		err := ec.Shutdown(shutdownCtx)
+		require.NoError(t, err)
+		require.Len(t, flusher.flushed, 1)
+	})
+
+	t.Run("cut off a slow flush at the timeout", func(t *testing.T) {
+		ec := newClient(&slowFlusher{delay: time.Minute})
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Millisecond*50)
+		defer cancelShutdown()
+
+		start := time.Now()
+		err := ec.Shutdown(shutdownCtx)
+		require.Error(t, err)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("without a flusher, return once the monitor has stopped", func(t *testing.T) {
+		ec := newClient(nil)
+
+		err := ec.Shutdown(context.Background())
+		require.NoError(t, err)
+	})
+}
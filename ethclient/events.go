@@ -0,0 +1,111 @@
+package ethclient
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// eventHub is the registry of the status change subscribers.
+// Publishing never blocks: a subscriber whose buffer is full either misses the event or is disconnected.
+type eventHub struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	bufferSize  int
+	// disconnectSlow closes the subscribers falling behind instead of dropping their events.
+	disconnectSlow bool
+}
+
+// subscriber is a single consumer of the status changes.
+type subscriber struct {
+	ch chan types.StatusChange
+	// hash restricts the subscriber to the changes of a single transaction, it receives every change when empty.
+	hash    string
+	dropped uint64
+}
+
+func newEventHub(bufferSize int, disconnectSlow bool) *eventHub {
+	return &eventHub{
+		subscribers:    make(map[*subscriber]struct{}),
+		bufferSize:     bufferSize,
+		disconnectSlow: disconnectSlow,
+	}
+}
+
+// subscribe registers a new subscriber to the changes of a transaction, or to every change when hash is empty.
+func (h *eventHub) subscribe(hash string) *subscriber {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &subscriber{ch: make(chan types.StatusChange, h.bufferSize), hash: hash}
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// unsubscribe removes a subscriber and closes its channel, it's a no-op if it was already disconnected.
+func (h *eventHub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; !ok {
+		return
+	}
+	delete(h.subscribers, sub)
+	close(sub.ch)
+}
+
+// publish sends an event to every subscriber, a nil hub discards it.
+// final marks the last change of a transaction, after which the subscribers to that transaction are closed.
+func (h *eventHub) publish(event types.StatusChange, final bool) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if sub.hash != "" && sub.hash != event.Hash {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			if h.disconnectSlow {
+				delete(h.subscribers, sub)
+				close(sub.ch)
+				log.Warn("Disconnected slow status change subscriber")
+				continue
+			}
+			sub.dropped++
+			log.WithField(txHashField, event.Hash).Debug("Dropped status change for slow subscriber")
+		}
+		if final && sub.hash != "" {
+			delete(h.subscribers, sub)
+			close(sub.ch)
+		}
+	}
+}
+
+// stats counts the subscribers and lists the transactions they watch, a nil hub has none.
+func (h *eventHub) stats() types.SubscriberStats {
+	stats := types.SubscriberStats{Hashes: []string{}}
+	if h == nil {
+		return stats
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	watched := make(map[string]bool)
+	for sub := range h.subscribers {
+		stats.Subscribers++
+		stats.Dropped += sub.dropped
+		if sub.hash != "" && !watched[sub.hash] {
+			watched[sub.hash] = true
+			stats.Hashes = append(stats.Hashes, sub.hash)
+		}
+	}
+	sort.Strings(stats.Hashes)
+	return stats
+}
@@ -0,0 +1,189 @@
+package ethclient
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the status change firehose.
+func TestSubscribeStatusChanges(t *testing.T) {
+	canceled, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	broadcasted, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+
+	newClient := func(bufferSize int, disconnectSlow bool) *EthClient {
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{
+				canceled.Hash().String():    *canceled,
+				broadcasted.Hash().String(): *broadcasted,
+			},
+			transactionsMutex: &sync.RWMutex{},
+			events:            newEventHub(bufferSize, disconnectSlow),
+		}
+	}
+
+	t.Run("a subscriber receives every status change", func(t *testing.T) {
+		client := newClient(8, false)
+		events, unsubscribe := client.SubscribeStatusChanges()
+		defer unsubscribe()
+
+		require.NoError(t, client.CancelTransaction(canceled.Hash().String()))
+		require.NoError(t, client.changeTransactionStatus(broadcasted.Hash().String(), types.BROADCASTED))
+
+		first := <-events
+		require.Equal(t, canceled.Hash().String(), first.Hash)
+		require.Equal(t, "STORED", first.From)
+		require.Equal(t, "CANCELED", first.To)
+		second := <-events
+		require.Equal(t, broadcasted.Hash().String(), second.Hash)
+		require.Equal(t, "BROADCASTED", second.To)
+	})
+
+	t.Run("rejected transitions aren't published", func(t *testing.T) {
+		client := newClient(8, false)
+		events, unsubscribe := client.SubscribeStatusChanges()
+		defer unsubscribe()
+
+		require.Error(t, client.changeTransactionStatus(canceled.Hash().String(), types.STORED))
+		require.Len(t, events, 0)
+	})
+
+	t.Run("with the drop policy, a full subscriber misses the new events but stays subscribed", func(t *testing.T) {
+		client := newClient(1, false)
+		events, unsubscribe := client.SubscribeStatusChanges()
+		defer unsubscribe()
+
+		require.NoError(t, client.CancelTransaction(canceled.Hash().String()))
+		require.NoError(t, client.changeTransactionStatus(broadcasted.Hash().String(), types.BROADCASTED))
+
+		event, ok := <-events
+		require.True(t, ok)
+		require.Equal(t, canceled.Hash().String(), event.Hash)
+		require.Len(t, events, 0)
+		require.Len(t, client.events.subscribers, 1)
+	})
+
+	t.Run("with the disconnect policy, a full subscriber is disconnected", func(t *testing.T) {
+		client := newClient(1, true)
+		events, unsubscribe := client.SubscribeStatusChanges()
+
+		require.NoError(t, client.CancelTransaction(canceled.Hash().String()))
+		require.NoError(t, client.changeTransactionStatus(broadcasted.Hash().String(), types.BROADCASTED))
+
+		<-events
+		_, ok := <-events
+		require.False(t, ok)
+		// Unsubscribing after the disconnection is safe.
+		unsubscribe()
+	})
+}
+
+// Test the subscriber statistics.
+func TestSubscriberStats(t *testing.T) {
+	t.Run("count the connected subscribers and the transactions they watch", func(t *testing.T) {
+		client := &EthClient{events: newEventHub(1, false)}
+		_, unsubscribeAll := client.SubscribeStatusChanges()
+		watcher := client.events.subscribe("0xb")
+		client.events.subscribe("0xa")
+		client.events.subscribe("0xb")
+
+		stats := client.SubscriberStats()
+		require.Equal(t, 4, stats.Subscribers)
+		require.Equal(t, []string{"0xa", "0xb"}, stats.Hashes)
+
+		unsubscribeAll()
+		client.events.unsubscribe(watcher)
+		stats = client.SubscriberStats()
+		require.Equal(t, 2, stats.Subscribers)
+		require.Equal(t, []string{"0xa", "0xb"}, stats.Hashes)
+	})
+
+	t.Run("a subscriber to a transaction only receives its changes", func(t *testing.T) {
+		client := &EthClient{events: newEventHub(1, false)}
+		watcher := client.events.subscribe("0xa")
+
+		client.events.publish(types.StatusChange{Hash: "0xb", From: "STORED", To: "BROADCASTED"}, true)
+		client.events.publish(types.StatusChange{Hash: "0xa", From: "STORED", To: "CANCELED"}, false)
+
+		event := <-watcher.ch
+		require.Equal(t, "0xa", event.Hash)
+		require.Equal(t, uint64(0), client.SubscriberStats().Dropped)
+	})
+
+	t.Run("a client without an event hub has no subscribers", func(t *testing.T) {
+		stats := (&EthClient{}).SubscriberStats()
+		require.Equal(t, 0, stats.Subscribers)
+		require.Empty(t, stats.Hashes)
+	})
+}
+
+// Test the subscriptions to a single transaction.
+func TestSubscribeTransaction(t *testing.T) {
+	stored, err := getTxFromRaw(existingTransactionRaw)
+	require.NoError(t, err)
+	other, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+
+	newClient := func(status types.TransactionStatus) *EthClient {
+		tx := *stored
+		tx.Status = status
+		return &EthClient{
+			storedTransactions: map[string]types.Transaction{
+				stored.Hash().String(): tx,
+				other.Hash().String():  *other,
+			},
+			transactionsMutex: &sync.RWMutex{},
+			events:            newEventHub(8, false),
+		}
+	}
+
+	t.Run("the subscriber receives the changes of the transaction until its final status", func(t *testing.T) {
+		client := newClient(types.STORED)
+		events, unsubscribe, err := client.SubscribeTransaction(stored.Hash().String())
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		require.NoError(t, client.changeTransactionStatus(other.Hash().String(), types.BROADCASTED))
+		require.NoError(t, client.changeTransactionStatus(stored.Hash().String(), types.BROADCASTED))
+
+		event, ok := <-events
+		require.True(t, ok)
+		require.Equal(t, stored.Hash().String(), event.Hash)
+		require.Equal(t, "STORED", event.From)
+		require.Equal(t, "BROADCASTED", event.To)
+		_, ok = <-events
+		require.False(t, ok)
+		require.Equal(t, 0, client.SubscriberStats().Subscribers)
+	})
+
+	t.Run("a canceled transaction can still be sped up, so its stream stays open", func(t *testing.T) {
+		client := newClient(types.STORED)
+		events, unsubscribe, err := client.SubscribeTransaction(stored.Hash().String())
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		require.NoError(t, client.CancelTransaction(stored.Hash().String()))
+		require.Equal(t, "CANCELED", (<-events).To)
+		require.Equal(t, 1, client.SubscriberStats().Subscribers)
+	})
+
+	t.Run("a transaction already in a final status gets a closed channel", func(t *testing.T) {
+		client := newClient(types.BROADCASTED)
+		events, unsubscribe, err := client.SubscribeTransaction(stored.Hash().String())
+		require.NoError(t, err)
+		defer unsubscribe()
+
+		_, ok := <-events
+		require.False(t, ok)
+	})
+
+	t.Run("an unknown transaction can't be subscribed to", func(t *testing.T) {
+		client := newClient(types.STORED)
+		_, _, err := client.SubscribeTransaction("0x1")
+		require.EqualError(t, err, "transaction not found")
+	})
+}
@@ -0,0 +1,62 @@
+package ethclient
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+)
+
+// gasHistory is a bounded ring buffer of the gas prices observed by the monitor, the oldest observation is overwritten once it's full.
+type gasHistory struct {
+	mu           sync.Mutex
+	observations []types.GasObservation
+	// next is the index the next observation is written at, size the number of observations kept.
+	next int
+	size int
+}
+
+// newGasHistory returns a history keeping the last capacity observations, nil when capacity is 0.
+func newGasHistory(capacity int) *gasHistory {
+	if capacity <= 0 {
+		return nil
+	}
+	return &gasHistory{observations: make([]types.GasObservation, capacity)}
+}
+
+// add records a gas price observed at the given time, a nil history discards it.
+func (h *gasHistory) add(gasPrice float64, at time.Time) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	gasPriceInt, _ := big.NewFloat(gasPrice).Int(nil)
+	h.observations[h.next] = types.GasObservation{GasPrice: (*hexutil.Big)(gasPriceInt), Timestamp: at.UTC()}
+	h.next = (h.next + 1) % len(h.observations)
+	if h.size < len(h.observations) {
+		h.size++
+	}
+}
+
+// snapshot returns a copy of the observations, oldest first.
+func (h *gasHistory) snapshot() []types.GasObservation {
+	if h == nil {
+		return []types.GasObservation{}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	observations := make([]types.GasObservation, 0, h.size)
+	if h.size == 0 {
+		return observations
+	}
+	start := (h.next - h.size + len(h.observations)) % len(h.observations)
+	for i := 0; i < h.size; i++ {
+		observations = append(observations, h.observations[(start+i)%len(h.observations)])
+	}
+	return observations
+}
@@ -0,0 +1,47 @@
+package ethclient
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test the gas price observations are accumulated in a bounded history.
+func TestGasHistory(t *testing.T) {
+	t.Run("the observations accumulate oldest first and the oldest ones are overwritten", func(t *testing.T) {
+		doer := &SequenceDoer{}
+		for i := 1; i <= 5; i++ {
+			doer.Bodies = append(doer.Bodies, fmt.Sprintf(`{"jsonrpc": "2.0", "result": "0x%x", "id":1}`, i))
+		}
+		client := &EthClient{Client: doer, gasHistory: newGasHistory(3)}
+
+		_, err := client.RefreshGasPrice(context.Background())
+		require.NoError(t, err)
+		history := client.GasHistory()
+		require.Len(t, history, 1)
+		require.Equal(t, big.NewInt(1), history[0].GasPrice.ToInt())
+		require.False(t, history[0].Timestamp.IsZero())
+
+		for i := 0; i < 4; i++ {
+			_, err := client.RefreshGasPrice(context.Background())
+			require.NoError(t, err)
+		}
+		history = client.GasHistory()
+		require.Len(t, history, 3)
+		for i, observation := range history {
+			require.Equal(t, big.NewInt(int64(i+3)), observation.GasPrice.ToInt())
+		}
+		require.False(t, history[2].Timestamp.Before(history[0].Timestamp))
+	})
+
+	t.Run("no history is kept when its size is 0", func(t *testing.T) {
+		client := &EthClient{Client: &MonitorGasMockDoer{}, gasHistory: newGasHistory(0)}
+
+		_, err := client.RefreshGasPrice(context.Background())
+		require.NoError(t, err)
+		require.Empty(t, client.GasHistory())
+	})
+}
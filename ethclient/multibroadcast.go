@@ -0,0 +1,133 @@
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// multiBroadcaster sends each transaction to several nodes at once, it's broadcast as soon as one of them accepts it.
+type multiBroadcaster struct {
+	nodes []broadcastNode
+}
+
+// broadcastNode is one of the destinations of a multiBroadcaster, name identifies it in the logs without leaking the API key of its URL.
+type broadcastNode struct {
+	name        string
+	broadcaster Broadcaster
+}
+
+// broadcastOutcome is the result of sending a transaction to a single node.
+type broadcastOutcome struct {
+	txHash   string
+	isRPCErr bool
+	err      error
+}
+
+// newMultiBroadcaster returns a broadcaster sending to the node of ec and to the nodes at urls, sharing the HTTP client, the retries
+// and the upstream error count of ec.
+func newMultiBroadcaster(ec *EthClient, urls []string) *multiBroadcaster {
+	b := &multiBroadcaster{nodes: []broadcastNode{{name: nodeName(ec.URL), broadcaster: ec}}}
+	for _, nodeURL := range urls {
+		b.nodes = append(b.nodes, broadcastNode{
+			name: nodeName(nodeURL),
+			broadcaster: &EthClient{
+				URL:               nodeURL,
+				Client:            ec.Client,
+				retries:           ec.retries,
+				retryBackoff:      ec.retryBackoff,
+				userAgent:         ec.userAgent,
+				rateLimitRetries:  ec.rateLimitRetries,
+				rateLimitMaxDelay: ec.rateLimitMaxDelay,
+				primary:           ec,
+			},
+		})
+	}
+	return b
+}
+
+// broadcastErrors is the failure of a broadcast to several nodes. Its message joins theirs, errors.Is and errors.As
+// match any of them, e.g. the *types.JSONRPCError of a rejecting node.
+type broadcastErrors []error
+
+func (e broadcastErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e broadcastErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e broadcastErrors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeName returns the host of a node URL.
+func nodeName(nodeURL string) string {
+	u, err := url.Parse(nodeURL)
+	if err != nil {
+		return "invalid URL"
+	}
+	return u.Host
+}
+
+// Broadcast sends the transaction to every node concurrently and succeeds if at least one accepts it.
+// When none does, the failure is only reported as a rejection if every node rejected the transaction, so that an unreachable node gets another chance.
+func (b *multiBroadcaster) Broadcast(ctx context.Context, rawHex string) (string, bool, error) {
+	outcomes := make([]broadcastOutcome, len(b.nodes))
+	var wg sync.WaitGroup
+	for i, node := range b.nodes {
+		wg.Add(1)
+		go func(i int, node broadcastNode) {
+			defer wg.Done()
+			txHash, isRPCErr, err := node.broadcaster.Broadcast(ctx, rawHex)
+			outcomes[i] = broadcastOutcome{txHash: txHash, isRPCErr: isRPCErr, err: err}
+		}(i, node)
+	}
+	wg.Wait()
+
+	txHash := ""
+	accepted := 0
+	rejected := 0
+	failures := broadcastErrors{}
+	for i, outcome := range outcomes {
+		logger := log.WithField("node", b.nodes[i].name)
+		switch {
+		case outcome.err == nil:
+			logger.Info("Node accepted the transaction")
+			accepted++
+			if txHash == "" {
+				txHash = outcome.txHash
+			}
+		case outcome.isRPCErr:
+			logger.Warn("Node rejected the transaction: ", outcome.err)
+			rejected++
+			failures = append(failures, outcome.err)
+		default:
+			logger.Warn("Failed to send the transaction to the node: ", outcome.err)
+			failures = append(failures, outcome.err)
+		}
+	}
+	if accepted > 0 {
+		return txHash, false, nil
+	}
+	return "", rejected == len(outcomes), failures
+}
@@ -0,0 +1,95 @@
+package ethclient
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// tests broadcasting to several nodes.
+func TestMultiBroadcaster(t *testing.T) {
+	tx, err := getTxFromRaw(tx1SpeedUpRaw)
+	require.NoError(t, err)
+	hash := tx.Hash().String()
+	newClient := func(broadcasters ...Broadcaster) *EthClient {
+		b := &multiBroadcaster{}
+		for _, broadcaster := range broadcasters {
+			b.nodes = append(b.nodes, broadcastNode{name: "node", broadcaster: broadcaster})
+		}
+		ec := &EthClient{
+			storedTransactions: map[string]types.Transaction{hash: *tx},
+			transactionsMutex:  &sync.RWMutex{},
+			Client:             &MonitorGasMockDoer{},
+		}
+		ec.SetBroadcaster(b)
+		return ec
+	}
+	unreachable := func() Broadcaster { return &EthClient{URL: "http://unreachable", Client: &CountingDoer{}} }
+	rejecting := func() Broadcaster { return &stubBroadcaster{err: errors.New("nonce too low")} }
+
+	t.Run("the transaction is broadcast when one node accepts it", func(t *testing.T) {
+		accepting := &stubBroadcaster{}
+		ec := newClient(unreachable(), accepting)
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.BROADCASTED, ec.storedTransactions[hash].Status)
+		require.Equal(t, []string{tx1SpeedUpRaw}, accepting.sent)
+	})
+
+	t.Run("the transaction is failed when every node rejects it", func(t *testing.T) {
+		ec := newClient(rejecting(), rejecting())
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.FAILED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("the transaction is kept when a node couldn't be reached", func(t *testing.T) {
+		ec := newClient(unreachable(), rejecting())
+
+		require.NoError(t, ec.CheckOnce(context.Background()))
+		require.Equal(t, types.STORED, ec.storedTransactions[hash].Status)
+	})
+
+	t.Run("the error of a rejecting node is kept when every node fails", func(t *testing.T) {
+		nodeErr := &types.JSONRPCError{Code: -32003, Message: "nonce too low"}
+		b := &multiBroadcaster{nodes: []broadcastNode{
+			{name: "node", broadcaster: unreachable()},
+			{name: "node", broadcaster: &stubBroadcaster{err: nodeErr}},
+		}}
+
+		_, isRPCErr, err := b.Broadcast(context.Background(), tx1SpeedUpRaw)
+		require.False(t, isRPCErr)
+		require.Contains(t, err.Error(), "; nonce too low")
+		var rpcErr *types.JSONRPCError
+		require.ErrorAs(t, err, &rpcErr)
+		require.Equal(t, -32003, rpcErr.Code)
+	})
+}
+
+// tests the nodes are named after their host, leaving out the API key in the path.
+func TestNewMultiBroadcaster(t *testing.T) {
+	ec := &EthClient{URL: "https://goerli.infura.io/v3/secret", Client: &MonitorGasMockDoer{}, retries: 2}
+
+	b := newMultiBroadcaster(ec, []string{"http://localhost:8545"})
+	require.Len(t, b.nodes, 2)
+	require.Equal(t, "goerli.infura.io", b.nodes[0].name)
+	require.Same(t, ec, b.nodes[0].broadcaster)
+	require.Equal(t, "localhost:8545", b.nodes[1].name)
+	node := b.nodes[1].broadcaster.(*EthClient)
+	require.Equal(t, "http://localhost:8545", node.URL)
+	require.Equal(t, 2, node.retries)
+}
+
+// tests the failed requests to the extra broadcast nodes are counted with the ones to the node of the client.
+func TestMultiBroadcasterUpstreamErrors(t *testing.T) {
+	ec := &EthClient{URL: "http://primary", Client: &CountingDoer{}}
+	b := newMultiBroadcaster(ec, []string{"http://secondary"})
+
+	_, _, err := b.Broadcast(context.Background(), tx1SpeedUpRaw)
+	require.Error(t, err)
+	require.Equal(t, uint64(2), ec.upstreamErrors.Load())
+}
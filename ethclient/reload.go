@@ -0,0 +1,46 @@
+package ethclient
+
+import (
+	"time"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reload reads the configuration again and applies the settings that can change at runtime to the logger, the global client
+// and the loaded config: the log level and the gas monitoring interval. Other changes only take effect after a restart, see
+// config.Config.RestartRequired for why.
+// The running configuration is kept when the new one is invalid.
+func Reload() error {
+	previous := config.GetConfig()
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	levelName := cfg.LogLevel()
+	logLevel, err := log.ParseLevel(levelName)
+	if err != nil {
+		log.Error("Invalid log level in the reloaded config, keeping the current one: ", err)
+		levelName = previous.LogLevel()
+	} else {
+		log.SetLevel(logLevel)
+	}
+
+	if Client != nil {
+		Client.setMonitoringInterval(cfg.GasMonitoringInterval())
+	}
+	config.SetReloadable(levelName, cfg.GasMonitoringInterval())
+
+	if previous.RestartRequired(cfg) {
+		log.Warn("Config reloaded, changes other than LOG_LEVEL and GAS_MONITORING_INTERVAL require a restart")
+	} else {
+		log.Info("Config reloaded")
+	}
+	return nil
+}
+
+// setMonitoringInterval changes the time between two gas price checks, the running monitor applies it after its next check.
+func (ec *EthClient) setMonitoringInterval(interval time.Duration) {
+	ec.reloadedFrequence.Store(int64(interval))
+}
@@ -0,0 +1,73 @@
+package ethclient
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// tests the Reload function.
+func TestReload(t *testing.T) {
+	level := log.GetLevel()
+	defer log.SetLevel(level)
+	os.Setenv("NETWORK", "test_network")
+	os.Setenv("INFURA_PROJECT_ID", "test_project_id")
+	defer os.Unsetenv("NETWORK")
+	defer os.Unsetenv("INFURA_PROJECT_ID")
+	require.NoError(t, config.LoadConfig())
+
+	client := Client
+	defer func() { Client = client }()
+	Client = &EthClient{gasMonitoringFrequence: 5 * time.Second}
+
+	t.Run("apply the reloaded log level and gas monitoring interval", func(t *testing.T) {
+		os.Setenv("LOG_LEVEL", "DEBUG")
+		os.Setenv("GAS_MONITORING_INTERVAL", "1s")
+		defer os.Unsetenv("LOG_LEVEL")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL")
+
+		err := Reload()
+		require.NoError(t, err)
+		require.Equal(t, log.DebugLevel, log.GetLevel())
+		require.Equal(t, time.Second, Client.monitoringInterval())
+	})
+
+	t.Run("when the reloaded config is invalid, keep the running one", func(t *testing.T) {
+		os.Setenv("GAS_MONITORING_INTERVAL", "-1s")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL")
+
+		err := Reload()
+		require.Error(t, err)
+		require.Equal(t, time.Second, Client.monitoringInterval())
+		require.Equal(t, time.Second, config.GetConfig().GasMonitoringInterval())
+	})
+
+	t.Run("when the reloaded log level is invalid, keep the current one", func(t *testing.T) {
+		os.Setenv("LOG_LEVEL", "DEBUG")
+		defer os.Unsetenv("LOG_LEVEL")
+		require.NoError(t, Reload())
+
+		os.Setenv("LOG_LEVEL", "LOUD")
+		err := Reload()
+		require.NoError(t, err)
+		require.Equal(t, log.DebugLevel, log.GetLevel())
+		require.Equal(t, "DEBUG", config.GetConfig().LogLevel())
+	})
+
+	t.Run("only the reloadable settings are applied to the loaded config", func(t *testing.T) {
+		addr := config.GetConfig().Addr()
+		os.Setenv("PORT", "9191")
+		os.Setenv("GAS_MONITORING_INTERVAL", "2s")
+		defer os.Unsetenv("PORT")
+		defer os.Unsetenv("GAS_MONITORING_INTERVAL")
+
+		err := Reload()
+		require.NoError(t, err)
+		require.Equal(t, addr, config.GetConfig().Addr())
+		require.Equal(t, 2*time.Second, config.GetConfig().GasMonitoringInterval())
+	})
+}
@@ -36,28 +36,58 @@ func main() {
 	}
 	log.SetLevel(logLevel)
 
-	ethclient.Init()
+	err = ethclient.Init()
+	if err != nil {
+		log.Fatal("Failed to initialize the Ethereum client: ",err)
+	}
 
 	// Create cancellable context
 	ctx, cancel := context.WithCancel(context.Background())
 
-	go ethclient.Client.MonitorGas(ctx)
+	ethclient.Client.StartMonitor(ctx)
+	ethclient.Client.StartSnapshots(ctx)
+
+	// Reload the config on SIGHUP.
+	go func() {
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+		for range sighup {
+			err := godotenv.Overload()
+			if err != nil {
+				log.Error("Error loading .env file: ", err)
+			}
+			err = ethclient.Reload()
+			if err != nil {
+				log.Error("Failed to reload the config: ", err)
+			}
+		}
+	}()
 
 	go func() {
 		sigint := make(chan os.Signal, 1)
 		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
 		<-sigint
 
-		// Cleanup and exit
+		// Stops the server, then the monitor.
 		cancel()
-		os.Exit(0)
 	}()
 
-	// Start server
-	err = rpc.StartServer(ethclient.Client)
-	if err != nil {
+	// Start server, it returns once the in-flight requests are done.
+	err = rpc.StartServer(ctx, ethclient.Client)
+	if err != nil && ctx.Err() == nil {
 		log.Fatal("Failed to start the JSON RPC server: ",err)
 	}
+	if err != nil {
+		log.Warn("Forcing the server shutdown: ", err)
+	}
+
+	// Cleanup and exit
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout())
+	err = ethclient.Client.Shutdown(shutdownCtx)
+	cancelShutdown()
+	if err != nil {
+		log.Warn("Forcing exit: ", err)
+	}
 }
 
 
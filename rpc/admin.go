@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// adminAPIKeyHeader carries the key required by the admin methods.
+const adminAPIKeyHeader = "X-API-Key"
+
+// authorizeAdmin checks the admin API key of a request, writing the error response when it's missing or wrong.
+// Admin methods are disabled when no key is configured.
+func (s *EthService) authorizeAdmin(w http.ResponseWriter, r *http.Request, req types.JSONRPCRequest) bool {
+	err := s.checkAdminKey(r)
+	if err != nil {
+		log.Error("Admin method rejected: ", req.Method, ": ", err)
+		writeJSONRPCError(w, req.ID, codeUnauthorized, err.Error())
+		return false
+	}
+	return true
+}
+
+// checkAdminKey returns an error when the request doesn't carry the admin API key, or when no key is configured.
+func (s *EthService) checkAdminKey(r *http.Request) error {
+	if s.adminAPIKey == "" {
+		return errors.New("admin methods are disabled")
+	}
+	key := r.Header.Get(adminAPIKeyHeader)
+	if subtle.ConstantTimeCompare([]byte(key), []byte(s.adminAPIKey)) != 1 {
+		return errors.New("unauthorized")
+	}
+	return nil
+}
+
+// handleRefreshGasPrice fetches the gas price right away, updating the cached one, and returns it as a hex quantity.
+func (s *EthService) handleRefreshGasPrice(w http.ResponseWriter, r *http.Request, req types.JSONRPCRequest) {
+	gasPrice, err := s.EthClient.RefreshGasPrice(r.Context())
+	if err != nil {
+		log.Error("Failed to refresh gas price: ", err)
+		writeJSONRPCError(w, req.ID, nodeErrorCode(err, codeServerError), err.Error())
+		return
+	}
+	gasPriceInt, _ := big.NewFloat(gasPrice).Int(nil)
+	writeJSONRPCResult(w, req.ID, (*hexutil.Big)(gasPriceInt))
+}
+
+// handleSetLogLevel applies the log level named by the first param right away, until the next restart or reload.
+func (s *EthService) handleSetLogLevel(w http.ResponseWriter, req types.JSONRPCRequest) {
+	if len(req.Params) == 0 {
+		log.Error("Failed to retrieve log level")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	name, ok := req.Params[0].(string)
+	if !ok {
+		log.Error("Log level param is not a string")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+	level, err := log.ParseLevel(name)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
+		return
+	}
+	log.SetLevel(level)
+	log.Info("Log level set to ", level.String())
+	writeJSONRPCResult(w, req.ID, level.String())
+}
+
+// handleSetStatus moves the transaction passed as first param to the status named by the second one, and returns the status.
+// Only the allowed transitions are applied, e.g. STORED to FAILED to unblock a stuck transaction.
+func (s *EthService) handleSetStatus(w http.ResponseWriter, req types.JSONRPCRequest) {
+	hash, ok := txHashParam(w, req)
+	if !ok {
+		return
+	}
+	if len(req.Params) < 2 {
+		log.Error("Failed to retrieve status")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	name, ok := req.Params[1].(string)
+	if !ok {
+		log.Error("Status param is not a string")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+	status, err := types.ParseTransactionStatus(name)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
+		return
+	}
+
+	err = s.EthClient.SetTransactionStatus(hash, status)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeServerError, err.Error())
+		return
+	}
+	writeJSONRPCResult(w, req.ID, status.String())
+}
+
+// handleTestBroadcast sends the transaction passed as first param to the node, like the monitor would, and returns the outcome.
+func (s *EthService) handleTestBroadcast(w http.ResponseWriter, r *http.Request, req types.JSONRPCRequest) {
+	hash, ok := txHashParam(w, req)
+	if !ok {
+		return
+	}
+
+	result, err := s.EthClient.TestBroadcast(r.Context(), hash)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeServerError, err.Error())
+		return
+	}
+	writeJSONRPCResult(w, req.ID, result)
+}
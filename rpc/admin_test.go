@@ -0,0 +1,162 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the admin methods are gated by the admin API key.
+func TestAdminMethods(t *testing.T) {
+	refreshGasPrice := func(service *EthService, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"refresh_gas_price","params":[]}`))
+		if key != "" {
+			req.Header.Set(adminAPIKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		service.handleRequest(rr, req)
+		return rr
+	}
+
+	t.Run("with the admin API key, refresh the gas price and return it", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}, adminAPIKey: "secret"}
+
+		rr := refreshGasPrice(service, "secret")
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, "0x77359400", resp.Result)
+	})
+
+	t.Run("without the admin API key, reject the call", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}, adminAPIKey: "secret"}
+
+		for _, key := range []string{"", "wrong"} {
+			rr := refreshGasPrice(service, key)
+
+			resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+			require.Equal(t, -32001, resp.Error.Code)
+			require.Equal(t, "unauthorized", resp.Error.Message)
+		}
+	})
+
+	t.Run("when no admin API key is configured, the admin methods are disabled", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+
+		rr := refreshGasPrice(service, "secret")
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32001, resp.Error.Code)
+		require.Equal(t, "admin methods are disabled", resp.Error.Message)
+	})
+}
+
+// Test the set_log_level admin method.
+func TestSetLogLevel(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.InfoLevel)
+	service := &EthService{EthClient: &mockEthService{}, adminAPIKey: "secret"}
+	setLogLevel := func(key, level string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"set_log_level","params":["%s"]}`, level)
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set(adminAPIKeyHeader, key)
+		rr := httptest.NewRecorder()
+		service.handleRequest(rr, req)
+		return rr
+	}
+
+	t.Run("a valid level is applied right away", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, setLogLevel("secret", "debug"), http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, "debug", resp.Result)
+		require.Equal(t, log.DebugLevel, log.GetLevel())
+	})
+
+	t.Run("an invalid level is rejected and the current one kept", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, setLogLevel("secret", "verbose"), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+		require.Contains(t, resp.Error.Data, "not a valid logrus Level")
+		require.Equal(t, log.DebugLevel, log.GetLevel())
+	})
+
+	t.Run("without the admin API key, the level isn't changed", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, setLogLevel("wrong", "error"), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32001, resp.Error.Code)
+		require.Equal(t, log.DebugLevel, log.GetLevel())
+	})
+}
+
+// Test the set_status admin method.
+func TestSetStatus(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}, adminAPIKey: "secret"}
+	setStatus := func(key, status string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"set_status","params":["%s","%s"]}`, validTransactionHash, status)
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set(adminAPIKeyHeader, key)
+		rr := httptest.NewRecorder()
+		service.handleRequest(rr, req)
+		return rr
+	}
+
+	t.Run("an allowed transition is applied", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, setStatus("secret", "failed"), http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, "FAILED", resp.Result)
+	})
+
+	t.Run("a disallowed transition is rejected", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, setStatus("secret", "SPEDUP"), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32000, resp.Error.Code)
+		require.Equal(t, "invalid status transition from STORED to SPEDUP for transaction: "+validTransactionHash, resp.Error.Message)
+	})
+
+	t.Run("an unknown status is an invalid param", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, setStatus("secret", "PENDING"), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+
+	t.Run("without the admin API key, reject the call", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, setStatus("wrong", "FAILED"), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32001, resp.Error.Code)
+	})
+}
+
+// Test the test_broadcast admin method.
+func TestTestBroadcast(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}, adminAPIKey: "secret"}
+	testBroadcast := func(key, hash string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"test_broadcast","params":["%s"]}`, hash)
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set(adminAPIKeyHeader, key)
+		rr := httptest.NewRecorder()
+		service.handleRequest(rr, req)
+		return rr
+	}
+
+	t.Run("return the outcome of the broadcast", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, testBroadcast("secret", validTransactionHash), http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, map[string]interface{}{
+			"sent":      false,
+			"error":     "nonce too low",
+			"nodeError": true,
+			"permanent": true,
+		}, resp.Result)
+	})
+
+	t.Run("an unknown transaction", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, testBroadcast("secret", notFoundTransactionHash), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32000, resp.Error.Code)
+		require.Equal(t, "transaction not found", resp.Error.Message)
+	})
+
+	t.Run("without the admin API key, reject the call", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, testBroadcast("", validTransactionHash), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32001, resp.Error.Code)
+	})
+}
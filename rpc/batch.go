@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// duplicateIDWarning is added to the responses of a batch sharing their id with another request, when duplicates are annotated.
+const duplicateIDWarning = "duplicate id in batch"
+
+// bufferedResponseWriter collects the response of a single batch element in memory.
+type bufferedResponseWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: http.Header{}, status: http.StatusOK}
+}
+
+// Header returns the headers of the buffered response, they are discarded when assembling the batch response.
+func (bw *bufferedResponseWriter) Header() http.Header {
+	return bw.header
+}
+
+// Write appends to the buffered response body.
+func (bw *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return bw.body.Write(b)
+}
+
+// WriteHeader records the status code of the buffered response.
+func (bw *bufferedResponseWriter) WriteHeader(code int) {
+	bw.status = code
+}
+
+// isBatchRequest reports whether the body is a JSON array, i.e. a JSON-RPC batch.
+func isBatchRequest(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// handleBatch handles a JSON-RPC batch by dispatching each request through handleSingleRequest and returning the responses in the same order.
+// Up to batchConcurrency requests are processed in parallel.
+// Unhandled methods are proxied to the node one by one.
+func (s *EthService) handleBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	var batch []json.RawMessage
+	err := json.Unmarshal(body, &batch)
+	if err != nil {
+		log.Error("Failed to decode batch request body: ", err)
+		writeJSONRPCError(w, nil, codeInvalidRequest, "invalid json request")
+		return
+	}
+	setAccessLogInfo(r.Context(), types.JSONRPCRequest{Method: "batch"})
+	// An empty batch is answered with a single error, not an empty array.
+	if len(batch) == 0 {
+		log.Error("Rejected empty batch")
+		writeJSONRPCError(w, nil, codeInvalidRequest, "empty batch")
+		return
+	}
+
+	ids := make([]json.RawMessage, len(batch))
+	for i, raw := range batch {
+		ids[i] = batchElementID(raw)
+	}
+	duplicates := duplicateIDs(ids)
+	if len(duplicates) > 0 && !s.annotateDuplicateIDs {
+		log.Error("Rejected batch with duplicate ids")
+		writeJSONRPCError(w, nil, codeInvalidRequest, duplicateIDWarning)
+		return
+	}
+
+	concurrency := s.batchConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	// The elements beyond the concurrency limit wait for a slot.
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	responses := make([]json.RawMessage, len(batch))
+	for i, raw := range batch {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(i int, raw json.RawMessage) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			responses[i] = s.dispatchBatchElement(r, raw, ids[i])
+			if duplicates[string(ids[i])] {
+				responses[i] = annotateResponse(responses[i], duplicateIDWarning)
+			}
+		}(i, raw)
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(responses)
+}
+
+// dispatchBatchElement processes one element of a batch and returns its JSON response.
+func (s *EthService) dispatchBatchElement(r *http.Request, raw json.RawMessage, id json.RawMessage) json.RawMessage {
+	// The batch is logged as a whole, the elements record their details in a throwaway access log info.
+	sub := r.Clone(context.WithValue(r.Context(), accessLogKey{}, &accessLogInfo{}))
+	// An idempotency key identifies a single submission, not each element of a batch.
+	sub.Header.Del(idempotencyKeyHeader)
+	sub.Body = io.NopCloser(bytes.NewReader(raw))
+	sub.ContentLength = int64(len(raw))
+
+	bw := newBufferedResponseWriter()
+	s.handleSingleRequest(bw, sub, raw)
+
+	response := bytes.TrimSpace(bw.body.Bytes())
+	if !json.Valid(response) {
+		// A failed proxied call answers with a plain text http error, which can't be embedded in the batch.
+		log.Error("Invalid response for batch element: ", string(response))
+		decodedID, _ := types.DecodeID(id)
+		rpcErr := &types.JSONRPCError{Code: int(codeInternalError), Message: "internal error"}
+		if s.devMode {
+			rpcErr.Data = sanitizeMessage(string(response))
+		}
+		response, _ = json.Marshal(types.JSONRPCResponse{
+			Jsonrpc: "2.0",
+			ID:      decodedID,
+			Error:   rpcErr,
+		})
+	}
+	return response
+}
+
+// batchElementID returns the raw id of a batch element, or nil when it has none or can't be decoded.
+func batchElementID(raw json.RawMessage) json.RawMessage {
+	var element struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(raw, &element) != nil {
+		return nil
+	}
+	return element.ID
+}
+
+// duplicateIDs returns the non-null ids used by more than one request of a batch.
+func duplicateIDs(ids []json.RawMessage) map[string]bool {
+	seen := make(map[string]bool, len(ids))
+	duplicates := make(map[string]bool)
+	for _, id := range ids {
+		if len(id) == 0 || string(id) == "null" {
+			continue
+		}
+		if seen[string(id)] {
+			duplicates[string(id)] = true
+		}
+		seen[string(id)] = true
+	}
+	return duplicates
+}
+
+// annotateResponse adds a warning member to a JSON-RPC response object.
+func annotateResponse(response json.RawMessage, warning string) json.RawMessage {
+	var members map[string]json.RawMessage
+	if json.Unmarshal(response, &members) != nil {
+		return response
+	}
+	members["warning"], _ = json.Marshal(warning)
+	annotated, err := json.Marshal(members)
+	if err != nil {
+		return response
+	}
+	return annotated
+}
@@ -0,0 +1,250 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/safwentrabelsi/tx-json-rpc-server/ethclient"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// Test JSON-RPC batch requests.
+func TestHandleBatch(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	t.Run("when receiving a batch, return the responses in the same order", func(t *testing.T) {
+		batch := fmt.Sprintf(`[
+			{"jsonrpc":"2.0","id":1,"method":"cancel_transaction","params":["%s"]},
+			{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]},
+			{"jsonrpc":"2.0","id":3,"method":"cancel_transaction","params":["%s"]}
+		]`, validTransactionHash, notFoundTransactionHash)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(batch))
+
+		responses := parseBatchResponse(t, rr.Body.Bytes())
+		require.Len(t, responses, 3)
+		require.Equal(t, float64(1), responses[0].ID)
+		require.Equal(t, "Transaction canceled", responses[0].Result)
+		require.Equal(t, float64(2), responses[1].ID)
+		require.Equal(t, "0x1", responses[1].Result)
+		require.Equal(t, float64(3), responses[2].ID)
+		require.Contains(t, responses[2].Error.Message, "transaction not found")
+	})
+
+	t.Run("when an element isn't a request object, answer it with an invalid request error and process the others", func(t *testing.T) {
+		batch := `  [1, {"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]}, "eth_chainId"]`
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(batch))
+
+		responses := parseBatchResponse(t, rr.Body.Bytes())
+		require.Len(t, responses, 3)
+		require.Equal(t, -32600, responses[0].Error.Code)
+		require.Nil(t, responses[0].ID)
+		require.Equal(t, float64(2), responses[1].ID)
+		require.Equal(t, "0x1", responses[1].Result)
+		require.Equal(t, -32600, responses[2].Error.Code)
+	})
+
+	t.Run("when receiving a batch with duplicate ids, reject it", func(t *testing.T) {
+		batch := `[
+			{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]},
+			{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}
+		]`
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(batch))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, nil, "2.0")
+		require.Equal(t, -32600, resp.Error.Code)
+		require.Contains(t, resp.Error.Message, "duplicate id")
+	})
+
+	t.Run("when receiving an empty batch, return a single invalid request error", func(t *testing.T) {
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(` [ ] `))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, nil, "2.0")
+		require.NotNil(t, resp.Error)
+		require.Equal(t, -32600, resp.Error.Code)
+		require.Contains(t, resp.Error.Message, "empty batch")
+	})
+
+	t.Run("when an element fails outside JSON-RPC, return an internal error with the details in dev mode only", func(t *testing.T) {
+		batch := `[{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}]`
+
+		for _, devMode := range []bool{false, true} {
+			service := &EthService{EthClient: &flakyEthService{failures: 1}, devMode: devMode}
+			rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(batch))
+
+			responses := parseBatchResponse(t, rr.Body.Bytes())
+			require.Len(t, responses, 1)
+			require.Equal(t, -32603, responses[0].Error.Code)
+			if devMode {
+				require.Contains(t, responses[0].Error.Data, "connection reset by peer")
+			} else {
+				require.Nil(t, responses[0].Error.Data)
+			}
+		}
+	})
+
+	t.Run("when receiving a batch with several null ids, don't treat them as duplicates", func(t *testing.T) {
+		batch := `[
+			{"jsonrpc":"2.0","id":null,"method":"eth_chainId","params":[]},
+			{"jsonrpc":"2.0","id":null,"method":"eth_chainId","params":[]}
+		]`
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(batch))
+
+		require.Len(t, parseBatchResponse(t, rr.Body.Bytes()), 2)
+	})
+
+	t.Run("when duplicate ids are annotated, process the batch and flag the responses", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}, annotateDuplicateIDs: true}
+		batch := `[
+			{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]},
+			{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]},
+			{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}
+		]`
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(batch))
+
+		var responses []map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &responses))
+		require.Len(t, responses, 3)
+		require.Equal(t, duplicateIDWarning, responses[0]["warning"])
+		require.NotContains(t, responses[1], "warning")
+		require.Equal(t, duplicateIDWarning, responses[2]["warning"])
+	})
+}
+
+// inFlightEthService records the maximum number of concurrent proxied calls.
+type inFlightEthService struct {
+	mockEthService
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (m *inFlightEthService) SendRequest(ctx context.Context, body io.Reader, headers http.Header) (*http.Response, error) {
+	m.mu.Lock()
+	m.inFlight++
+	if m.inFlight > m.maxInFlight {
+		m.maxInFlight = m.inFlight
+	}
+	m.mu.Unlock()
+
+	time.Sleep(time.Millisecond * 20)
+
+	m.mu.Lock()
+	m.inFlight--
+	m.mu.Unlock()
+	return m.mockEthService.SendRequest(ctx, body, headers)
+}
+
+// Test the batch concurrency limit.
+func TestHandleBatchConcurrency(t *testing.T) {
+	t.Run("no more than the configured number of elements are in flight at once", func(t *testing.T) {
+		ethClient := &inFlightEthService{}
+		service := &EthService{EthClient: ethClient, batchConcurrency: 3}
+		elements := make([]string, 10)
+		for i := range elements {
+			elements[i] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"eth_chainId","params":[]}`, i)
+		}
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader("["+strings.Join(elements, ",")+"]"))
+
+		responses := parseBatchResponse(t, rr.Body.Bytes())
+		require.Len(t, responses, 10)
+		for i, response := range responses {
+			require.Equal(t, float64(i), response.ID)
+		}
+		require.LessOrEqual(t, ethClient.maxInFlight, 3)
+		require.Greater(t, ethClient.maxInFlight, 1)
+	})
+
+	t.Run("the batch is logged as a whole", func(t *testing.T) {
+		hook := test.NewGlobal()
+		defer hook.Reset()
+		service := &EthService{EthClient: &mockEthService{}, batchConcurrency: 2}
+
+		handler := accessLog(service.handleRequest)
+		handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(`[
+			{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]},
+			{"jsonrpc":"2.0","id":2,"method":"status_transitions","params":[]}
+		]`)))
+
+		require.Equal(t, "batch", hook.LastEntry().Data["method"])
+	})
+}
+
+// Test concurrent eth_sendRawTransaction elements against the transaction store, run with -race.
+func TestHandleBatchConcurrentSendRawTransaction(t *testing.T) {
+	// The logger's lock would order the elements and hide races from the detector.
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.PanicLevel)
+	require.NoError(t, ethclient.Init())
+	service := &EthService{EthClient: ethclient.Client, batchConcurrency: 16}
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethTypes.LatestSignerForChainID(big.NewInt(5))
+
+	// Every transaction is sent twice in the batch.
+	elements := []string{}
+	hashes := map[string]bool{}
+	for nonce := uint64(0); nonce < 32; nonce++ {
+		tx, err := ethTypes.SignNewTx(key, signer, &ethTypes.DynamicFeeTx{
+			ChainID: big.NewInt(5), Nonce: nonce, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(10), Gas: 21000, To: &common.Address{1},
+		})
+		require.NoError(t, err)
+		raw, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		hashes[tx.Hash().String()] = false
+		for i := 0; i < 2; i++ {
+			elements = append(elements, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"eth_sendRawTransaction","params":["%s"]}`,
+				len(elements), hexutil.Encode(raw)))
+		}
+	}
+
+	rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader("["+strings.Join(elements, ",")+"]"))
+
+	responses := parseBatchResponse(t, rr.Body.Bytes())
+	require.Len(t, responses, len(elements))
+	rejected := 0
+	for _, response := range responses {
+		if response.Error != nil {
+			require.Contains(t, response.Error.Message, "already STORED")
+			rejected++
+			continue
+		}
+		hash, ok := response.Result.(string)
+		require.True(t, ok)
+		stored, known := hashes[hash]
+		require.True(t, known)
+		require.False(t, stored, "transaction %s stored twice", hash)
+		hashes[hash] = true
+	}
+	require.Equal(t, len(hashes), rejected)
+	require.Len(t, ethclient.Client.ListTransactions(), len(hashes))
+}
+
+// parseBatchResponse is a helper function to parse the response of a batch.
+func parseBatchResponse(t *testing.T, body []byte) []types.JSONRPCResponse {
+	var responses []types.JSONRPCResponse
+	err := json.Unmarshal(body, &responses)
+	require.NoError(t, err)
+	return responses
+}
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"net/http"
+	"strings"
+)
+
+// corsPolicy is the CORS configuration of the server, a nil policy disables CORS.
+type corsPolicy struct {
+	origins     []string
+	methods     []string
+	headers     []string
+	credentials bool
+}
+
+// newCORSPolicy returns the policy allowing the given origins, nil when there are none.
+func newCORSPolicy(origins, methods, headers []string, credentials bool) *corsPolicy {
+	if len(origins) == 0 {
+		return nil
+	}
+	return &corsPolicy{origins: origins, methods: methods, headers: headers, credentials: credentials}
+}
+
+// allowedOrigin returns the Access-Control-Allow-Origin value for a request origin, empty when it's not allowed.
+// A credentialed policy echoes the origin since browsers reject the wildcard with credentials.
+func (p *corsPolicy) allowedOrigin(origin string) string {
+	for _, allowed := range p.origins {
+		if allowed == "*" && !p.credentials {
+			return "*"
+		}
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// withCORS sets the CORS headers of the requests from an allowed origin and answers their preflight requests.
+func withCORS(policy *corsPolicy, next http.HandlerFunc) http.HandlerFunc {
+	if policy == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+		allowed := ""
+		if origin != "" {
+			allowed = policy.allowedOrigin(origin)
+		}
+		if allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			if policy.credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		// A preflight request is answered here whether the origin is allowed or not, the browser checks the headers.
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed != "" {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.methods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.headers, ", "))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next(w, r)
+	}
+}
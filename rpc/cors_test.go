@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test the CORS headers of a credentialed policy.
+func TestCORS(t *testing.T) {
+	policy := newCORSPolicy([]string{"https://app.example"}, []string{"POST", "OPTIONS"}, []string{"Content-Type", "X-API-Key"}, true)
+	router := newRouter(&EthService{EthClient: &mockEthService{}, cors: policy})
+	send := func(method, origin string) *httptest.ResponseRecorder {
+		var body *strings.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"status_transitions","params":[]}`)
+		} else {
+			body = strings.NewReader("")
+		}
+		req := httptest.NewRequest(method, "/", body)
+		req.Header.Set("Origin", origin)
+		if method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("a preflight request from an allowed origin gets the configured lists", func(t *testing.T) {
+		rr := send(http.MethodOptions, "https://app.example")
+
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Equal(t, "https://app.example", rr.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "POST, OPTIONS", rr.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "Content-Type, X-API-Key", rr.Header().Get("Access-Control-Allow-Headers"))
+		require.Empty(t, rr.Body.String())
+	})
+
+	t.Run("a request from an allowed origin is handled with the CORS headers", func(t *testing.T) {
+		rr := send(http.MethodPost, "https://app.example")
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, "https://app.example", rr.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "Origin", rr.Header().Get("Vary"))
+	})
+
+	t.Run("a request from another origin gets no CORS headers", func(t *testing.T) {
+		rr := send(http.MethodOptions, "https://evil.example")
+
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		require.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		require.Empty(t, rr.Header().Get("Access-Control-Allow-Credentials"))
+		require.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+	})
+}
+
+// Test the origin returned by the CORS policies.
+func TestCORSAllowedOrigin(t *testing.T) {
+	t.Run("a wildcard allows any origin", func(t *testing.T) {
+		policy := newCORSPolicy([]string{"*"}, nil, nil, false)
+		require.Equal(t, "*", policy.allowedOrigin("https://app.example"))
+	})
+
+	t.Run("origins are matched ignoring case", func(t *testing.T) {
+		policy := newCORSPolicy([]string{"https://App.example"}, nil, nil, true)
+		require.Equal(t, "https://app.example", policy.allowedOrigin("https://app.example"))
+		require.Empty(t, policy.allowedOrigin("https://app.example.evil"))
+	})
+
+	t.Run("without origins CORS is disabled", func(t *testing.T) {
+		require.Nil(t, newCORSPolicy(nil, []string{"POST"}, nil, false))
+	})
+}
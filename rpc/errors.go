@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+)
+
+// errorCode is a JSON-RPC error code, every error response uses one of the codes below.
+type errorCode int
+
+// Standard JSON-RPC 2.0 codes, and the server-defined ones in the -32000 to -32099 range.
+const (
+	// codeParseError: the body couldn't be read.
+	codeParseError errorCode = -32700
+	// codeInvalidRequest: the body isn't a valid request or batch.
+	codeInvalidRequest errorCode = -32600
+	// codeMethodNotFound: the method isn't handled and proxying is disabled.
+	codeMethodNotFound errorCode = -32601
+	// codeInvalidParams: the params are missing or malformed.
+	codeInvalidParams errorCode = -32602
+	// codeInternalError: the server failed to produce a response.
+	codeInternalError errorCode = -32603
+	// codeServerError: the request was valid but failed, e.g. an unknown transaction or a panic.
+	codeServerError errorCode = -32000
+	// codeUnauthorized: an admin method was called without the admin API key.
+	codeUnauthorized errorCode = -32001
+	// codeLimitExceeded: a rate limit was exceeded.
+	codeLimitExceeded errorCode = -32005
+)
+
+// nodeErrorCode returns the code of the JSON-RPC error returned by the node that caused err, so wallets can branch on it,
+// or fallback when err doesn't come from a node error.
+func nodeErrorCode(err error, fallback errorCode) errorCode {
+	var nodeErr *types.JSONRPCError
+	if errors.As(err, &nodeErr) {
+		return errorCode(nodeErr.Code)
+	}
+	return fallback
+}
+
+// sanitizeMessage replaces the invalid UTF-8 sequences of an error message, e.g. relayed from the node, with U+FFFD
+// so the response is always valid JSON text.
+func sanitizeMessage(message string) string {
+	return strings.ToValidUTF8(message, "\uFFFD")
+}
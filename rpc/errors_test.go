@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the known failures are answered with the expected error codes.
+func TestErrorCodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		service *EthService
+		body    string
+		code    int
+	}{
+		{"malformed JSON", &EthService{EthClient: &mockEthService{}}, `{"jsonrpc":`, -32600},
+		{"invalid request", &EthService{EthClient: &mockEthService{}}, `"not a request"`, -32600},
+		{"unknown method without proxy", &EthService{EthClient: &mockEthService{}, proxyDisabled: true}, `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`, -32601},
+		{"missing params", &EthService{EthClient: &mockEthService{}}, `{"jsonrpc":"2.0","id":1,"method":"cancel_transaction","params":[]}`, -32602},
+		{"failed call", &EthService{EthClient: &mockEthService{}}, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"cancel_transaction","params":["%s"]}`, notFoundTransactionHash), -32000},
+		{"rate limited sender", &EthService{EthClient: &rateLimitedEthService{}}, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`, validTransactionRawHex), -32005},
+		{"node error on store", &EthService{EthClient: &nodeErrorEthService{}}, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`, validTransactionRawHex), -32003},
+		{"node error on next nonce", &EthService{EthClient: &nodeErrorEthService{}}, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"next_nonce","params":["%s"]}`, senderAddress), -32003},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := makeRequest(t, tt.service.handleRequest, "POST", "/", strings.NewReader(tt.body))
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			var resp types.JSONRPCResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.NotNil(t, resp.Error)
+			require.Equal(t, tt.code, resp.Error.Code)
+		})
+	}
+}
+
+// nodeErrorEthService fails the calls reaching the node with the node's JSON-RPC error.
+type nodeErrorEthService struct {
+	mockEthService
+}
+
+func (m *nodeErrorEthService) StoreTransaction(tx types.Transaction) error {
+	return fmt.Errorf("failed to get the sender balance: %w", &types.JSONRPCError{Code: -32003, Message: "transaction rejected"})
+}
+
+func (m *nodeErrorEthService) NextNonce(ctx context.Context, from common.Address) (uint64, error) {
+	return 0, fmt.Errorf("failed to get the next nonce: %w", &types.JSONRPCError{Code: -32003, Message: "transaction rejected"})
+}
+
+// badMessageEthService fails cancellations with a node error message containing invalid UTF-8.
+type badMessageEthService struct {
+	mockEthService
+}
+
+func (m *badMessageEthService) CancelTransaction(hash string) error {
+	return errors.New("execution reverted: \xff\xfe")
+}
+
+// Test an error message with invalid UTF-8 still produces a valid JSON response.
+func TestErrorMessageInvalidUTF8(t *testing.T) {
+	service := &EthService{EthClient: &badMessageEthService{}}
+	request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"cancel_transaction","params":["%s"]}`, validTransactionHash)
+
+	rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+	require.True(t, utf8.Valid(rr.Body.Bytes()))
+	require.True(t, json.Valid(rr.Body.Bytes()))
+	resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+	require.Equal(t, "execution reverted: \uFFFD", resp.Error.Message)
+}
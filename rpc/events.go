@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// handleEvents streams every transaction status change as a server-sent event until the client disconnects or the server shuts down.
+// With a hash query parameter, only the changes of that transaction are streamed and the stream ends once it reaches a final status.
+// Each event is a JSON encoded types.StatusChange in the data field.
+func (s *EthService) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var events <-chan types.StatusChange
+	var unsubscribe func()
+	if hash := r.URL.Query().Get("hash"); hash != "" {
+		err := isValidTxHash(hash)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		events, unsubscribe, err = s.EthClient.SubscribeTransaction(common.HexToHash(hash).String())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	} else {
+		events, unsubscribe = s.EthClient.SubscribeStatusChanges()
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// The transaction reached a final status, or the subscriber fell behind and was disconnected.
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				log.Error("Failed to encode status change: ", err)
+				continue
+			}
+			_, err = fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		case <-s.shutdown:
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingEthService emits the status changes sent on its events channel.
+type streamingEthService struct {
+	mockEthService
+	events chan types.StatusChange
+}
+
+func (m *streamingEthService) SubscribeStatusChanges() (<-chan types.StatusChange, func()) {
+	return m.events, func() {}
+}
+
+func (m *streamingEthService) SubscribeTransaction(hash string) (<-chan types.StatusChange, func(), error) {
+	if hash != validTransactionHash {
+		return nil, nil, errors.New("transaction not found")
+	}
+	return m.events, func() {}, nil
+}
+
+// Test the /events firehose.
+func TestHandleEvents(t *testing.T) {
+	t.Run("a subscriber receives every status change as a server-sent event", func(t *testing.T) {
+		ethClient := &streamingEthService{events: make(chan types.StatusChange)}
+		server := httptest.NewServer(newRouter(&EthService{EthClient: ethClient}))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/events")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		ethClient.events <- types.StatusChange{Hash: validTransactionHash, From: "STORED", To: "CANCELED"}
+		ethClient.events <- types.StatusChange{Hash: notFoundTransactionHash, From: "STORED", To: "BROADCASTED"}
+		close(ethClient.events)
+
+		var received []types.StatusChange
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var event types.StatusChange
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+			received = append(received, event)
+		}
+
+		require.Len(t, received, 2)
+		require.Equal(t, "CANCELED", received[0].To)
+		require.Equal(t, "BROADCASTED", received[1].To)
+	})
+
+	t.Run("when the client disconnects, stop streaming", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+		req := httptest.NewRequest("GET", "/events", nil)
+		ctx, cancel := context.WithCancel(req.Context())
+		cancel()
+
+		rr := httptest.NewRecorder()
+		service.handleEvents(rr, req.WithContext(ctx))
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("a subscriber to a transaction receives its changes until the final one", func(t *testing.T) {
+		ethClient := &streamingEthService{events: make(chan types.StatusChange, 1)}
+		server := httptest.NewServer(newRouter(&EthService{EthClient: ethClient}))
+		defer server.Close()
+
+		resp, err := http.Get(server.URL + "/events?hash=" + validTransactionHash)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		// The client closes the channel once the transaction reaches a final status.
+		ethClient.events <- types.StatusChange{Hash: validTransactionHash, From: "STORED", To: "BROADCASTED"}
+		close(ethClient.events)
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, 1, strings.Count(string(body), "data: "))
+		require.Contains(t, string(body), `"from":"STORED","to":"BROADCASTED"`)
+	})
+
+	t.Run("when the transaction is unknown, return not found", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+
+		rr := makeRequest(t, service.handleEvents, "GET", "/events?hash="+notFoundTransactionHash, nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("when the hash is invalid, return bad request", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+
+		rr := makeRequest(t, service.handleEvents, "GET", "/events?hash=0x1234", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// exportHeader is the header row of the CSV export of the stored transactions.
+var exportHeader = []string{"hash", "sender", "nonce", "status", "gas_fee_cap", "gas_tip_cap", "stored_at", "reason"}
+
+// handleExport streams the stored transactions as CSV, one row per transaction, for offline analysis.
+// It requires the admin API key, and format is the only query parameter, csv being the default and only format.
+func (s *EthService) handleExport(w http.ResponseWriter, r *http.Request) {
+	err := s.checkAdminKey(r)
+	if err != nil {
+		log.Error("Export rejected: ", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		http.Error(w, "unsupported format: "+format, http.StatusBadRequest)
+		return
+	}
+
+	// The rows are written from a copy of the store, so the export doesn't hold the lock while the client reads it.
+	transactions := s.EthClient.Transactions()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+	writer := csv.NewWriter(w)
+	writer.Write(exportHeader)
+	for i := range transactions {
+		err := writer.Write(exportRow(&transactions[i]))
+		if err != nil {
+			log.Error("Failed to write the export: ", err)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Error("Failed to write the export: ", err)
+	}
+}
+
+// exportRow returns the CSV row of a transaction, with an empty sender when it can't be recovered.
+func exportRow(tx *types.Transaction) []string {
+	sender := ""
+	if from, err := txSender(tx); err == nil {
+		sender = from.Hex()
+	}
+	storedAt := ""
+	if !tx.StoredAt.IsZero() {
+		storedAt = tx.StoredAt.UTC().Format(time.RFC3339Nano)
+	}
+	return []string{
+		tx.Hash().String(),
+		sender,
+		strconv.FormatUint(tx.Nonce(), 10),
+		tx.Status.String(),
+		tx.GasFeeCap().String(),
+		tx.GasTipCap().String(),
+		storedAt,
+		tx.Reason,
+	}
+}
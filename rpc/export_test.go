@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test the CSV export of the stored transactions.
+func TestExport(t *testing.T) {
+	router := newRouter(&EthService{EthClient: &mockEthService{}, adminAPIKey: "secret"})
+	export := func(key, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/export"+query, nil)
+		req.Header.Set(adminAPIKeyHeader, key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("stream a header and a row per transaction", func(t *testing.T) {
+		rr := export("secret", "?format=csv")
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		rows, err := csv.NewReader(rr.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 2)
+		require.Equal(t, []string{"hash", "sender", "nonce", "status", "gas_fee_cap", "gas_tip_cap", "stored_at", "reason"}, rows[0])
+		tx, err := decodeRawTx(validTransactionRawHex)
+		require.NoError(t, err)
+		row := rows[1]
+		row[1] = strings.ToLower(row[1])
+		require.Equal(t, []string{
+			tx.Hash().String(), "0x007ab5199b6c57f7aa51bc3d0604a43505501a0c", "12313", "STORED", "1500000016", "1500000000", "2024-01-02T03:04:05Z", "",
+		}, row)
+	})
+
+	t.Run("csv is the default format", func(t *testing.T) {
+		rr := export("secret", "")
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("reject an unsupported format", func(t *testing.T) {
+		rr := export("secret", "?format=json")
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("without the admin API key, reject the export", func(t *testing.T) {
+		rr := export("wrong", "")
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.NotContains(t, rr.Body.String(), "STORED")
+	})
+}
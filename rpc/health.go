@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readinessTimeout bounds the call to the node made by a readiness check.
+const readinessTimeout = 2 * time.Second
+
+// healthStatus is the JSON body of the health probe responses.
+type healthStatus struct {
+	Status string `json:"status"`
+	// BlockNumber is the latest block of the node, set by the readiness checks.
+	BlockNumber *uint64 `json:"blockNumber,omitempty"`
+}
+
+// handleHealth answers the liveness probes with 200 as long as the server is up. With ?ready=true, it's a readiness probe:
+// it also checks the node answers eth_blockNumber within readinessTimeout, and answers 503 when it doesn't.
+// The node error is only logged since it may contain the node URL and its API key.
+func (s *EthService) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if r.URL.Query().Get("ready") != "true" {
+		json.NewEncoder(w).Encode(healthStatus{Status: "ok"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+	blockNumber, err := s.EthClient.BlockNumber(ctx)
+	if err != nil {
+		log.Warn("Readiness check failed, the node is unreachable: ", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(healthStatus{Status: "unavailable"})
+		return
+	}
+	json.NewEncoder(w).Encode(healthStatus{Status: "ok", BlockNumber: &blockNumber})
+}
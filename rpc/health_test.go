@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// unreachableEthService is a mockEthService whose node can't be reached.
+type unreachableEthService struct {
+	mockEthService
+}
+
+func (m *unreachableEthService) BlockNumber(ctx context.Context) (uint64, error) {
+	return 0, errors.New(`Post "https://goerli.infura.io/v3/secret": connection refused`)
+}
+
+// Test the health probes.
+func TestHealth(t *testing.T) {
+	probe := func(ec EthServiceInterface, target string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		newRouter(&EthService{EthClient: ec}).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr
+	}
+
+	t.Run("the liveness probe succeeds whatever the node", func(t *testing.T) {
+		rr := probe(&unreachableEthService{}, "/health")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+	})
+
+	t.Run("the readiness probe returns the latest block of the node", func(t *testing.T) {
+		rr := probe(&mockEthService{}, "/health?ready=true")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"status":"ok","blockNumber":16}`, rr.Body.String())
+	})
+
+	t.Run("the readiness probe fails when the node is unreachable, without leaking its URL", func(t *testing.T) {
+		rr := probe(&unreachableEthService{}, "/health?ready=true")
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		require.JSONEq(t, `{"status":"unavailable"}`, rr.Body.String())
+	})
+}
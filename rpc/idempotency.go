@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"sync"
+	"time"
+)
+
+// idempotencyKeyHeader lets clients retry eth_sendRawTransaction and get the outcome of their first successful submission.
+const idempotencyKeyHeader = "X-Idempotency-Key"
+
+// idempotencyCache maps idempotency keys to the result of the submission that used them.
+// Entries expire after ttl plus skew and the oldest ones are evicted once maxKeys is reached.
+type idempotencyCache struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+	// pending holds the keys of the submissions in progress, the channel is closed once the submission completes.
+	pending map[string]chan struct{}
+	// order lists the keys from the oldest to the newest.
+	order []string
+	ttl   time.Duration
+	// skew tolerates a small clock drift before expiring an entry.
+	skew    time.Duration
+	maxKeys int
+	now     func() time.Time
+}
+
+type idempotencyEntry struct {
+	result  interface{}
+	expires time.Time
+}
+
+func newIdempotencyCache(ttl time.Duration, maxKeys int) *idempotencyCache {
+	return &idempotencyCache{
+		entries: make(map[string]idempotencyEntry),
+		pending: make(map[string]chan struct{}),
+		ttl:     ttl,
+		maxKeys: maxKeys,
+		now:     time.Now,
+	}
+}
+
+// get returns the result stored for a key, if it hasn't expired.
+func (c *idempotencyCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lookup(key)
+}
+
+// reserve returns the result stored for a key, waiting for a submission in progress with the same key to complete.
+// Without a result, the key is reserved for the caller, who must then put its result or release it.
+func (c *idempotencyCache) reserve(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		done, ok := c.pending[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		<-done
+		c.mu.Lock()
+	}
+	if result, ok := c.lookup(key); ok {
+		return result, true
+	}
+	c.pending[key] = make(chan struct{})
+	return nil, false
+}
+
+// release drops the reservation of a key, letting the next submission with that key proceed. It's a no-op once the result was put.
+func (c *idempotencyCache) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.unblock(key)
+}
+
+// lookup returns the result stored for a key, if it hasn't expired. c.mu must be held.
+func (c *idempotencyCache) lookup(key string) (interface{}, bool) {
+	entry, ok := c.entries[key]
+	if !ok || c.expired(entry, c.now()) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// unblock wakes the submissions waiting for a key. c.mu must be held.
+func (c *idempotencyCache) unblock(key string) {
+	if done, ok := c.pending[key]; ok {
+		close(done)
+		delete(c.pending, key)
+	}
+}
+
+// put stores the result of a key, evicting the expired and then the oldest entries to stay within maxKeys.
+// The submissions waiting for the key get that result.
+func (c *idempotencyCache) put(key string, result interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	defer c.unblock(key)
+	now := c.now()
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = idempotencyEntry{result: result, expires: now.Add(c.ttl)}
+
+	for len(c.order) > 0 {
+		oldest := c.order[0]
+		if len(c.order) <= c.maxKeys && !c.expired(c.entries[oldest], now) {
+			break
+		}
+		delete(c.entries, oldest)
+		c.order = c.order[1:]
+	}
+}
+
+// expired reports whether an entry expired, allowing for the clock skew tolerance.
+func (c *idempotencyCache) expired(entry idempotencyEntry, now time.Time) bool {
+	return now.After(entry.expires.Add(c.skew))
+}
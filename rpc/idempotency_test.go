@@ -0,0 +1,168 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingEthService holds StoreTransaction until unblock is closed, signalling entered on each call.
+type blockingEthService struct {
+	recordingEthService
+	mu      sync.Mutex
+	entered chan struct{}
+	unblock chan struct{}
+}
+
+func (m *blockingEthService) StoreTransaction(tx types.Transaction) error {
+	m.entered <- struct{}{}
+	<-m.unblock
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.recordingEthService.StoreTransaction(tx)
+}
+
+// Test idempotency keys on eth_sendRawTransaction.
+func TestIdempotencyKey(t *testing.T) {
+	sendRaw := func(t *testing.T, service *EthService, id int, rawTx, key string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"eth_sendRawTransaction","params":["%s"]}`, id, rawTx)
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		rr := httptest.NewRecorder()
+		service.handleRequest(rr, req)
+		return rr
+	}
+
+	t.Run("when a key is repeated, return the cached result without storing the transaction again", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient, idempotency: newIdempotencyCache(time.Minute, 10)}
+
+		first := parseAndCheckResponse(t, sendRaw(t, service, 1, validTransactionRawHex, "key-1"), http.StatusOK, float64(1), "2.0")
+		require.Nil(t, first.Error)
+
+		// The retry carries a regenerated transaction.
+		second := parseAndCheckResponse(t, sendRaw(t, service, 2, existingTransactionRaw, "key-1"), http.StatusOK, float64(2), "2.0")
+		require.Nil(t, second.Error)
+		require.Equal(t, first.Result, second.Result)
+		require.Len(t, ethClient.stored, 1)
+	})
+
+	t.Run("when the first submission failed, don't cache its outcome", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}, idempotency: newIdempotencyCache(time.Minute, 10)}
+
+		first := parseAndCheckResponse(t, sendRaw(t, service, 1, existingTransactionRaw, "key-1"), http.StatusOK, float64(1), "2.0")
+		require.NotNil(t, first.Error)
+
+		second := parseAndCheckResponse(t, sendRaw(t, service, 2, validTransactionRawHex, "key-1"), http.StatusOK, float64(2), "2.0")
+		require.Nil(t, second.Error)
+	})
+
+	t.Run("when a key is repeated while the first submission is in progress, wait for its result", func(t *testing.T) {
+		ethClient := &blockingEthService{entered: make(chan struct{}, 2), unblock: make(chan struct{})}
+		service := &EthService{EthClient: ethClient, idempotency: newIdempotencyCache(time.Minute, 10)}
+
+		responses := make([]*httptest.ResponseRecorder, 2)
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			responses[0] = sendRaw(t, service, 1, validTransactionRawHex, "key-1")
+		}()
+		<-ethClient.entered
+		// The retry carries a regenerated transaction.
+		go func() {
+			defer wg.Done()
+			responses[1] = sendRaw(t, service, 2, existingTransactionRaw, "key-1")
+		}()
+		time.Sleep(50 * time.Millisecond)
+		close(ethClient.unblock)
+		wg.Wait()
+
+		first := parseAndCheckResponse(t, responses[0], http.StatusOK, float64(1), "2.0")
+		second := parseAndCheckResponse(t, responses[1], http.StatusOK, float64(2), "2.0")
+		require.Nil(t, second.Error)
+		require.Equal(t, first.Result, second.Result)
+		require.Len(t, ethClient.stored, 1)
+	})
+
+	t.Run("when the first submission failed, the waiting retry is stored", func(t *testing.T) {
+		cache := newIdempotencyCache(time.Minute, 10)
+		_, ok := cache.reserve("key-1")
+		require.False(t, ok)
+
+		reserved := make(chan bool)
+		go func() {
+			_, ok := cache.reserve("key-1")
+			reserved <- ok
+		}()
+		cache.release("key-1")
+		require.False(t, <-reserved)
+		require.Contains(t, cache.pending, "key-1")
+	})
+
+	t.Run("without a key, store every submission", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient, idempotency: newIdempotencyCache(time.Minute, 10)}
+
+		sendRaw(t, service, 1, validTransactionRawHex, "")
+		sendRaw(t, service, 2, validTransactionRawHex, "")
+		require.Len(t, ethClient.stored, 2)
+	})
+}
+
+// Test the idempotency cache bounds.
+func TestIdempotencyCache(t *testing.T) {
+	t.Run("entries expire after the TTL", func(t *testing.T) {
+		now := time.Now()
+		cache := newIdempotencyCache(time.Minute, 10)
+		cache.now = func() time.Time { return now }
+
+		cache.put("key", "0x1")
+		_, ok := cache.get("key")
+		require.True(t, ok)
+
+		now = now.Add(time.Minute + time.Second)
+		_, ok = cache.get("key")
+		require.False(t, ok)
+	})
+
+	t.Run("entries just past the TTL don't expire within the clock skew tolerance", func(t *testing.T) {
+		now := time.Now()
+		cache := newIdempotencyCache(time.Minute, 10)
+		cache.skew = 2 * time.Second
+		cache.now = func() time.Time { return now }
+
+		cache.put("key", "0x1")
+		now = now.Add(time.Minute + time.Second)
+		_, ok := cache.get("key")
+		require.True(t, ok)
+
+		now = now.Add(2 * time.Second)
+		_, ok = cache.get("key")
+		require.False(t, ok)
+	})
+
+	t.Run("the oldest entries are evicted beyond the maximum number of keys", func(t *testing.T) {
+		cache := newIdempotencyCache(time.Minute, 2)
+
+		cache.put("key-1", "0x1")
+		cache.put("key-2", "0x2")
+		cache.put("key-3", "0x3")
+
+		_, ok := cache.get("key-1")
+		require.False(t, ok)
+		result, ok := cache.get("key-3")
+		require.True(t, ok)
+		require.Equal(t, "0x3", result)
+		require.Len(t, cache.entries, 2)
+	})
+}
@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	transactionsTotalDesc = prometheus.NewDesc("txrpc_transactions_total",
+		"Transactions moved to each status since the server started, the stored ones included.", []string{"status"}, nil)
+	storedTransactionsDesc = prometheus.NewDesc("txrpc_stored_transactions",
+		"Transactions currently STORED, waiting for their broadcast.", nil, nil)
+	gasPriceDesc = prometheus.NewDesc("txrpc_gas_price_wei",
+		"Last gas price observed by the monitor, in wei.", nil, nil)
+	gasChecksDesc = prometheus.NewDesc("txrpc_gas_checks_total",
+		"Gas price checks run by the monitor or on demand.", nil, nil)
+	upstreamErrorsDesc = prometheus.NewDesc("txrpc_upstream_errors_total",
+		"Requests to the node that failed or were answered with an HTTP error status.", nil, nil)
+)
+
+// newProxyLatency returns the histogram of the durations of the proxied requests, with the default Prometheus buckets.
+func newProxyLatency() prometheus.Histogram {
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "txrpc_proxy_request_duration_seconds",
+		Help: "Duration of the requests proxied to the node, retries included.",
+	})
+}
+
+// clientCollector reads the metrics of the transaction store and of the node client when they're scraped.
+type clientCollector struct {
+	ethClient EthServiceInterface
+}
+
+// Describe sends the descriptors of the metrics of the client.
+func (c clientCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- transactionsTotalDesc
+	ch <- storedTransactionsDesc
+	ch <- gasPriceDesc
+	ch <- gasChecksDesc
+	ch <- upstreamErrorsDesc
+}
+
+// Collect sends the current values of the metrics of the client.
+func (c clientCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.ethClient.Stats()
+	for status, total := range c.ethClient.StatusTotals() {
+		ch <- prometheus.MustNewConstMetric(transactionsTotalDesc, prometheus.CounterValue, float64(total), strings.ToLower(status))
+	}
+	ch <- prometheus.MustNewConstMetric(storedTransactionsDesc, prometheus.GaugeValue, float64(stats.Queue["STORED"]))
+	// The gas price is left out until the monitor observed one.
+	if stats.GasPrice != nil {
+		gasPrice, _ := new(big.Float).SetInt(stats.GasPrice.ToInt()).Float64()
+		ch <- prometheus.MustNewConstMetric(gasPriceDesc, prometheus.GaugeValue, gasPrice)
+	}
+	ch <- prometheus.MustNewConstMetric(gasChecksDesc, prometheus.CounterValue, float64(stats.Checks))
+	ch <- prometheus.MustNewConstMetric(upstreamErrorsDesc, prometheus.CounterValue, float64(stats.UpstreamErrors))
+}
+
+// metricsHandler exposes the metrics of the server in the Prometheus text format: the transactions moved to each status,
+// the STORED transactions, the last observed gas price, the monitor checks, the failed requests to the node and the latencies of the proxied requests.
+func (s *EthService) metricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(clientCollector{ethClient: s.EthClient})
+	if s.proxyLatency != nil {
+		registry.MustRegister(s.proxyLatency)
+	}
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
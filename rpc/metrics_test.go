@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test the /metrics route exposes the metrics in the Prometheus text format.
+func TestMetrics(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}, proxyLatency: newProxyLatency()}
+	service.proxyLatency.Observe((20 * time.Millisecond).Seconds())
+
+	rr := httptest.NewRecorder()
+	newRouter(service).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+
+	body := rr.Body.String()
+	require.Contains(t, body, "# TYPE txrpc_transactions_total counter\n")
+	for _, sample := range []string{
+		`txrpc_transactions_total{status="stored"} 3`,
+		`txrpc_transactions_total{status="canceled"} 0`,
+		`txrpc_transactions_total{status="spedup"} 0`,
+		`txrpc_transactions_total{status="failed"} 1`,
+		`txrpc_transactions_total{status="broadcasted"} 1`,
+		`txrpc_transactions_total{status="expired"} 0`,
+		"txrpc_stored_transactions 2",
+		"txrpc_gas_price_wei 1e+09",
+		"txrpc_gas_checks_total 5",
+		"txrpc_upstream_errors_total 1",
+		`txrpc_proxy_request_duration_seconds_bucket{le="0.025"} 1`,
+		"txrpc_proxy_request_duration_seconds_count 1",
+	} {
+		require.Contains(t, body, sample+"\n")
+	}
+}
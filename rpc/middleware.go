@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// accessLogKey is the context key under which the access log details of a request are stored.
+type accessLogKey struct{}
+
+// accessLogInfo holds the JSON-RPC details of a request, filled in by the handler once the body is decoded.
+type accessLogInfo struct {
+	method string
+	id     interface{}
+}
+
+// statusRecorder wraps an http.ResponseWriter to capture the status code written by the handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code before writing it to the underlying ResponseWriter.
+func (sr *statusRecorder) WriteHeader(code int) {
+	sr.status = code
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Flush forwards to the underlying ResponseWriter so streaming handlers keep working behind the middleware.
+func (sr *statusRecorder) Flush() {
+	if flusher, ok := sr.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// accessLog middleware emits one structured log entry per request once the handler returns.
+func accessLog(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		info := &accessLogInfo{}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next(rec, r.WithContext(context.WithValue(r.Context(), accessLogKey{}, info)))
+
+		log.WithFields(log.Fields{
+			"method":      info.method,
+			"id":          info.id,
+			"status":      rec.status,
+			"duration_ms": time.Since(start).Milliseconds(),
+			"client_ip":   clientIP(r),
+		}).Info("access")
+	}
+}
+
+// setAccessLogInfo records the decoded JSON-RPC request details for the access log, if the request goes through it.
+func setAccessLogInfo(ctx context.Context, req types.JSONRPCRequest) {
+	info, ok := ctx.Value(accessLogKey{}).(*accessLogInfo)
+	if !ok {
+		return
+	}
+	info.method = req.Method
+	info.id = req.ID
+}
+
+// accessLogMethod returns the JSON-RPC method recorded for the access log so far, empty when unknown.
+func accessLogMethod(ctx context.Context) string {
+	info, ok := ctx.Value(accessLogKey{}).(*accessLogInfo)
+	if !ok {
+		return ""
+	}
+	return info.method
+}
+
+// clientIP returns the originating client IP, preferring the first X-Forwarded-For entry when behind a proxy.
+func clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+// Test access log middleware.
+func TestAccessLog(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	t.Run("it logs the JSON-RPC method, id, status, duration and client IP", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+		handler := accessLog(service.handleRequest)
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":7,"method":"eth_chainId","params":[]}`))
+		req.RemoteAddr = "10.0.0.1:54321"
+		handler(httptest.NewRecorder(), req)
+
+		entry := hook.LastEntry()
+		require.NotNil(t, entry)
+		require.Equal(t, log.InfoLevel, entry.Level)
+		require.Equal(t, "access", entry.Message)
+		require.Equal(t, "eth_chainId", entry.Data["method"])
+		require.Equal(t, json.Number("7"), entry.Data["id"])
+		require.Equal(t, http.StatusOK, entry.Data["status"])
+		require.Equal(t, "10.0.0.1", entry.Data["client_ip"])
+		require.Contains(t, entry.Data, "duration_ms")
+	})
+
+	t.Run("it records the status code written by the handler and the forwarded client IP", func(t *testing.T) {
+		handler := accessLog(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.5, 10.0.0.1")
+		handler(httptest.NewRecorder(), req)
+
+		entry := hook.LastEntry()
+		require.Equal(t, http.StatusTeapot, entry.Data["status"])
+		require.Equal(t, "203.0.113.5", entry.Data["client_ip"])
+	})
+}
@@ -0,0 +1,38 @@
+package rpc
+
+import (
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// methodLimiters throttles the JSON-RPC methods configured with their own rate limit, by method.
+// The other methods are only subject to the upstream rate limit of the client.
+type methodLimiters map[string]*rate.Limiter
+
+// newMethodLimiters returns the limiters of the given requests per second by method, nil when there are none.
+// A method can take a second worth of requests at once.
+func newMethodLimiters(limits map[string]float64) methodLimiters {
+	if len(limits) == 0 {
+		return nil
+	}
+	limiters := make(methodLimiters, len(limits))
+	for method, rps := range limits {
+		limiters[method] = rate.NewLimiter(rate.Limit(rps), int(math.Ceil(rps)))
+	}
+	return limiters
+}
+
+// allow reports whether a request for method can be handled at now, and otherwise the time until it can.
+func (l methodLimiters) allow(method string, now time.Time) (bool, time.Duration) {
+	limiter, ok := l[method]
+	if !ok || limiter.AllowN(now, 1) {
+		return true, 0
+	}
+	// Peek at the refill time without consuming a token.
+	reservation := limiter.ReserveN(now, 1)
+	retryAfter := reservation.DelayFrom(now)
+	reservation.CancelAt(now)
+	return false, retryAfter
+}
@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	"github.com/stretchr/testify/require"
+)
+
+// Test the methods with their own rate limit are throttled independently of the others.
+func TestMethodRateLimits(t *testing.T) {
+	service := &EthService{
+		EthClient:        &mockEthService{},
+		methodLimits:     newMethodLimiters(map[string]float64{"eth_getLogs": 0.5, "eth_call": 0.5}),
+		retryAfterHeader: true,
+	}
+	send := func(method string) (*httptest.ResponseRecorder, types.JSONRPCResponse) {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":[]}`, method)
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+		return rr, parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+	}
+
+	_, resp := send("eth_getLogs")
+	require.Nil(t, resp.Error)
+
+	t.Run("the configured method is throttled once its burst is spent", func(t *testing.T) {
+		rr, resp := send("eth_getLogs")
+		require.Equal(t, -32005, resp.Error.Code)
+		require.Equal(t, "method rate limit exceeded", resp.Error.Message)
+		require.Equal(t, "2", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("the other methods aren't throttled", func(t *testing.T) {
+		_, resp := send("eth_call")
+		require.Nil(t, resp.Error)
+		for i := 0; i < 5; i++ {
+			_, resp := send("eth_chainId")
+			require.Nil(t, resp.Error)
+		}
+	})
+}
@@ -3,13 +3,31 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
+	"net"
 	"net/http"
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/safwentrabelsi/tx-json-rpc-server/config"
+	"github.com/safwentrabelsi/tx-json-rpc-server/ethclient"
 	"github.com/safwentrabelsi/tx-json-rpc-server/types"
 	log "github.com/sirupsen/logrus"
 )
@@ -19,68 +37,305 @@ type EthServiceInterface interface {
     StoreTransaction( tx types.Transaction) error
 	CancelTransaction(hex string) error
 	SendRequest(ctx context.Context,body io.Reader, headers http.Header) (*http.Response, error)
+	StatusTransitions() map[string][]string
+	GetTransaction(hash string) (types.TransactionView, error)
+	TransactionStatuses(hashes []string) map[string]string
+	CancelTransactionByNonce(from common.Address, nonce uint64) (string, error)
+	EligibleTransactions() ([]string, error)
+	LastGasPrice() float64
+	StoreTransactions(txs []types.Transaction) ([]string, error)
+	GasStats() types.GasStats
+	SubscribeStatusChanges() (<-chan types.StatusChange, func())
+	SubscribeTransaction(hash string) (<-chan types.StatusChange, func(), error)
+	SubscriberStats() types.SubscriberStats
+	RefreshGasPrice(ctx context.Context) (float64, error)
+	TransactionsByStatus(status types.TransactionStatus) []string
+	NextNonce(ctx context.Context, from common.Address) (uint64, error)
+	SetTransactionStatus(hash string, status types.TransactionStatus) error
+	TestBroadcast(ctx context.Context, hash string) (types.TestBroadcastResult, error)
+	Stats() types.ServerStats
+	StatusTotals() map[string]uint64
+	Transactions() []types.Transaction
+	GasHistory() []types.GasObservation
+	GasSaved() *big.Int
+	CancelIfStored(hash string) error
+	BroadcastReason(ctx context.Context, hash string) (string, error)
+	ListTransactions() []types.TransactionView
+	CancelableTransactions(from common.Address) []string
+	BlockNumber(ctx context.Context) (uint64, error)
 }
 
 // EthService is a service struct that uses an implementation of the EthTransactionService interface.
 type EthService struct {
 	EthClient EthServiceInterface
+	proxyRetries      int
+	proxyRetryBackoff time.Duration
+	// annotateDuplicateIDs processes batches reusing ids instead of rejecting them, flagging the affected responses.
+	annotateDuplicateIDs bool
+	// proxyLatency records the durations of the proxied requests, nil records none.
+	proxyLatency prometheus.Histogram
+	// maxDeadline bounds how far ahead the transaction deadlines can be set, 0 means no limit.
+	maxDeadline time.Duration
+	// queuedGasInfo answers eth_sendRawTransaction with the gas price and the cap of the queued transaction.
+	queuedGasInfo bool
+	// idempotency remembers the results of the submissions made with an idempotency key, nil ignores the keys.
+	idempotency *idempotencyCache
+	// proxyDisabled answers the methods not handled by the server with "method not found" instead of forwarding them to the node.
+	proxyDisabled bool
+	// batchConcurrency bounds the batch elements processed in parallel, 0 or 1 processes them one by one.
+	batchConcurrency int
+	// retryAfterHeader sets the Retry-After header on rate limited responses.
+	retryAfterHeader bool
+	// methodLimits throttles the methods configured with their own rate limit, nil throttles none.
+	methodLimits methodLimiters
+	// adminAPIKey is the key required by the admin methods, empty disables them.
+	adminAPIKey string
+	// devMode adds debugging details, e.g. the stack trace of a panic, to the internal error responses.
+	devMode bool
+	// validateProxyResponses checks the proxied responses are well-formed JSON-RPC responses instead of streaming them as is.
+	validateProxyResponses bool
+	// cors is the CORS policy of the routes, nil disables CORS.
+	cors *corsPolicy
+	// shutdown is closed when the server shuts down, to end the long-lived event streams. nil never is.
+	shutdown chan struct{}
+	// startedAt is when the server started, requests counts the JSON-RPC requests handled since, batch elements included.
+	startedAt time.Time
+	requests  atomic.Uint64
+}
+
+// idempotentMethods lists the read-only methods that are safe to retry when proxying them fails.
+var idempotentMethods = map[string]bool{
+	"eth_blockNumber":           true,
+	"eth_call":                  true,
+	"eth_chainId":               true,
+	"eth_estimateGas":           true,
+	"eth_feeHistory":            true,
+	"eth_gasPrice":              true,
+	"eth_getBalance":            true,
+	"eth_getBlockByHash":        true,
+	"eth_getBlockByNumber":      true,
+	"eth_getCode":               true,
+	"eth_getLogs":               true,
+	"eth_getStorageAt":          true,
+	"eth_getTransactionByHash":  true,
+	"eth_getTransactionCount":   true,
+	"eth_getTransactionReceipt": true,
+	"eth_maxPriorityFeePerGas":  true,
+	"net_version":               true,
+	"web3_clientVersion":        true,
+}
+
+// routeOptionsKey is the context key under which the options of the matched route are stored.
+type routeOptionsKey struct{}
+
+// routeOptions holds the per-route behavior flags of the JSON-RPC handler.
+type routeOptions struct {
+	// immediate forwards eth_sendRawTransaction to the node instead of queuing it.
+	immediate bool
 }
 
 // StartServer initializes and starts the server with provided EthServiceInterface implementation and listening address.
-func StartServer(ec EthServiceInterface) error {
+// It serves until ctx is done, then shuts the server down gracefully like Serve.
+func StartServer(ctx context.Context, ec EthServiceInterface) error {
 	addr := config.GetConfig().Addr()
-	service := &EthService{EthClient: ec}
-	http.HandleFunc("/", recoverPanic(service.handleRequest))
-	log.Info("Starting server on :",addr)
-	err := http.ListenAndServe(addr, nil)
+	listener, err := listen(addr)
 	if err != nil {
 		log.Error("Failed to start server: ", err)
 		return err
 	}
+	return Serve(ctx, ec, listener)
+}
+
+// listen binds the server address before serving so a port taken by another process fails the startup with a clear error.
+func listen(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			_, port, _ := net.SplitHostPort(addr)
+			return nil, fmt.Errorf("port %s already in use: %w", port, err)
+		}
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return listener, nil
+}
+
+// Serve serves the JSON-RPC server on an already bound listener, e.g. one created by a test, until ctx is done.
+// It then stops accepting connections and waits up to the shutdown timeout for the in-flight requests, e.g. the proxied ones, to finish.
+// The event streams are ended right away. It returns nil once the server is shut down.
+func Serve(ctx context.Context, ec EthServiceInterface, listener net.Listener) error {
+	service := &EthService{
+		EthClient:         ec,
+		proxyRetries:      config.GetConfig().ProxyRetries(),
+		proxyRetryBackoff: config.GetConfig().ProxyRetryBackoff(),
+		annotateDuplicateIDs: config.GetConfig().BatchDuplicateIDs() == config.DuplicateIDsAnnotate,
+		queuedGasInfo:        config.GetConfig().QueuedGasInfo(),
+		maxDeadline:          config.GetConfig().MaxDeadline(),
+		proxyLatency:         newProxyLatency(),
+		proxyDisabled:        !config.GetConfig().ProxyEnabled(),
+		batchConcurrency:     config.GetConfig().BatchConcurrency(),
+		retryAfterHeader:     config.GetConfig().RetryAfterHeader(),
+		methodLimits:         newMethodLimiters(config.GetConfig().MethodRateLimits()),
+		adminAPIKey:          config.GetConfig().AdminAPIKey(),
+		devMode:              config.GetConfig().DevMode(),
+		startedAt:            time.Now(),
+		validateProxyResponses: config.GetConfig().ValidateProxyResponses(),
+		cors: newCORSPolicy(config.GetConfig().CORSAllowedOrigins(), config.GetConfig().CORSAllowedMethods(),
+			config.GetConfig().CORSAllowedHeaders(), config.GetConfig().CORSAllowCredentials()),
+	}
+	if config.GetConfig().IdempotencyMaxKeys() > 0 {
+		service.idempotency = newIdempotencyCache(config.GetConfig().IdempotencyTTL(), config.GetConfig().IdempotencyMaxKeys())
+		service.idempotency.skew = config.GetConfig().ClockSkewTolerance()
+	}
+	service.shutdown = make(chan struct{})
+	server := &http.Server{Handler: newRouter(service)}
+	server.RegisterOnShutdown(func() { close(service.shutdown) })
+
+	log.Info("Starting server on :", listener.Addr().String())
+	return serveUntilDone(ctx, server, listener, config.GetConfig().ShutdownTimeout())
+}
+
+// serveUntilDone serves on listener until ctx is done, then shuts the server down, waiting up to timeout for the in-flight requests.
+func serveUntilDone(ctx context.Context, server *http.Server, listener net.Listener, timeout time.Duration) error {
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+	select {
+	case err := <-served:
+		log.Error("Failed to start server: ", err)
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down the server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		// Drop the requests still running.
+		server.Close()
+		return fmt.Errorf("failed to finish the in-flight requests: %w", err)
+	}
 	return nil
 }
 
-// handleRequest handles incoming HTTP requests by decoding the JSON RPC request and processing the request based on the specified method.
+// newRouter registers the JSON-RPC handler on its routes.
+// The default and /queued routes queue transactions while /passthrough forwards them to the node right away.
+// /events streams the transaction status changes and /export the stored transactions as CSV.
+// Every route answers the CORS preflight requests when CORS is enabled.
+func newRouter(service *EthService) *http.ServeMux {
+	queued := accessLog(withCORS(service.cors, recoverPanic(withRouteOptions(routeOptions{}, service.handleRequest), service.devMode)))
+	passthrough := accessLog(withCORS(service.cors, recoverPanic(withRouteOptions(routeOptions{immediate: true}, service.handleRequest), service.devMode)))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", queued)
+	mux.HandleFunc("/queued", queued)
+	mux.HandleFunc("/passthrough", passthrough)
+	mux.HandleFunc("/events", accessLog(withCORS(service.cors, recoverPanic(service.handleEvents, service.devMode))))
+	mux.HandleFunc("/export", accessLog(withCORS(service.cors, recoverPanic(service.handleExport, service.devMode))))
+	// Probes and scrapes are polled, they're not access logged.
+	mux.HandleFunc("/health", service.handleHealth)
+	mux.Handle("/metrics", service.metricsHandler())
+	return mux
+}
+
+// withRouteOptions makes the route options available to the handler through the request context.
+func withRouteOptions(opts routeOptions, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next(w, r.WithContext(context.WithValue(r.Context(), routeOptionsKey{}, opts)))
+	}
+}
+
+// getRouteOptions returns the options of the matched route, defaulting to queuing when none were set.
+func getRouteOptions(ctx context.Context) routeOptions {
+	opts, _ := ctx.Value(routeOptionsKey{}).(routeOptions)
+	return opts
+}
+
+// handleRequest handles incoming HTTP requests by reading the body and dispatching it either as a batch or as a single JSON RPC request.
 func (s *EthService) handleRequest(w http.ResponseWriter, r *http.Request) {
-    var req types.JSONRPCRequest
     bodyBytes, err := io.ReadAll(r.Body)
     if err != nil {
         log.Error("Failed to read request body: ", err)
-		writeJSONRPCError(w, req.ID, -32700, "parse error")
+		writeJSONRPCError(w, nil, codeParseError, "parse error")
         return
     }
+
+	if isBatchRequest(bodyBytes) {
+		s.handleBatch(w, r, bodyBytes)
+		return
+	}
+	s.handleSingleRequest(w, r, bodyBytes)
+}
+
+// handleSingleRequest decodes a single JSON RPC request and processes it based on the specified method.
+func (s *EthService) handleSingleRequest(w http.ResponseWriter, r *http.Request, bodyBytes []byte) {
+    var req types.JSONRPCRequest
     bodyReader := bytes.NewReader(bodyBytes)
 
-    err = json.NewDecoder(bytes.NewBuffer(bodyBytes)).Decode(&req)
+    err := json.NewDecoder(bytes.NewBuffer(bodyBytes)).Decode(&req)
     if err != nil {
         log.Error("Failed to decode request body: ", err)
-		writeJSONRPCError(w, req.ID, -32600, "invalid json request")
+		writeJSONRPCError(w, req.ID, codeInvalidRequest, "invalid json request")
         return
     }
+	setAccessLogInfo(r.Context(), req)
+	s.requests.Add(1)
+
+	// Throttle the expensive methods before handling or proxying them.
+	if ok, retryAfter := s.methodLimits.allow(req.Method, time.Now()); !ok {
+		log.WithField("method", req.Method).Warn("Method rate limit exceeded")
+		s.setRetryAfterDelay(w, retryAfter)
+		writeJSONRPCError(w, req.ID, codeLimitExceeded, "method rate limit exceeded")
+		return
+	}
 
 	// For the proxy, make sure to reset the reader.
     bodyReader.Seek(0, io.SeekStart)
 
 	switch req.Method {
 	case "eth_sendRawTransaction":
+		// Passthrough routes don't queue the transaction.
+		if getRouteOptions(r.Context()).immediate {
+			s.proxyToRPCNode(w, r, req, bodyReader)
+			break
+		}
+		// A retried submission gets the result of the first one, waiting for it if it's still in progress.
+		// The key is released if this submission fails, so the next retry is stored.
+		idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+		if idempotencyKey != "" && s.idempotency != nil {
+			if result, ok := s.idempotency.reserve(idempotencyKey); ok {
+				writeJSONRPCResult(w, req.ID, result)
+				return
+			}
+			defer s.idempotency.release(idempotencyKey)
+		}
 		res := types.JSONRPCResponse{
 			Jsonrpc: "2.0",
 			ID: req.ID,
 		}
 		if len(req.Params) > 0 {
+			rawTx := req.Params[0]
+			if isBase64Encoded(req.Params) {
+				// The raw transaction is stored and broadcast as hex.
+				rawTx, err = base64RawTxToHex(rawTx)
+				if err != nil {
+					log.Error(err.Error())
+					writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
+					return
+				}
+			}
 			// Validate the raw transaction hex.
-			err = isValidHexRawTx(req.Params[0])
+			err = isValidHexRawTx(rawTx)
 			if  err != nil {
 				log.Error(err.Error())
-				writeJSONRPCError(w, req.ID, -32602, "invalid params")
+				writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
 				return
 			}
-			rawHex := req.Params[0].(string)
+			rawHex := rawTx.(string)
 			// Decode to bytes
 			bytesTx, err := hex.DecodeString(rawHex[2:]) 
 			if err != nil {
 				log.Error("Failed to decode transaction data: ", err.Error())
-				writeJSONRPCError(w, req.ID, -32602, "invalid params")
+				writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", "failed to decode transaction data: "+err.Error())
 				return
 			}
 			// Unmarshal to tx type.
@@ -88,7 +343,20 @@ func (s *EthService) handleRequest(w http.ResponseWriter, r *http.Request) {
 			err = tx.UnmarshalBinary(bytesTx)
 			if err != nil {
 				log.Error("Failed to unmarshal transaction data: ", err.Error())
-				writeJSONRPCError(w, req.ID, -32602, "invalid params")
+				writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", "failed to unmarshal transaction data: "+err.Error())
+				return
+			}
+
+			tx.Deadline, err = deadlineOption(req.Params, time.Now(), s.maxDeadline)
+			if err != nil {
+				log.Error(err.Error())
+				writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
+				return
+			}
+			tx.Label, err = labelOption(req.Params)
+			if err != nil {
+				log.Error(err.Error())
+				writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
 				return
 			}
 
@@ -97,15 +365,22 @@ func (s *EthService) handleRequest(w http.ResponseWriter, r *http.Request) {
 			err = s.EthClient.StoreTransaction(tx)
 			if err != nil {
 				log.Error(err.Error())
-				writeJSONRPCError(w, req.ID, -32000, err.Error())
+				s.setRetryAfter(w, err)
+				writeJSONRPCError(w, req.ID, storeErrorCode(err), err.Error())
 				return
 			}
 			// Return transaction hash.
 			res.Result = tx.Hash().String()
+			if s.queuedGasInfo {
+				res.Result = s.queuedTransaction(&tx)
+			}
+			if idempotencyKey != "" && s.idempotency != nil {
+				s.idempotency.put(idempotencyKey, res.Result)
+			}
 			} else {
 				// No params receiverd
 				log.Error("Failed to retrieve raw transaction")
-				writeJSONRPCError(w, req.ID, -32602, "invalid parameters: not enough params to decode")
+				writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
 				return
 			}
 
@@ -123,7 +398,7 @@ func (s *EthService) handleRequest(w http.ResponseWriter, r *http.Request) {
 			err = isValidTxHash(req.Params[0])
 			if  err != nil {
 				log.Error(err.Error())
-				writeJSONRPCError(w, req.ID, -32602, "invalid params")
+				writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
 				return
 			}
 
@@ -131,7 +406,7 @@ func (s *EthService) handleRequest(w http.ResponseWriter, r *http.Request) {
 			err := s.EthClient.CancelTransaction(req.Params[0].(string))
 			if err != nil {
 				log.Error(err.Error())
-				writeJSONRPCError(w, req.ID, -32000, err.Error())
+				writeJSONRPCError(w, req.ID, codeServerError, err.Error())
 				return
 			}
 			// Return message as a result.
@@ -139,22 +414,391 @@ func (s *EthService) handleRequest(w http.ResponseWriter, r *http.Request) {
 			} else {
 				// No params receiverd
 				log.Error("Failed to retrieve transaction hash")
-				writeJSONRPCError(w, req.ID, -32602, "invalid parameters: not enough params to decode")
+				writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
 				return
 			}
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(res)
 		break
+	case "status_transitions":
+		writeJSONRPCResult(w, req.ID, s.EthClient.StatusTransitions())
+	case "get_transaction_status":
+		s.handleGetTransactionStatus(w, req)
+	case "get_transaction_statuses":
+		s.handleGetTransactionStatuses(w, req)
+	case "broadcast_reason":
+		s.handleBroadcastReason(w, r, req)
+	case "cancel_if_stored":
+		s.handleCancelIfStored(w, req)
+	case "cancel_by_nonce":
+		s.handleCancelByNonce(w, req)
+	case "next_nonce":
+		s.handleNextNonce(w, r, req)
+	case "cancelable_transactions":
+		s.handleCancelableTransactions(w, req)
+	case "eligible_transactions":
+		hashes, err := s.EthClient.EligibleTransactions()
+		if err != nil {
+			log.Error(err.Error())
+			writeJSONRPCError(w, req.ID, codeServerError, err.Error())
+			return
+		}
+		writeJSONRPCResult(w, req.ID, orEmpty(hashes))
+	case "list_transactions":
+		s.handleListTransactions(w, req)
+	case "transactions_by_status":
+		s.handleTransactionsByStatus(w, req)
+	case "eth_getTransactionByHash":
+		s.handleGetTransactionByHash(w, r, req, bodyReader)
+	case "gas_stats":
+		writeJSONRPCResult(w, req.ID, s.EthClient.GasStats())
+	case "subscriber_stats":
+		writeJSONRPCResult(w, req.ID, s.EthClient.SubscriberStats())
+	case "gas_history":
+		history := s.EthClient.GasHistory()
+		if history == nil {
+			history = []types.GasObservation{}
+		}
+		writeJSONRPCResult(w, req.ID, history)
+	case "gas_saved":
+		writeJSONRPCResult(w, req.ID, (*hexutil.Big)(s.EthClient.GasSaved()))
+	case "server_stats":
+		writeJSONRPCResult(w, req.ID, s.serverStats())
+	case "send_raw_transactions":
+		s.handleSendRawTransactions(w, req)
+	case "refresh_gas_price":
+		if !s.authorizeAdmin(w, r, req) {
+			return
+		}
+		s.handleRefreshGasPrice(w, r, req)
+	case "set_log_level":
+		if !s.authorizeAdmin(w, r, req) {
+			return
+		}
+		s.handleSetLogLevel(w, req)
+	case "set_status":
+		if !s.authorizeAdmin(w, r, req) {
+			return
+		}
+		s.handleSetStatus(w, req)
+	case "test_broadcast":
+		if !s.authorizeAdmin(w, r, req) {
+			return
+		}
+		s.handleTestBroadcast(w, r, req)
 		default:
-			s.proxyToRPCNode(w, r, bodyReader)
+			if s.proxyDisabled {
+				log.Error("Method not found: ", req.Method)
+				writeJSONRPCError(w, req.ID, codeMethodNotFound, "method not found")
+				return
+			}
+			s.proxyToRPCNode(w, r, req, bodyReader)
 
 		}
 	}
 	
 
-// proxyToRPCNode is used to forward requests that are not handled by the EthService to the Ethereum RPC node.	
-func (s *EthService) proxyToRPCNode(w http.ResponseWriter, r *http.Request,body io.Reader) {
-	resp, err := s.EthClient.SendRequest(r.Context(), body, r.Header)
+// handleGetTransactionStatus returns the status of a stored transaction.
+// The raw hex is only included when the optional second param is true, to keep the response small by default.
+func (s *EthService) handleGetTransactionStatus(w http.ResponseWriter, req types.JSONRPCRequest) {
+	hash, ok := txHashParam(w, req)
+	if !ok {
+		return
+	}
+
+	includeRawHex := false
+	if len(req.Params) > 1 {
+		includeRawHex, ok = req.Params[1].(bool)
+		if !ok {
+			log.Error("the include raw hex param is not a boolean")
+			writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+			return
+		}
+	}
+
+	verbose := false
+	if len(req.Params) > 2 {
+		verbose, ok = req.Params[2].(bool)
+		if !ok {
+			log.Error("the verbose param is not a boolean")
+			writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+			return
+		}
+	}
+
+	view, err := s.EthClient.GetTransaction(hash)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeServerError, err.Error())
+		return
+	}
+	if !includeRawHex {
+		view.RawHex = ""
+	}
+	if !verbose {
+		view.Attempts = nil
+	}
+	writeJSONRPCResult(w, req.ID, view)
+}
+
+// handleGetTransactionStatuses returns the statuses of several transactions by hash, "not_found" for the unknown ones.
+// Each hash is validated, the first invalid one is reported by index.
+func (s *EthService) handleGetTransactionStatuses(w http.ResponseWriter, req types.JSONRPCRequest) {
+	if len(req.Params) == 0 {
+		log.Error("Failed to retrieve transaction hashes")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	params, ok := req.Params[0].([]interface{})
+	if !ok || len(params) == 0 {
+		log.Error("Transaction hashes param is not a non-empty array")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+
+	hashes := make([]string, len(params))
+	for i, param := range params {
+		if err := isValidTxHash(param); err != nil {
+			log.Error(err.Error())
+			writeJSONRPCErrorData(w, req.ID, codeInvalidParams, fmt.Sprintf("invalid params: hash %d", i), err.Error())
+			return
+		}
+		hashes[i] = param.(string)
+	}
+	writeJSONRPCResult(w, req.ID, s.EthClient.TransactionStatuses(hashes))
+}
+
+// handleCancelByNonce cancels the STORED transaction matching a sender address and nonce, for users who lost the transaction hash.
+func (s *EthService) handleCancelByNonce(w http.ResponseWriter, req types.JSONRPCRequest) {
+	if len(req.Params) < 2 {
+		log.Error("Failed to retrieve sender and nonce")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	from, err := parseAddress(req.Params[0])
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+	nonce, err := parseQuantity(req.Params[1])
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+
+	hash, err := s.EthClient.CancelTransactionByNonce(from, nonce)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeServerError, err.Error())
+		return
+	}
+	// Return the hash of the canceled transaction.
+	writeJSONRPCResult(w, req.ID, hash)
+}
+
+// handleBroadcastReason explains why the transaction whose hash is passed as first param hasn't been broadcast yet.
+func (s *EthService) handleBroadcastReason(w http.ResponseWriter, r *http.Request, req types.JSONRPCRequest) {
+	hash, ok := txHashParam(w, req)
+	if !ok {
+		return
+	}
+	reason, err := s.EthClient.BroadcastReason(r.Context(), hash)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, nodeErrorCode(err, codeServerError), err.Error())
+		return
+	}
+	writeJSONRPCResult(w, req.ID, reason)
+}
+
+// handleCancelIfStored cancels the transaction whose hash is passed as first param only if it's still STORED and not being broadcast.
+func (s *EthService) handleCancelIfStored(w http.ResponseWriter, req types.JSONRPCRequest) {
+	hash, ok := txHashParam(w, req)
+	if !ok {
+		return
+	}
+	err := s.EthClient.CancelIfStored(hash)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeServerError, err.Error())
+		return
+	}
+	writeJSONRPCResult(w, req.ID, "Transaction canceled")
+}
+
+// handleNextNonce returns the nonce the sender passed as first param should use next, as a hex quantity.
+func (s *EthService) handleNextNonce(w http.ResponseWriter, r *http.Request, req types.JSONRPCRequest) {
+	if len(req.Params) == 0 {
+		log.Error("Failed to retrieve sender")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	from, err := parseAddress(req.Params[0])
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+
+	nonce, err := s.EthClient.NextNonce(r.Context(), from)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, nodeErrorCode(err, codeServerError), err.Error())
+		return
+	}
+	writeJSONRPCResult(w, req.ID, hexutil.Uint64(nonce))
+}
+
+// handleCancelableTransactions returns the hashes of the transactions of the sender passed as first param that can still be canceled.
+func (s *EthService) handleCancelableTransactions(w http.ResponseWriter, req types.JSONRPCRequest) {
+	if len(req.Params) == 0 {
+		log.Error("Failed to retrieve sender")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	from, err := parseAddress(req.Params[0])
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+	writeJSONRPCResult(w, req.ID, orEmpty(s.EthClient.CancelableTransactions(from)))
+}
+
+// serverStats completes the stats of the client with the uptime and the request count of the server.
+func (s *EthService) serverStats() types.ServerStats {
+	stats := s.EthClient.Stats()
+	if !s.startedAt.IsZero() {
+		stats.UptimeSeconds = int64(time.Since(s.startedAt).Seconds())
+	}
+	stats.Requests = s.requests.Load()
+	return stats
+}
+
+// handleTransactionsByStatus returns the hashes of the stored transactions with the status named by the first param.
+func (s *EthService) handleTransactionsByStatus(w http.ResponseWriter, req types.JSONRPCRequest) {
+	if len(req.Params) == 0 {
+		log.Error("Failed to retrieve status")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	name, ok := req.Params[0].(string)
+	if !ok {
+		log.Error("Status param is not a string")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+	status, err := types.ParseTransactionStatus(name)
+	if err != nil {
+		log.Error(err.Error())
+		writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
+		return
+	}
+	writeJSONRPCResult(w, req.ID, orEmpty(s.EthClient.TransactionsByStatus(status)))
+}
+
+// handleListTransactions returns the views of the stored transactions, without their raw hex,
+// only the ones with the status passed as optional first param if any.
+func (s *EthService) handleListTransactions(w http.ResponseWriter, req types.JSONRPCRequest) {
+	filter := ""
+	if len(req.Params) > 0 {
+		name, ok := req.Params[0].(string)
+		if !ok {
+			log.Error("Status param is not a string")
+			writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+			return
+		}
+		status, err := types.ParseTransactionStatus(name)
+		if err != nil {
+			log.Error(err.Error())
+			writeJSONRPCErrorData(w, req.ID, codeInvalidParams, "invalid params", err.Error())
+			return
+		}
+		filter = status.String()
+	}
+
+	views := []types.TransactionView{}
+	for _, view := range s.EthClient.ListTransactions() {
+		if filter != "" && view.Status != filter {
+			continue
+		}
+		view.RawHex = ""
+		view.Attempts = nil
+		views = append(views, view)
+	}
+	writeJSONRPCResult(w, req.ID, views)
+}
+
+// handleGetTransactionByHash answers eth_getTransactionByHash for a queued transaction, which the node doesn't know yet.
+// The other hashes, including the transactions already broadcast, are proxied to the node.
+func (s *EthService) handleGetTransactionByHash(w http.ResponseWriter, r *http.Request, req types.JSONRPCRequest, body *bytes.Reader) {
+	if len(req.Params) > 0 && isValidTxHash(req.Params[0]) == nil {
+		view, err := s.EthClient.GetTransaction(req.Params[0].(string))
+		if err == nil && view.Status == types.STORED.String() {
+			result, err := queuedTransactionObject(view)
+			if err != nil {
+				log.Error(err.Error())
+				writeJSONRPCError(w, req.ID, codeInternalError, "internal error")
+				return
+			}
+			writeJSONRPCResult(w, req.ID, result)
+			return
+		}
+	}
+	if s.proxyDisabled {
+		log.Error("Method not found: ", req.Method)
+		writeJSONRPCError(w, req.ID, codeMethodNotFound, "method not found")
+		return
+	}
+	s.proxyToRPCNode(w, r, req, body)
+}
+
+// queuedTransactionObject builds the transaction object of a queued transaction like the node would for a pending one,
+// with a status member telling the client it's held by the server.
+func queuedTransactionObject(view types.TransactionView) (map[string]interface{}, error) {
+	tx, err := decodeRawTx(view.RawHex)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := tx.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	object := map[string]interface{}{}
+	err = json.Unmarshal(encoded, &object)
+	if err != nil {
+		return nil, err
+	}
+	from, err := txSender(&tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover the sender: %w", err)
+	}
+	object["from"] = from.Hex()
+	object["blockHash"] = nil
+	object["blockNumber"] = nil
+	object["transactionIndex"] = nil
+	object["status"] = view.Status
+	return object, nil
+}
+
+// txSender recovers the address that signed a transaction.
+func txSender(tx *types.Transaction) (common.Address, error) {
+	return ethTypes.Sender(ethTypes.LatestSignerForChainID(tx.ChainId()), &tx.Transaction)
+}
+
+// proxyToRPCNode is used to forward requests that are not handled by the EthService to the Ethereum RPC node.
+// The response is streamed as is, unless validateProxyResponses is set.
+func (s *EthService) proxyToRPCNode(w http.ResponseWriter, r *http.Request, req types.JSONRPCRequest, body *bytes.Reader) {
+	method := req.Method
+	start := time.Now()
+	defer func() {
+		if s.proxyLatency != nil {
+			s.proxyLatency.Observe(time.Since(start).Seconds())
+		}
+	}()
+	resp, err := s.sendWithRetry(r.Context(), method, body, r.Header)
 	if err != nil {
 		log.Error("Failed to send request: ", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -162,24 +806,147 @@ func (s *EthService) proxyToRPCNode(w http.ResponseWriter, r *http.Request,body
 	}
 	defer resp.Body.Close()
 
+	if s.validateProxyResponses {
+		s.writeValidatedResponse(w, req, resp)
+		return
+	}
+
 	for name, values := range resp.Header {
 		for _, value := range values {
 			w.Header().Add(name, value)
 		}
 	}
 
-	io.Copy(w, resp.Body)
+	_, err = io.Copy(w, resp.Body)
+	if err != nil {
+		// The client is likely gone, drain the rest of the body so the connection to the node can be reused.
+		log.WithField("method", method).Error("Failed to write the proxied response: ", err)
+		io.Copy(io.Discard, resp.Body)
+	}
+}
+
+// writeValidatedResponse writes a proxied response once checked to be a well-formed JSON-RPC response to req, re-encoded.
+// An invalid response is replaced by an internal error.
+func (s *EthService) writeValidatedResponse(w http.ResponseWriter, req types.JSONRPCRequest, resp *http.Response) {
+	body, err := io.ReadAll(resp.Body)
+	if err == nil {
+		body, err = validateProxyResponse(req, resp.StatusCode, body)
+	}
+	if err != nil {
+		log.WithField("method", req.Method).Error("Invalid upstream response: ", err)
+		writeJSONRPCErrorData(w, req.ID, codeInternalError, "invalid upstream response", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+// validatedResponse is the re-encoding of a validated proxied response, its result is kept raw so that a null one isn't dropped.
+type validatedResponse struct {
+	Jsonrpc string              `json:"jsonrpc"`
+	ID      interface{}         `json:"id"`
+	Result  json.RawMessage     `json:"result,omitempty"`
+	Error   *types.JSONRPCError `json:"error,omitempty"`
+}
+
+// validateProxyResponse checks a proxied response is a JSON-RPC 2.0 response to req, with either a result or an error,
+// and returns it re-encoded.
+func validateProxyResponse(req types.JSONRPCRequest, statusCode int, body []byte) ([]byte, error) {
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected http status code: %d", statusCode)
+	}
+	var members map[string]json.RawMessage
+	err := json.Unmarshal(body, &members)
+	if err != nil {
+		return nil, fmt.Errorf("malformed response: %w", err)
+	}
+	var resp types.JSONRPCResponse
+	err = json.Unmarshal(body, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("malformed response: %w", err)
+	}
+	if resp.Jsonrpc != "2.0" {
+		return nil, fmt.Errorf("unexpected jsonrpc version: %q", resp.Jsonrpc)
+	}
+	// The id is decoded like the request's to compare large integers exactly.
+	resp.ID, err = types.DecodeID(members["id"])
+	if err != nil {
+		return nil, fmt.Errorf("malformed response: %w", err)
+	}
+	if !reflect.DeepEqual(resp.ID, req.ID) {
+		return nil, fmt.Errorf("response id %v doesn't match the request id %v", resp.ID, req.ID)
+	}
+	result, hasResult := members["result"]
+	if hasResult == (resp.Error != nil) {
+		return nil, errors.New("the response must have either a result or an error")
+	}
+	if resp.Error != nil {
+		resp.Error.Message = sanitizeMessage(resp.Error.Message)
+	}
+	return json.Marshal(validatedResponse{Jsonrpc: resp.Jsonrpc, ID: resp.ID, Result: result, Error: resp.Error})
+}
+
+// sendWithRetry sends the request to the node, retrying transport errors and 5xx responses of idempotent read methods.
+// The backoff grows linearly with each attempt and waiting stops as soon as the request context is done.
+func (s *EthService) sendWithRetry(ctx context.Context, method string, body *bytes.Reader, headers http.Header) (*http.Response, error) {
+	attempts := 1
+	if idempotentMethods[method] {
+		attempts += s.proxyRetries
+	}
+
+	for attempt := 1; ; attempt++ {
+		body.Seek(0, io.SeekStart)
+		resp, err := s.EthClient.SendRequest(ctx, body, headers)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return resp, nil
+		}
+		if attempt >= attempts {
+			return resp, err
+		}
+
+		if err == nil {
+			err = fmt.Errorf("unexpected http status code: %v", resp.StatusCode)
+			resp.Body.Close()
+		}
+		log.WithField("method", method).Warnf("proxied request failed (attempt %d/%d): %v", attempt, attempts, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.proxyRetryBackoff * time.Duration(attempt)):
+		}
+	}
 }
 
 	
+// writeJSONRPCResult is a utility function to write JSON RPC success responses.
+func writeJSONRPCResult(w http.ResponseWriter, id interface{}, result interface{}) {
+	res := types.JSONRPCResponse{
+		Jsonrpc: "2.0",
+		ID:      id,
+		Result:  result,
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
 // writeJSONRPCError is a utility function to write JSON RPC error responses.
-func writeJSONRPCError(w http.ResponseWriter, id interface{}, code int, message string) {
+func writeJSONRPCError(w http.ResponseWriter, id interface{}, code errorCode, message string) {
+	writeJSONRPCErrorData(w, id, code, message, nil)
+}
+
+// writeJSONRPCErrorData writes a JSON-RPC error response carrying additional details in its data field, omitted when nil.
+func writeJSONRPCErrorData(w http.ResponseWriter, id interface{}, code errorCode, message string, data interface{}) {
+	if details, ok := data.(string); ok {
+		data = sanitizeMessage(details)
+	}
 	res := types.JSONRPCResponse{
 		Jsonrpc: "2.0",
 		ID:      id,
 		Error: &types.JSONRPCError{
-			Code: code,
-			Message: message,
+			Code: int(code),
+			Message: sanitizeMessage(message),
+			Data:    data,
 		},
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -193,13 +960,121 @@ func isValidHexRawTx(rawTx interface{}) error {
 		return fmt.Errorf("the param is not a string")
 	}
 
-	if  rawTxStr[:2] != "0x" {
+	if  !strings.HasPrefix(rawTxStr, "0x") {
 		return fmt.Errorf("invalid transaction hex")
 	}
 	// No need to decode since the hex will be decoded after this validation.
 	return nil
 }
 
+// base64Encoding is the optional second eth_sendRawTransaction param marking the raw transaction as base64 instead of hex.
+const base64Encoding = "base64"
+
+// isBase64Encoded reports whether eth_sendRawTransaction params mark the raw transaction as base64.
+func isBase64Encoded(params []interface{}) bool {
+	if len(params) < 2 {
+		return false
+	}
+	encoding, ok := params[1].(string)
+	return ok && strings.EqualFold(encoding, base64Encoding)
+}
+
+// deadlineOption returns the broadcast deadline set by the optional options object following the raw transaction in eth_sendRawTransaction params,
+// e.g. {"deadline": "10m"} or {"deadline": "2024-01-02T03:04:05Z"}. It returns the zero time when no deadline is set.
+// A deadline in the past would fail the transaction right away and one too far ahead would keep it queued forever,
+// so the deadline must be in the future and, when maxDeadline isn't 0, at most maxDeadline away.
+func deadlineOption(params []interface{}, now time.Time, maxDeadline time.Duration) (time.Time, error) {
+	for _, param := range params[1:] {
+		options, ok := param.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, ok := options["deadline"]
+		if !ok {
+			return time.Time{}, nil
+		}
+		deadlineStr, ok := value.(string)
+		if !ok {
+			return time.Time{}, fmt.Errorf("the deadline is not a string")
+		}
+		var deadline time.Time
+		if timeout, err := time.ParseDuration(deadlineStr); err == nil {
+			if timeout <= 0 {
+				return time.Time{}, fmt.Errorf("the deadline must be positive")
+			}
+			deadline = now.Add(timeout)
+		} else {
+			deadline, err = time.Parse(time.RFC3339, deadlineStr)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid deadline: %q is neither a duration nor an RFC 3339 time", deadlineStr)
+			}
+			if !deadline.After(now) {
+				return time.Time{}, fmt.Errorf("the deadline is in the past")
+			}
+		}
+		if maxDeadline > 0 && deadline.Sub(now) > maxDeadline {
+			return time.Time{}, fmt.Errorf("the deadline is more than %s away", maxDeadline)
+		}
+		return deadline, nil
+	}
+	return time.Time{}, nil
+}
+
+// maxLabelLength is the maximum number of characters of a transaction label.
+const maxLabelLength = 64
+
+// labelOption returns the label set by the optional options object following the raw transaction in eth_sendRawTransaction params,
+// e.g. {"label": "payroll batch"}. It returns an empty label when none is set.
+func labelOption(params []interface{}) (string, error) {
+	for _, param := range params[1:] {
+		options, ok := param.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, ok := options["label"]
+		if !ok {
+			return "", nil
+		}
+		label, ok := value.(string)
+		if !ok {
+			return "", fmt.Errorf("the label is not a string")
+		}
+		if utf8.RuneCountInString(label) > maxLabelLength {
+			return "", fmt.Errorf("the label is longer than %d characters", maxLabelLength)
+		}
+		return label, nil
+	}
+	return "", nil
+}
+
+// base64RawTxToHex decodes a base64 raw transaction and returns it as a 0x prefixed hex string.
+func base64RawTxToHex(rawTx interface{}) (string, error) {
+	rawTxStr, ok := rawTx.(string)
+	if !ok {
+		return "", fmt.Errorf("the param is not a string")
+	}
+	bytesTx, err := base64.StdEncoding.DecodeString(rawTxStr)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction base64: %w", err)
+	}
+	return hexutil.Encode(bytesTx), nil
+}
+
+// txHashParam returns the transaction hash passed as first param, writing the error response when it's missing or invalid.
+func txHashParam(w http.ResponseWriter, req types.JSONRPCRequest) (string, bool) {
+	if len(req.Params) == 0 {
+		log.Error("Failed to retrieve transaction hash")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return "", false
+	}
+	if err := isValidTxHash(req.Params[0]); err != nil {
+		log.Error(err.Error())
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return "", false
+	}
+	return req.Params[0].(string), true
+}
+
 // isValidTxHash validates if the provided transaction hash is valid
 func isValidTxHash(param interface{}) error {
 	hashStr, ok := param.(string)
@@ -220,17 +1095,169 @@ func isValidTxHash(param interface{}) error {
 }
 
 
+// parseAddress validates and converts an address param.
+func parseAddress(param interface{}) (common.Address, error) {
+	addressStr, ok := param.(string)
+	if !ok {
+		return common.Address{}, fmt.Errorf("the param is not a string")
+	}
+	if !common.IsHexAddress(addressStr) {
+		return common.Address{}, fmt.Errorf("invalid address")
+	}
+	return common.HexToAddress(addressStr), nil
+}
+
+// parseQuantity converts a quantity param given either as a hex string (e.g. "0x5") or as a JSON number.
+func parseQuantity(param interface{}) (uint64, error) {
+	switch value := param.(type) {
+	case string:
+		quantity, err := hexutil.DecodeUint64(value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid quantity: %w", err)
+		}
+		return quantity, nil
+	case float64:
+		if value < 0 || value != math.Trunc(value) {
+			return 0, fmt.Errorf("invalid quantity: %v", value)
+		}
+		return uint64(value), nil
+	}
+	return 0, fmt.Errorf("the param is not a quantity")
+}
+
 // Recover panic middleware.
-func recoverPanic(next http.HandlerFunc) http.HandlerFunc {
+// In dev mode the error data holds the panic value and its stack trace.
+func recoverPanic(next http.HandlerFunc, devMode bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Errorf("panic: %+v", err)
+				log.WithFields(log.Fields{
+					"http_method": r.Method,
+					"path":        r.URL.Path,
+					"rpc_method":  accessLogMethod(r.Context()),
+				}).Errorf("panic: %+v", err)
+				var data interface{}
+				if devMode {
+					data = panicDetails{Panic: fmt.Sprint(err), Stack: string(debug.Stack())}
+				}
 				// Id should be the request.ID but to retrieve it in this middleware would harm the performance.
-				writeJSONRPCError(w, nil, -32000, "server error")
+				writeJSONRPCErrorData(w, nil, codeServerError, "server error", data)
 			}
 		}()
 
 		next(w, r)
 	}
-}
\ No newline at end of file
+}
+
+// panicDetails is the error data of a recovered panic in dev mode.
+type panicDetails struct {
+	Panic string `json:"panic"`
+	Stack string `json:"stack"`
+}
+
+// queuedTransaction describes a queued transaction with the cached gas price, so the client sees how far off its broadcast is.
+func (s *EthService) queuedTransaction(tx *types.Transaction) types.QueuedTransaction {
+	queued := types.QueuedTransaction{
+		Hash: tx.Hash().String(),
+		// The monitor broadcasts the transaction once the gas price drops to the sum of its caps.
+		GasCap: (*hexutil.Big)(new(big.Int).Add(tx.GasFeeCap(), tx.GasTipCap())),
+	}
+	if gasPrice := s.EthClient.LastGasPrice(); gasPrice > 0 {
+		gasPriceInt, _ := big.NewFloat(gasPrice).Int(nil)
+		queued.GasPrice = (*hexutil.Big)(gasPriceInt)
+	}
+	return queued
+}
+
+// handleSendRawTransactions stores an array of raw transactions atomically and returns their hashes.
+// Every transaction is decoded before any is stored, so a malformed one leaves the store untouched.
+func (s *EthService) handleSendRawTransactions(w http.ResponseWriter, req types.JSONRPCRequest) {
+	if len(req.Params) == 0 {
+		log.Error("Failed to retrieve raw transactions")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid parameters: not enough params to decode")
+		return
+	}
+	rawTxs, ok := req.Params[0].([]interface{})
+	if !ok || len(rawTxs) == 0 {
+		log.Error("Raw transactions param is not a non-empty array")
+		writeJSONRPCError(w, req.ID, codeInvalidParams, "invalid params")
+		return
+	}
+
+	txs := make([]types.Transaction, len(rawTxs))
+	for i, rawTx := range rawTxs {
+		tx, err := decodeRawTx(rawTx)
+		if err != nil {
+			log.Error(err.Error())
+			writeJSONRPCErrorData(w, req.ID, codeInvalidParams, fmt.Sprintf("invalid params: transaction %d", i), err.Error())
+			return
+		}
+		txs[i] = tx
+	}
+
+	hashes, err := s.EthClient.StoreTransactions(txs)
+	if err != nil {
+		log.Error(err.Error())
+		s.setRetryAfter(w, err)
+		writeJSONRPCError(w, req.ID, storeErrorCode(err), err.Error())
+		return
+	}
+	writeJSONRPCResult(w, req.ID, orEmpty(hashes))
+}
+
+// orEmpty returns the hashes, or an empty list when nil, so that the list methods always answer a JSON array, never null.
+func orEmpty(hashes []string) []string {
+	if hashes == nil {
+		return []string{}
+	}
+	return hashes
+}
+
+// setRetryAfter tells a rate limited client when to retry, in whole seconds rounded up.
+func (s *EthService) setRetryAfter(w http.ResponseWriter, err error) {
+	var rateLimitErr *ethclient.SenderRateLimitError
+	if !s.retryAfterHeader || !errors.As(err, &rateLimitErr) {
+		return
+	}
+	s.setRetryAfterDelay(w, rateLimitErr.RetryAfter)
+}
+
+// setRetryAfterDelay sets the Retry-After header to the delay in whole seconds rounded up, at least 1, when the header is enabled.
+func (s *EthService) setRetryAfterDelay(w http.ResponseWriter, delay time.Duration) {
+	if !s.retryAfterHeader {
+		return
+	}
+	seconds := int(math.Ceil(delay.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+}
+
+// storeErrorCode returns the JSON-RPC error code of a failure to store a transaction.
+func storeErrorCode(err error) errorCode {
+	if errors.Is(err, ethclient.ErrSenderRateLimited) {
+		return codeLimitExceeded
+	}
+	return nodeErrorCode(err, codeServerError)
+}
+
+// decodeRawTx decodes a raw transaction hex param into a transaction keeping its raw hex.
+func decodeRawTx(param interface{}) (types.Transaction, error) {
+	err := isValidHexRawTx(param)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	rawHex := param.(string)
+	bytesTx, err := hex.DecodeString(rawHex[2:])
+	if err != nil {
+		return types.Transaction{}, fmt.Errorf("failed to decode transaction data: %w", err)
+	}
+	tx := types.Transaction{}
+	err = tx.UnmarshalBinary(bytesTx)
+	if err != nil {
+		return types.Transaction{}, fmt.Errorf("failed to unmarshal transaction data: %w", err)
+	}
+	tx.RawHex = rawHex
+	return tx, nil
+}
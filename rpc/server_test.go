@@ -3,16 +3,26 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/safwentrabelsi/tx-json-rpc-server/ethclient"
 	"github.com/safwentrabelsi/tx-json-rpc-server/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,6 +34,7 @@ const (
 	invalidTransactionRawHex = "0x3e3598fb8aabc3733686dd0a7a84ea35e25a34d959a68b9aeb1f5c5f7ab5877a"
 	validTransactionHash = "0x3e3598fb8aabc3733686dd0a7a84ea35e25a34d959a68b9aeb1f5c5f7ab5877a"
 	notFoundTransactionHash = "0xae2f861e03fc34b5a7960c43bfc57ff2d847328ac9bd2422ee27bfdbe73c8719"
+	senderAddress = "0x8d7526216e3c4294345ecf45ad57f9aebacfb0c4"
 )
 
 // Mock for the EthTransactionService interface
@@ -45,11 +56,183 @@ func (m *mockEthService) CancelTransaction(hash string) error {
 	return nil
 }
 
+func (m *mockEthService) StatusTransitions() map[string][]string {
+	return map[string][]string{
+		"STORED":   {"CANCELED", "SPEDUP", "FAILED", "BROADCASTED"},
+		"CANCELED": {"SPEDUP"},
+	}
+}
+
+func (m *mockEthService) GetTransaction(hash string) (types.TransactionView, error) {
+	if hash != validTransactionHash {
+		return types.TransactionView{}, errors.New("transaction not found")
+	}
+	return types.TransactionView{Hash: hash, Status: "STORED", RawHex: validTransactionRawHex, Attempts: []types.BroadcastAttempt{
+		{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), GasPrice: (*hexutil.Big)(big.NewInt(5)), Outcome: types.AttemptRejected, Error: "txpool is full"},
+	}}, nil
+}
+
+func (m *mockEthService) TransactionStatuses(hashes []string) map[string]string {
+	statuses := make(map[string]string, len(hashes))
+	for _, hash := range hashes {
+		statuses[hash] = "not_found"
+		if hash == validTransactionHash {
+			statuses[hash] = "STORED"
+		}
+	}
+	return statuses
+}
+
+func (m *mockEthService) CancelTransactionByNonce(from common.Address, nonce uint64) (string, error) {
+	if from != common.HexToAddress(senderAddress) || nonce != 5 {
+		return "", errors.New("transaction not found")
+	}
+	return validTransactionHash, nil
+}
+
+func (m *mockEthService) EligibleTransactions() ([]string, error) {
+	return []string{validTransactionHash}, nil
+}
+
+func (m *mockEthService) LastGasPrice() float64 {
+	return 1000000000
+}
+
+func (m *mockEthService) SubscribeStatusChanges() (<-chan types.StatusChange, func()) {
+	events := make(chan types.StatusChange)
+	return events, func() {}
+}
+
+func (m *mockEthService) StoreTransactions(txs []types.Transaction) ([]string, error) {
+	hashes := make([]string, len(txs))
+	for i, tx := range txs {
+		if err := m.StoreTransaction(tx); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		hashes[i] = tx.Hash().String()
+	}
+	return hashes, nil
+}
+
+func (m *mockEthService) GasStats() types.GasStats {
+	return types.GasStats{Stored: 2, MinGasCap: (*hexutil.Big)(big.NewInt(10)), MaxGasCap: (*hexutil.Big)(big.NewInt(30)), AvgGasCap: (*hexutil.Big)(big.NewInt(20))}
+}
+
+func (m *mockEthService) SubscribeTransaction(hash string) (<-chan types.StatusChange, func(), error) {
+	return nil, nil, errors.New("transaction not found")
+}
+
+func (m *mockEthService) RefreshGasPrice(ctx context.Context) (float64, error) {
+	return 2000000000, nil
+}
+
+func (m *mockEthService) TransactionsByStatus(status types.TransactionStatus) []string {
+	if status != types.STORED {
+		return []string{}
+	}
+	return []string{validTransactionHash}
+}
+
+func (m *mockEthService) GasHistory() []types.GasObservation {
+	return []types.GasObservation{{GasPrice: (*hexutil.Big)(big.NewInt(20)), Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}}
+}
+
+func (m *mockEthService) BroadcastReason(ctx context.Context, hash string) (string, error) {
+	if hash == notFoundTransactionHash {
+		return "", errors.New("transaction not found")
+	}
+	return "gas price too high (cap 3982525096 < price 4000000000)", nil
+}
+
+func (m *mockEthService) ListTransactions() []types.TransactionView {
+	nonce := hexutil.Uint64(3)
+	return []types.TransactionView{
+		{Hash: validTransactionHash, Status: "STORED", RawHex: validTransactionRawHex, SenderPosition: 1, QueueDepth: 1, From: senderAddress,
+			Nonce: &nonce, GasFeeCap: (*hexutil.Big)(big.NewInt(30)), GasTipCap: (*hexutil.Big)(big.NewInt(2))},
+		{Hash: notFoundTransactionHash, Status: "FAILED", Reason: "evicted", QueueDepth: 1},
+	}
+}
+
+func (m *mockEthService) CancelIfStored(hash string) error {
+	switch hash {
+	case notFoundTransactionHash:
+		return errors.New("transaction not found")
+	case validTransactionHash:
+		return ethclient.ErrAlreadyBroadcast
+	}
+	return nil
+}
+
+func (m *mockEthService) GasSaved() *big.Int {
+	return big.NewInt(42000)
+}
+
+func (m *mockEthService) SubscriberStats() types.SubscriberStats {
+	return types.SubscriberStats{Subscribers: 2, Hashes: []string{validTransactionHash}, Dropped: 3}
+}
+
+func (m *mockEthService) NextNonce(ctx context.Context, from common.Address) (uint64, error) {
+	if from != common.HexToAddress(senderAddress) {
+		return 0, errors.New("failed to get the next nonce: unknown sender")
+	}
+	return 6, nil
+}
+
+func (m *mockEthService) BlockNumber(ctx context.Context) (uint64, error) {
+	return 16, nil
+}
+
+func (m *mockEthService) CancelableTransactions(from common.Address) []string {
+	if from != common.HexToAddress(senderAddress) {
+		return []string{}
+	}
+	return []string{validTransactionHash}
+}
+
+func (m *mockEthService) SetTransactionStatus(hash string, status types.TransactionStatus) error {
+	if hash != validTransactionHash {
+		return errors.New("transaction not found")
+	}
+	// The mocked transaction is STORED.
+	if status == types.STORED || status == types.SPEDUP {
+		return fmt.Errorf("invalid status transition from STORED to %s for transaction: %s", status, hash)
+	}
+	return nil
+}
+
+func (m *mockEthService) TestBroadcast(ctx context.Context, hash string) (types.TestBroadcastResult, error) {
+	if hash != validTransactionHash {
+		return types.TestBroadcastResult{}, errors.New("transaction not found")
+	}
+	return types.TestBroadcastResult{Error: "nonce too low", NodeError: true, Permanent: true}, nil
+}
+
+func (m *mockEthService) Stats() types.ServerStats {
+	return types.ServerStats{Queue: map[string]int{"STORED": 2}, GasPrice: (*hexutil.Big)(big.NewInt(1000000000)), UpstreamErrors: 1, Checks: 5}
+}
+
+func (m *mockEthService) StatusTotals() map[string]uint64 {
+	return map[string]uint64{"STORED": 3, "CANCELED": 0, "SPEDUP": 0, "FAILED": 1, "BROADCASTED": 1, "EXPIRED": 0}
+}
+
+func (m *mockEthService) Transactions() []types.Transaction {
+	tx, _ := decodeRawTx(validTransactionRawHex)
+	tx.StoredAt = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []types.Transaction{tx}
+}
+
 func (m *mockEthService) SendRequest(ctx context.Context, body io.Reader, headers http.Header) (*http.Response, error) {
-	// Emulte the response of eth_chainId which isn't handled by this proxy
+	// Emulte the response of eth_chainId which isn't handled by this proxy, echoing the request id like a node.
+	id := json.RawMessage("1")
+	var req struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if body != nil && json.NewDecoder(body).Decode(&req) == nil && len(req.ID) > 0 {
+		id = req.ID
+	}
 	return &http.Response{
         StatusCode: http.StatusOK,
-        Body: io.NopCloser(bytes.NewBufferString(`{"jsonrpc": "2.0","id": 1,"result": "0x1"}`)),
+        Body: io.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{"jsonrpc": "2.0","id": %s,"result": "0x1"}`, id))),
 	},nil
 }
 
@@ -77,6 +260,54 @@ func TestHandleRequest(t *testing.T) {
 		require.Nil(t, resp.Error)
 
 	})
+	t.Run("when the gas information is enabled, return it with the hash of the queued transaction", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}, queuedGasInfo: true}
+		validRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`,validTransactionRawHex)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(validRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		result, ok := resp.Result.(map[string]interface{})
+		require.True(t, ok)
+		require.NotEmpty(t, result["hash"])
+		require.Equal(t, "0x3b9aca00", result["gasPrice"])
+		require.Contains(t, result, "gasCap")
+	})
+	t.Run("when the raw transaction is marked as base64, store it as hex", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient}
+		rawTx, err := hexutil.Decode(validTransactionRawHex)
+		require.NoError(t, err)
+		validRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s","base64"]}`, base64.StdEncoding.EncodeToString(rawTx))
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(validRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, []string{validTransactionRawHex}, ethClient.stored)
+	})
+	t.Run("when the raw transaction is marked as base64 but isn't, return an error", func(t *testing.T) {
+		invalidRequest := `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["not base64!","base64"]}`
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(invalidRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+	t.Run("when the deadline option is invalid, return an error", func(t *testing.T) {
+		invalidRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s",{"deadline":"soon"}]}`, validTransactionRawHex)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(invalidRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+		require.Equal(t, `invalid deadline: "soon" is neither a duration nor an RFC 3339 time`, resp.Error.Data)
+	})
 	t.Run("when receiving a JSON request with empty params, return an error", func(t *testing.T) {
 		invalidRequest := `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":[]}`
 
@@ -110,6 +341,21 @@ func TestHandleRequest(t *testing.T) {
 
 	})
 
+	t.Run("when the transaction can't be decoded, return the decode error in the error data", func(t *testing.T) {
+		invalidRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`, invalidTransactionRawHex)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(invalidRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, "invalid params", resp.Error.Message)
+		data, ok := resp.Error.Data.(string)
+		require.True(t, ok)
+		require.Contains(t, data, "failed to unmarshal transaction data")
+		// The transaction is a 32 bytes hash, whose first byte isn't a known transaction type.
+		require.Contains(t, data, "transaction type not supported")
+	})
+
 	t.Run("when receiving a valid request but the transaction is invalid, return an error", func(t *testing.T) {
 		invalidRequest := `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["0xInvalid"]}`
 
@@ -186,6 +432,125 @@ func TestHandleRequest(t *testing.T) {
 		require.Equal(t,resp.Error.Code, -32000 )
 	})
 
+	t.Run("when receiving a status_transitions request, return the allowed transitions", func(t *testing.T) {
+		validRequest := `{"jsonrpc":"2.0","id":1,"method":"status_transitions","params":[]}`
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(validRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		transitions := resp.Result.(map[string]interface{})
+		require.ElementsMatch(t, []interface{}{"CANCELED", "SPEDUP", "FAILED", "BROADCASTED"}, transitions["STORED"])
+	})
+
+	t.Run("when receiving a get_transaction_status request, return the status without the raw hex", func(t *testing.T) {
+		validRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_status","params":["%s"]}`, validTransactionHash)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(validRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		result := resp.Result.(map[string]interface{})
+		require.Equal(t, "STORED", result["status"])
+		require.NotContains(t, result, "rawHex")
+	})
+
+	t.Run("when receiving a get_transaction_status request asking for the raw hex, include it", func(t *testing.T) {
+		validRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_status","params":["%s",true]}`, validTransactionHash)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(validRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, validTransactionRawHex, resp.Result.(map[string]interface{})["rawHex"])
+	})
+
+	t.Run("when receiving a verbose get_transaction_status request, include the broadcast attempts", func(t *testing.T) {
+		request := func(params string) map[string]interface{} {
+			body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_status","params":["%s",%s]}`, validTransactionHash, params)
+			rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(body))
+			resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+			require.Nil(t, resp.Error)
+			return resp.Result.(map[string]interface{})
+		}
+
+		result := request("false,true")
+		require.NotContains(t, result, "rawHex")
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"time": "2024-01-02T03:04:05Z", "gasPrice": "0x5", "outcome": "rejected", "error": "txpool is full"},
+		}, result["attempts"])
+
+		require.NotContains(t, request("true"), "attempts")
+		require.NotContains(t, request("true,false"), "attempts")
+	})
+
+	t.Run("when receiving a get_transaction_status request with a non boolean raw hex flag, return an error", func(t *testing.T) {
+		invalidRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_status","params":["%s","yes"]}`, validTransactionHash)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(invalidRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+
+	t.Run("when receiving a get_transaction_status request for an unknown transaction, return an error", func(t *testing.T) {
+		invalidRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_status","params":["%s"]}`, notFoundTransactionHash)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(invalidRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Contains(t, resp.Error.Message, "transaction not found")
+		require.Equal(t, -32000, resp.Error.Code)
+	})
+
+	t.Run("when receiving a cancel_by_nonce request matching a stored transaction, return its hash", func(t *testing.T) {
+		validRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"cancel_by_nonce","params":["%s","0x5"]}`, senderAddress)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(validRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, validTransactionHash, resp.Result)
+	})
+
+	t.Run("when receiving a cancel_by_nonce request matching no transaction, return an error", func(t *testing.T) {
+		invalidRequest := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"cancel_by_nonce","params":["%s",6]}`, senderAddress)
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(invalidRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Contains(t, resp.Error.Message, "transaction not found")
+		require.Equal(t, -32000, resp.Error.Code)
+	})
+
+	t.Run("when receiving a cancel_by_nonce request with an invalid sender, return an error", func(t *testing.T) {
+		invalidRequest := `{"jsonrpc":"2.0","id":1,"method":"cancel_by_nonce","params":["0xInvalid","0x5"]}`
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(invalidRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+
+	t.Run("when receiving an eligible_transactions request, return the eligible hashes", func(t *testing.T) {
+		validRequest := `{"jsonrpc":"2.0","id":1,"method":"eligible_transactions","params":[]}`
+
+		handler := http.HandlerFunc(service.handleRequest)
+		rr := makeRequest(t, handler, "POST", "/", strings.NewReader(validRequest))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, []interface{}{validTransactionHash}, resp.Result)
+	})
+
 	// Tests the default case and the proxyToRPCNode at once.
 	t.Run("when receiving a method that is not handled by the server, process it correctly", func(t *testing.T) {
 		unhandledMethodRequest := `{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`
@@ -200,91 +565,983 @@ func TestHandleRequest(t *testing.T) {
 
 }
 
-// Test raw hex transaction validation.
-func TestIsValidHexRawTx(t *testing.T) {
-	tests := []struct {
-		name    string
-		rawTx   interface{}
-		wantErr bool
-	}{
-		{
-			name:    "Valid hex string",
-			rawTx:   validTransactionRawHex,
-			wantErr: false,
-		},
-		{
-			name:    "Invalid hex string (missing 0x prefix)",
-			rawTx:   "abcdef",
-			wantErr: true,
-		},
-		{
-			name:    "Invalid hex string (not a string)",
-			rawTx:   123,
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if err := isValidHexRawTx(tt.rawTx); (err != nil) != tt.wantErr {
-				t.Errorf("isValidHexRawTx() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
+// recordingEthService records whether a request was queued or forwarded to the node.
+type recordingEthService struct {
+	mockEthService
+	stored  []string
+	labels  []string
+	proxied int
 }
 
-// Test transaction hash validation.
-func TestIsValidTxHash(t *testing.T) {
-	tests := []struct {
-		name    string
-		param   interface{}
-		wantErr bool
-	}{
-		{
-			name:    "Valid transaction hash",
-			param:   validTransactionHash,
-			wantErr: false,
-		},
-		{
-			name:    "Invalid transaction hash (missing 0x prefix)",
-			param:   "a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1",
-			wantErr: true,
-		},
-		{
-			name:    "Invalid transaction hash (not a string)",
-			param:   123,
-			wantErr: true,
-		},
-		{
-			name:    "Invalid transaction hash (incorrect length)",
-			param:   validTransactionRawHex+"1",
-			wantErr: true,
-		},
-		{
-			name:    "Invalid transaction hash (non-hex content)",
-			param:   "0xg0h1i2j3k4l5m6n7o8p9q0r1s2t3u4v5w6x7y8z9a0b1c2d3e4f5g6h7i8j9",
-			wantErr: true,
-		},
-	}
+func (m *recordingEthService) StoreTransaction(tx types.Transaction) error {
+	m.stored = append(m.stored, tx.RawHex)
+	m.labels = append(m.labels, tx.Label)
+	return nil
+}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			if err := isValidTxHash(tt.param); (err != nil) != tt.wantErr {
-				t.Errorf("isValidTxHash() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
+func (m *recordingEthService) StoreTransactions(txs []types.Transaction) ([]string, error) {
+	hashes := make([]string, len(txs))
+	for i, tx := range txs {
+		m.stored = append(m.stored, tx.RawHex)
+		hashes[i] = tx.Hash().String()
 	}
+	return hashes, nil
+}
+func (m *recordingEthService) SendRequest(ctx context.Context, body io.Reader, headers http.Header) (*http.Response, error) {
+	m.proxied++
+	return m.mockEthService.SendRequest(ctx, body, headers)
 }
 
-// Test panic recovery middleware.
-func TestRecoverPanic(t *testing.T) {
+// Test the per-route behavior of the router.
+func TestRoutes(t *testing.T) {
+	request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`, validTransactionRawHex)
 
-	// Create a handler that panics
-	handler := func(w http.ResponseWriter, r *http.Request) {
+	t.Run("the queued route stores the transaction", func(t *testing.T) {
+		ec := &recordingEthService{}
+		router := newRouter(&EthService{EthClient: ec})
+
+		rr := makeRequest(t, router.ServeHTTP, "POST", "/queued", strings.NewReader(request))
+
+		parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, []string{validTransactionRawHex}, ec.stored)
+		require.Equal(t, 0, ec.proxied)
+	})
+
+	t.Run("the passthrough route forwards the same transaction to the node", func(t *testing.T) {
+		ec := &recordingEthService{}
+		router := newRouter(&EthService{EthClient: ec})
+
+		rr := makeRequest(t, router.ServeHTTP, "POST", "/passthrough", strings.NewReader(request))
+
+		parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Empty(t, ec.stored)
+		require.Equal(t, 1, ec.proxied)
+	})
+
+	t.Run("the default route queues transactions", func(t *testing.T) {
+		ec := &recordingEthService{}
+		router := newRouter(&EthService{EthClient: ec})
+
+		makeRequest(t, router.ServeHTTP, "POST", "/", strings.NewReader(request))
+
+		require.Len(t, ec.stored, 1)
+	})
+}
+
+// drainTrackingBody is a proxied response body recording whether it was read to the end and closed.
+type drainTrackingBody struct {
+	io.Reader
+	drained bool
+	closed  bool
+}
+
+func (b *drainTrackingBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err == io.EOF {
+		b.drained = true
+	}
+	return n, err
+}
+
+func (b *drainTrackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+// largeResponseEthService answers the proxied requests with a large body.
+type largeResponseEthService struct {
+	mockEthService
+	body *drainTrackingBody
+}
+
+func (m *largeResponseEthService) SendRequest(ctx context.Context, body io.Reader, headers http.Header) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: m.body}, nil
+}
+
+// failingWriter is a ResponseWriter whose writes fail once the client is gone, after the first one.
+type failingWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("broken pipe")
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+// Test a proxied response cut off by the client is drained.
+func TestProxyPartialWrite(t *testing.T) {
+	ethClient := &largeResponseEthService{body: &drainTrackingBody{Reader: strings.NewReader(strings.Repeat("x", 256*1024))}}
+	service := &EthService{EthClient: ethClient}
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_getLogs","params":[]}`))
+	w := &failingWriter{ResponseRecorder: httptest.NewRecorder()}
+
+	service.handleRequest(w, req)
+
+	require.Greater(t, w.writes, 1)
+	require.True(t, ethClient.body.drained)
+	require.True(t, ethClient.body.closed)
+}
+
+// fixedResponseEthService answers the proxied requests with a fixed body.
+type fixedResponseEthService struct {
+	mockEthService
+	body string
+}
+
+func (m *fixedResponseEthService) SendRequest(ctx context.Context, body io.Reader, headers http.Header) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(m.body))}, nil
+}
+
+// Test the validation of the proxied responses.
+func TestValidateProxyResponses(t *testing.T) {
+	request := `{"jsonrpc":"2.0","id":7,"method":"eth_getTransactionByHash","params":["0x01"]}`
+	proxy := func(upstream string) *httptest.ResponseRecorder {
+		service := &EthService{EthClient: &fixedResponseEthService{body: upstream}, validateProxyResponses: true}
+		return makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+	}
+
+	t.Run("a valid response is passed on, null result included", func(t *testing.T) {
+		rr := proxy(`{"jsonrpc": "2.0", "id": 7, "result": null}`)
+
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.JSONEq(t, `{"jsonrpc":"2.0","id":7,"result":null}`, rr.Body.String())
+	})
+
+	t.Run("a node error is passed on", func(t *testing.T) {
+		rr := proxy(`{"jsonrpc":"2.0","id":7,"error":{"code":-32000,"message":"header not found"}}`)
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(7), "2.0")
+		require.Equal(t, -32000, resp.Error.Code)
+		require.Equal(t, "header not found", resp.Error.Message)
+	})
+
+	tests := []struct {
+		name     string
+		upstream string
+		data     string
+	}{
+		{"a malformed response", `<html>Bad Gateway</html>`, "malformed response: invalid character '<' looking for beginning of value"},
+		{"a response to another request", `{"jsonrpc":"2.0","id":8,"result":"0x1"}`, "response id 8 doesn't match the request id 7"},
+		{"a response of another version", `{"jsonrpc":"1.0","id":7,"result":"0x1"}`, `unexpected jsonrpc version: "1.0"`},
+		{"a response without result", `{"jsonrpc":"2.0","id":7}`, "the response must have either a result or an error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" is replaced by an internal error", func(t *testing.T) {
+			rr := proxy(tt.upstream)
+
+			resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(7), "2.0")
+			require.Equal(t, -32603, resp.Error.Code)
+			require.Equal(t, "invalid upstream response", resp.Error.Message)
+			require.Equal(t, tt.data, resp.Error.Data)
+		})
+	}
+
+	t.Run("without validation, the response is streamed as is", func(t *testing.T) {
+		service := &EthService{EthClient: &fixedResponseEthService{body: `<html>Bad Gateway</html>`}}
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		require.Equal(t, `<html>Bad Gateway</html>`, rr.Body.String())
+	})
+}
+
+// flakyEthService fails the first proxied requests before answering like the node.
+type flakyEthService struct {
+	mockEthService
+	failures int
+	calls    int
+}
+
+func (m *flakyEthService) SendRequest(ctx context.Context, body io.Reader, headers http.Header) (*http.Response, error) {
+	m.calls++
+	if m.calls <= m.failures {
+		return nil, errors.New("connection reset by peer")
+	}
+	return m.mockEthService.SendRequest(ctx, body, headers)
+}
+
+// Test the retry of proxied requests.
+func TestProxyRetry(t *testing.T) {
+	t.Run("a read method succeeds on the second attempt", func(t *testing.T) {
+		ec := &flakyEthService{failures: 1}
+		service := &EthService{EthClient: ec, proxyRetries: 2, proxyRetryBackoff: time.Millisecond}
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x8d7526216e3c4294345ecf45ad57f9aebacfb0c4","latest"]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, 2, ec.calls)
+	})
+
+	t.Run("a write method isn't retried", func(t *testing.T) {
+		ec := &flakyEthService{failures: 1}
+		service := &EthService{EthClient: ec, proxyRetries: 2, proxyRetryBackoff: time.Millisecond}
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_sendTransaction","params":[]}`))
+
+		require.Equal(t, http.StatusInternalServerError, rr.Code)
+		require.Equal(t, 1, ec.calls)
+	})
+
+	t.Run("it stops retrying when the request context is done", func(t *testing.T) {
+		ec := &flakyEthService{failures: 2}
+		service := &EthService{EthClient: ec, proxyRetries: 2, proxyRetryBackoff: time.Hour}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := service.sendWithRetry(ctx, "eth_call", bytes.NewReader(nil), http.Header{})
+
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 1, ec.calls)
+	})
+}
+
+// Test raw hex transaction validation.
+func TestIsValidHexRawTx(t *testing.T) {
+	tests := []struct {
+		name    string
+		rawTx   interface{}
+		wantErr bool
+	}{
+		{
+			name:    "Valid hex string",
+			rawTx:   validTransactionRawHex,
+			wantErr: false,
+		},
+		{
+			name:    "Invalid hex string (missing 0x prefix)",
+			rawTx:   "abcdef",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid hex string (not a string)",
+			rawTx:   123,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := isValidHexRawTx(tt.rawTx); (err != nil) != tt.wantErr {
+				t.Errorf("isValidHexRawTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Test transaction hash validation.
+func TestIsValidTxHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   interface{}
+		wantErr bool
+	}{
+		{
+			name:    "Valid transaction hash",
+			param:   validTransactionHash,
+			wantErr: false,
+		},
+		{
+			name:    "Invalid transaction hash (missing 0x prefix)",
+			param:   "a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid transaction hash (not a string)",
+			param:   123,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid transaction hash (incorrect length)",
+			param:   validTransactionRawHex+"1",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid transaction hash (non-hex content)",
+			param:   "0xg0h1i2j3k4l5m6n7o8p9q0r1s2t3u4v5w6x7y8z9a0b1c2d3e4f5g6h7i8j9",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := isValidTxHash(tt.param); (err != nil) != tt.wantErr {
+				t.Errorf("isValidTxHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// rateLimitedEthService rejects every transaction as over the sender rate limit.
+type rateLimitedEthService struct {
+	mockEthService
+}
+
+func (m *rateLimitedEthService) StoreTransaction(tx types.Transaction) error {
+	return &ethclient.SenderRateLimitError{RetryAfter: 1500 * time.Millisecond}
+}
+
+// Test the error code of throttled submissions.
+func TestSenderRateLimited(t *testing.T) {
+	t.Run("when the sender is over its rate limit, return a limit exceeded error", func(t *testing.T) {
+		service := &EthService{EthClient: &rateLimitedEthService{}}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`, validTransactionRawHex)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32005, resp.Error.Code)
+		require.Contains(t, resp.Error.Message, "sender rate limit exceeded")
+	})
+
+	t.Run("when the sender is over its rate limit, tell it when to retry", func(t *testing.T) {
+		service := &EthService{EthClient: &rateLimitedEthService{}, retryAfterHeader: true}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`, validTransactionRawHex)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		require.Equal(t, "2", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("when the Retry-After header is disabled, don't set it", func(t *testing.T) {
+		service := &EthService{EthClient: &rateLimitedEthService{}}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s"]}`, validTransactionRawHex)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		require.Empty(t, rr.Header().Get("Retry-After"))
+	})
+}
+
+// Test unknown methods when proxying is disabled.
+func TestProxyDisabled(t *testing.T) {
+	t.Run("when proxying is disabled, answer an unknown method with method not found", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient, proxyDisabled: true}
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"eth_chainId","params":[]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32601, resp.Error.Code)
+		require.Equal(t, "method not found", resp.Error.Message)
+		require.Zero(t, ethClient.proxied)
+	})
+
+	t.Run("when proxying is disabled, still handle the custom methods", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}, proxyDisabled: true}
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"status_transitions","params":[]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+	})
+}
+
+// Test the gas_stats method.
+func TestHandleGasStats(t *testing.T) {
+	t.Run("return the gas statistics of the stored transactions", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"gas_stats","params":[]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, map[string]interface{}{
+			"stored":    float64(2),
+			"minGasCap": "0xa",
+			"maxGasCap": "0x1e",
+			"avgGasCap": "0x14",
+			"gasPrice":  nil,
+		}, resp.Result)
+	})
+}
+
+// Test binding an address already in use fails with a clear error.
+func TestListen(t *testing.T) {
+	first, err := listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer first.Close()
+	_, port, err := net.SplitHostPort(first.Addr().String())
+	require.NoError(t, err)
+
+	_, err = listen(first.Addr().String())
+	require.ErrorContains(t, err, fmt.Sprintf("port %s already in use", port))
+	require.ErrorIs(t, err, syscall.EADDRINUSE)
+}
+
+// Test serving on a listener created beforehand.
+func TestServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { served <- Serve(ctx, &mockEthService{}, listener) }()
+
+	resp, err := http.Post("http://"+listener.Addr().String(), "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"gas_saved","params":[]}`))
+	require.NoError(t, err)
+	var body types.JSONRPCResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	resp.Body.Close()
+	require.Equal(t, "0xa410", body.Result)
+
+	cancel()
+	require.NoError(t, <-served)
+	_, err = http.Post("http://"+listener.Addr().String(), "application/json", strings.NewReader(`{}`))
+	require.Error(t, err)
+}
+
+// Test the shutdown lets the in-flight requests finish, and gives up on them after the timeout.
+func TestServeUntilDone(t *testing.T) {
+	serve := func(timeout time.Duration) (started, release chan struct{}, responded chan error, stop func() error) {
+		started, release, responded = make(chan struct{}), make(chan struct{}), make(chan error, 1)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			w.Write([]byte("done"))
+		})}
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error, 1)
+		go func() { served <- serveUntilDone(ctx, server, listener, timeout) }()
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String())
+			if err == nil {
+				resp.Body.Close()
+			}
+			responded <- err
+		}()
+		return started, release, responded, func() error {
+			cancel()
+			return <-served
+		}
+	}
+
+	t.Run("an in-flight request finishes", func(t *testing.T) {
+		started, release, responded, stop := serve(5 * time.Second)
+		<-started
+		stopped := make(chan error, 1)
+		go func() { stopped <- stop() }()
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		require.NoError(t, <-responded)
+		require.NoError(t, <-stopped)
+	})
+
+	t.Run("a request still running after the timeout is dropped", func(t *testing.T) {
+		started, release, responded, stop := serve(50 * time.Millisecond)
+		defer close(release)
+		<-started
+		require.ErrorIs(t, stop(), context.DeadlineExceeded)
+		require.Error(t, <-responded)
+	})
+}
+
+// Test 64-bit integer ids are echoed back exactly, without the float64 rounding above 2^53.
+func TestLargeIntegerID(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	for _, id := range []string{"9007199254740993", "18446744073709551615", "-9223372036854775808"} {
+		t.Run(id, func(t *testing.T) {
+			rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":`+id+`,"method":"gas_saved","params":[]}`))
+			require.JSONEq(t, `{"jsonrpc":"2.0","id":`+id+`,"result":"0xa410"}`, rr.Body.String())
+			require.Contains(t, rr.Body.String(), `"id":`+id+`,`)
+
+			rr = makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`[{"jsonrpc":"2.0","id":`+id+`,"method":"gas_saved","params":[]}]`))
+			require.Contains(t, rr.Body.String(), `"id":`+id+`,`)
+		})
+	}
+}
+
+// emptyEthService is a mockEthService with an empty queue whose list methods return nil.
+type emptyEthService struct {
+	mockEthService
+}
+
+func (m *emptyEthService) ListTransactions() []types.TransactionView { return nil }
+
+func (m *emptyEthService) TransactionsByStatus(status types.TransactionStatus) []string { return nil }
+
+func (m *emptyEthService) EligibleTransactions() ([]string, error) { return nil, nil }
+
+func (m *emptyEthService) CancelableTransactions(from common.Address) []string { return nil }
+
+func (m *emptyEthService) GasHistory() []types.GasObservation { return nil }
+
+// Test the list methods answer an empty array, not null, when the queue is empty.
+func TestHandleListMethodsEmptyQueue(t *testing.T) {
+	service := &EthService{EthClient: &emptyEthService{}}
+	for method, params := range map[string]string{
+		"list_transactions":       `[]`,
+		"transactions_by_status":  `["STORED"]`,
+		"eligible_transactions":   `[]`,
+		"cancelable_transactions": fmt.Sprintf(`["%s"]`, senderAddress),
+		"gas_history":             `[]`,
+	} {
+		t.Run(method, func(t *testing.T) {
+			body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":%s}`, method, params)
+			rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(body))
+
+			require.Contains(t, rr.Body.String(), `"result":[]`)
+			resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+			require.Nil(t, resp.Error)
+			require.Equal(t, []interface{}{}, resp.Result)
+		})
+	}
+}
+
+// Test the list_transactions method.
+func TestHandleListTransactions(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+	request := func(params string) types.JSONRPCResponse {
+		body := `{"jsonrpc":"2.0","id":1,"method":"list_transactions","params":` + params + `}`
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(body))
+		return parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+	}
+	stored := map[string]interface{}{
+		"hash": validTransactionHash, "status": "STORED", "senderPosition": float64(1), "queueDepth": float64(1),
+		"from": senderAddress, "nonce": "0x3", "gasFeeCap": "0x1e", "gasTipCap": "0x2",
+	}
+
+	t.Run("every transaction is listed, without its raw hex", func(t *testing.T) {
+		resp := request(`[]`)
+		require.Nil(t, resp.Error)
+		require.Equal(t, []interface{}{
+			stored,
+			map[string]interface{}{"hash": notFoundTransactionHash, "status": "FAILED", "reason": "evicted", "queueDepth": float64(1)},
+		}, resp.Result)
+	})
+
+	t.Run("the transactions can be filtered by status", func(t *testing.T) {
+		resp := request(`["stored"]`)
+		require.Nil(t, resp.Error)
+		require.Equal(t, []interface{}{stored}, resp.Result)
+
+		resp = request(`["BROADCASTED"]`)
+		require.Nil(t, resp.Error)
+		require.Equal(t, []interface{}{}, resp.Result)
+	})
+
+	t.Run("an unknown status is rejected", func(t *testing.T) {
+		resp := request(`["PENDING"]`)
+		require.Equal(t, -32602, resp.Error.Code)
+		require.Equal(t, `unknown transaction status: "PENDING"`, resp.Error.Data)
+	})
+}
+
+// Test the broadcast_reason method.
+func TestHandleBroadcastReason(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+	request := func(hash string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"broadcast_reason","params":["%s"]}`, hash)
+		return makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(body))
+	}
+
+	resp := parseAndCheckResponse(t, request(validTransactionHash), http.StatusOK, float64(1), "2.0")
+	require.Nil(t, resp.Error)
+	require.Equal(t, "gas price too high (cap 3982525096 < price 4000000000)", resp.Result)
+
+	resp = parseAndCheckResponse(t, request(notFoundTransactionHash), http.StatusOK, float64(1), "2.0")
+	require.Equal(t, -32000, resp.Error.Code)
+	require.Equal(t, "transaction not found", resp.Error.Message)
+}
+
+// Test the cancel_if_stored method.
+func TestHandleCancelIfStored(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+	request := func(hash string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"cancel_if_stored","params":["%s"]}`, hash)
+		return makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(body))
+	}
+
+	t.Run("a STORED transaction is canceled", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, request(common.Hash{}.String()), http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, "Transaction canceled", resp.Result)
+	})
+
+	t.Run("a transaction broadcast in between isn't", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, request(validTransactionHash), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32000, resp.Error.Code)
+		require.Equal(t, "already broadcast", resp.Error.Message)
+	})
+
+	t.Run("an invalid hash is rejected", func(t *testing.T) {
+		resp := parseAndCheckResponse(t, request("0x1"), http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+}
+
+// Test the gas_saved method.
+func TestHandleGasSaved(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"gas_saved","params":[]}`))
+
+	resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+	require.Nil(t, resp.Error)
+	require.Equal(t, "0xa410", resp.Result)
+}
+
+// Test the parsing of the eth_sendRawTransaction deadline option.
+func TestDeadlineOption(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	deadline := func(options interface{}) []interface{} {
+		return []interface{}{validTransactionRawHex, options}
+	}
+
+	t.Run("no deadline by default", func(t *testing.T) {
+		for _, params := range [][]interface{}{{validTransactionRawHex}, {validTransactionRawHex, "base64"}, deadline(map[string]interface{}{})} {
+			got, err := deadlineOption(params, now, 0)
+			require.NoError(t, err)
+			require.True(t, got.IsZero())
+		}
+	})
+
+	t.Run("a duration is relative to now", func(t *testing.T) {
+		got, err := deadlineOption(deadline(map[string]interface{}{"deadline": "10m"}), now, 0)
+		require.NoError(t, err)
+		require.Equal(t, now.Add(10*time.Minute), got)
+	})
+
+	t.Run("a time is absolute, after the base64 encoding", func(t *testing.T) {
+		got, err := deadlineOption([]interface{}{"", "base64", map[string]interface{}{"deadline": "2024-01-02T04:00:00Z"}}, now, 0)
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC), got)
+	})
+
+	t.Run("an invalid deadline is rejected", func(t *testing.T) {
+		_, err := deadlineOption(deadline(map[string]interface{}{"deadline": "2024-01-01T00:00:00Z"}), now, 0)
+		require.EqualError(t, err, "the deadline is in the past")
+		_, err = deadlineOption(deadline(map[string]interface{}{"deadline": "-1m"}), now, 0)
+		require.EqualError(t, err, "the deadline must be positive")
+		_, err = deadlineOption(deadline(map[string]interface{}{"deadline": 600}), now, 0)
+		require.EqualError(t, err, "the deadline is not a string")
+	})
+
+	t.Run("a deadline further than the max deadline is rejected", func(t *testing.T) {
+		_, err := deadlineOption(deadline(map[string]interface{}{"deadline": "2124-01-02T03:04:05Z"}), now, 720*time.Hour)
+		require.EqualError(t, err, "the deadline is more than 720h0m0s away")
+		_, err = deadlineOption(deadline(map[string]interface{}{"deadline": "721h"}), now, 720*time.Hour)
+		require.EqualError(t, err, "the deadline is more than 720h0m0s away")
+
+		got, err := deadlineOption(deadline(map[string]interface{}{"deadline": "720h"}), now, 720*time.Hour)
+		require.NoError(t, err)
+		require.Equal(t, now.Add(720*time.Hour), got)
+	})
+
+	t.Run("an invalid deadline is answered with an invalid params error", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}, maxDeadline: time.Hour}
+		for _, deadline := range []string{"2001-01-02T03:04:05Z", "2h"} {
+			request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s",{"deadline":"%s"}]}`, validTransactionRawHex, deadline)
+			rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+			resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+			require.Equal(t, -32602, resp.Error.Code, deadline)
+		}
+	})
+}
+
+// Test the eth_sendRawTransaction label option.
+func TestLabelOption(t *testing.T) {
+	t.Run("the label is stored with the transaction", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s",{"label":"payroll batch","deadline":"10m"}]}`, validTransactionRawHex)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, []string{"payroll batch"}, ethClient.labels)
+	})
+
+	t.Run("no label by default", func(t *testing.T) {
+		for _, params := range [][]interface{}{{validTransactionRawHex}, {validTransactionRawHex, map[string]interface{}{"deadline": "10m"}}} {
+			label, err := labelOption(params)
+			require.NoError(t, err)
+			require.Empty(t, label)
+		}
+	})
+
+	t.Run("an invalid label is rejected", func(t *testing.T) {
+		_, err := labelOption([]interface{}{validTransactionRawHex, map[string]interface{}{"label": 1}})
+		require.EqualError(t, err, "the label is not a string")
+
+		label, err := labelOption([]interface{}{validTransactionRawHex, map[string]interface{}{"label": strings.Repeat("é", 64)}})
+		require.NoError(t, err)
+		require.Equal(t, strings.Repeat("é", 64), label)
+		_, err = labelOption([]interface{}{validTransactionRawHex, map[string]interface{}{"label": strings.Repeat("a", 65)}})
+		require.EqualError(t, err, "the label is longer than 64 characters")
+
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":["%s",{"label":"%s"}]}`, validTransactionRawHex, strings.Repeat("a", 65))
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+		require.Empty(t, ethClient.stored)
+	})
+}
+
+// Test the gas_history method.
+func TestHandleGasHistory(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"gas_history","params":[]}`))
+
+	resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+	require.Nil(t, resp.Error)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"gasPrice": "0x14", "timestamp": "2024-01-02T03:04:05Z"},
+	}, resp.Result)
+}
+
+// Test the transactions_by_status method.
+func TestHandleTransactionsByStatus(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	t.Run("when the status is known, return the hashes of its transactions", func(t *testing.T) {
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"transactions_by_status","params":["STORED"]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, []interface{}{validTransactionHash}, resp.Result)
+	})
+
+	t.Run("when the status is unknown, return an invalid params error", func(t *testing.T) {
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"transactions_by_status","params":["PENDING"]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+		require.Equal(t, `unknown transaction status: "PENDING"`, resp.Error.Data)
+	})
+
+	t.Run("when the status is missing, return an invalid params error", func(t *testing.T) {
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"transactions_by_status","params":[]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+}
+
+// Test the next_nonce method.
+func TestHandleNextNonce(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	t.Run("return the next nonce of the sender", func(t *testing.T) {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"next_nonce","params":["%s"]}`, senderAddress)
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, "0x6", resp.Result)
+	})
+
+	t.Run("when the address is invalid, return an invalid params error", func(t *testing.T) {
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"next_nonce","params":["0x1234"]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+}
+
+// Test the cancelable_transactions method.
+func TestHandleCancelableTransactions(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	t.Run("return the cancelable transactions of the sender", func(t *testing.T) {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"cancelable_transactions","params":["%s"]}`, senderAddress)
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, []interface{}{validTransactionHash}, resp.Result)
+	})
+
+	t.Run("when the sender has none, return an empty list", func(t *testing.T) {
+		request := `{"jsonrpc":"2.0","id":1,"method":"cancelable_transactions","params":["0x0000000000000000000000000000000000000001"]}`
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, []interface{}{}, resp.Result)
+	})
+
+	t.Run("when the address is invalid, return an invalid params error", func(t *testing.T) {
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"cancelable_transactions","params":["0x1234"]}`))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+}
+
+func TestHandleGetTransactionStatuses(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+	unknownHash := "0x" + strings.Repeat("ab", 32)
+
+	t.Run("return the status of the known hashes and not_found for the others", func(t *testing.T) {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_statuses","params":[["%s","%s"]]}`, validTransactionHash, unknownHash)
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, map[string]interface{}{validTransactionHash: "STORED", unknownHash: "not_found"}, resp.Result)
+	})
+
+	t.Run("when a hash is invalid, return an invalid params error with its index", func(t *testing.T) {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_statuses","params":[["%s","0x1234"]]}`, validTransactionHash)
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+		require.Equal(t, "invalid params: hash 1", resp.Error.Message)
+	})
+
+	t.Run("when the hashes aren't a non-empty array, return an invalid params error", func(t *testing.T) {
+		for _, params := range []string{`[]`, `[[]]`, `["` + validTransactionHash + `"]`} {
+			rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"get_transaction_statuses","params":`+params+`}`))
+
+			resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+			require.Equal(t, -32602, resp.Error.Code, params)
+		}
+	})
+}
+
+// Test the eth_getTransactionByHash intercept.
+func TestHandleGetTransactionByHash(t *testing.T) {
+	t.Run("when the transaction is queued, return it with its status", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionByHash","params":["%s"]}`, validTransactionHash)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		result := resp.Result.(map[string]interface{})
+		require.Equal(t, "STORED", result["status"])
+		require.Equal(t, "0x007ab5199b6c57f7aa51bc3d0604a43505501a0c", strings.ToLower(result["from"].(string)))
+		require.Equal(t, "0x3019", result["nonce"])
+		require.Nil(t, result["blockHash"])
+		require.Nil(t, result["blockNumber"])
+		require.Zero(t, ethClient.proxied)
+	})
+
+	t.Run("when the transaction is unknown, proxy the request to the node", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionByHash","params":["%s"]}`, notFoundTransactionHash)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Equal(t, "0x1", resp.Result)
+		require.Equal(t, 1, ethClient.proxied)
+	})
+}
+
+// Test the subscriber_stats method.
+func TestHandleSubscriberStats(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}}
+
+	rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"subscriber_stats","params":[]}`))
+
+	resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+	require.Nil(t, resp.Error)
+	require.Equal(t, map[string]interface{}{
+		"subscribers": float64(2),
+		"hashes":      []interface{}{validTransactionHash},
+		"dropped":     float64(3),
+	}, resp.Result)
+}
+
+// Test the server_stats method.
+func TestHandleServerStats(t *testing.T) {
+	service := &EthService{EthClient: &mockEthService{}, startedAt: time.Now().Add(-time.Minute)}
+	serverStats := func() map[string]interface{} {
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"server_stats","params":[]}`))
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		return resp.Result.(map[string]interface{})
+	}
+
+	stats := serverStats()
+	require.Equal(t, float64(60), stats["uptimeSeconds"])
+	require.Equal(t, float64(1), stats["requests"])
+	require.Equal(t, map[string]interface{}{"STORED": float64(2)}, stats["queue"])
+	require.Equal(t, "0x3b9aca00", stats["gasPrice"])
+	require.Equal(t, float64(1), stats["upstreamErrors"])
+	require.Equal(t, float64(5), stats["checks"])
+
+	makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"status_transitions","params":[]}`))
+	require.Equal(t, float64(3), serverStats()["requests"])
+}
+
+// Test the send_raw_transactions method.
+func TestHandleSendRawTransactions(t *testing.T) {
+	t.Run("when all the transactions are valid, store them and return their hashes", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"send_raw_transactions","params":[["%s","%s"]]}`, validTransactionRawHex, existingTransactionRaw)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Nil(t, resp.Error)
+		require.Len(t, resp.Result, 2)
+		require.Equal(t, []string{validTransactionRawHex, existingTransactionRaw}, ethClient.stored)
+	})
+
+	t.Run("when one transaction is invalid, store none of them", func(t *testing.T) {
+		ethClient := &recordingEthService{}
+		service := &EthService{EthClient: ethClient}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"send_raw_transactions","params":[["%s","0xInvalid"]]}`, validTransactionRawHex)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+		require.Contains(t, resp.Error.Message, "transaction 1")
+		require.Equal(t, "failed to decode transaction data: encoding/hex: invalid byte: U+0049 'I'", resp.Error.Data)
+		require.Empty(t, ethClient.stored)
+	})
+
+	t.Run("when storing fails, return the error", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"send_raw_transactions","params":[["%s","%s"]]}`, validTransactionRawHex, existingTransactionRaw)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32000, resp.Error.Code)
+		require.Contains(t, resp.Error.Message, "already STORED")
+	})
+
+	t.Run("when the param isn't an array, return an error", func(t *testing.T) {
+		service := &EthService{EthClient: &mockEthService{}}
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"send_raw_transactions","params":["%s"]}`, validTransactionRawHex)
+
+		rr := makeRequest(t, service.handleRequest, "POST", "/", strings.NewReader(request))
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, float64(1), "2.0")
+		require.Equal(t, -32602, resp.Error.Code)
+	})
+}
+
+// Test panic recovery middleware.
+func TestRecoverPanic(t *testing.T) {
+
+	// Create a handler that panics
+	handler := func(w http.ResponseWriter, r *http.Request) {
 		panic("test panic")
 	}
 
-	handler = recoverPanic(handler)
+	handler = recoverPanic(handler, false)
 
 	req, _ := http.NewRequest("GET", "/", nil)
 	w := httptest.NewRecorder()
@@ -294,6 +1551,63 @@ func TestRecoverPanic(t *testing.T) {
 	require.Contains(t, w.Body.String(), "server error") 
 }
 
+// Test the stack trace of a panic is only returned in dev mode.
+func TestRecoverPanicDevMode(t *testing.T) {
+	panicking := func(w http.ResponseWriter, r *http.Request) {
+		panic("test panic")
+	}
+
+	t.Run("in dev mode, return the panic and its stack trace in the error data", func(t *testing.T) {
+		rr := makeRequest(t, recoverPanic(panicking, true), "POST", "/", nil)
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, nil, "2.0")
+		require.Equal(t, "server error", resp.Error.Message)
+		data, ok := resp.Error.Data.(map[string]interface{})
+		require.True(t, ok)
+		require.Equal(t, "test panic", data["panic"])
+		require.Contains(t, data["stack"], "runtime/debug.Stack")
+	})
+
+	t.Run("in production, keep the error generic", func(t *testing.T) {
+		rr := makeRequest(t, recoverPanic(panicking, false), "POST", "/", nil)
+
+		resp := parseAndCheckResponse(t, rr, http.StatusOK, nil, "2.0")
+		require.Equal(t, "server error", resp.Error.Message)
+		require.Nil(t, resp.Error.Data)
+		require.NotContains(t, rr.Body.String(), "goroutine")
+	})
+}
+
+// Test the panic log entry identifies the request.
+func TestRecoverPanicLog(t *testing.T) {
+	hook := test.NewGlobal()
+	defer hook.Reset()
+
+	handler := accessLog(recoverPanic(func(w http.ResponseWriter, r *http.Request) {
+		setAccessLogInfo(r.Context(), types.JSONRPCRequest{Method: "eth_sendRawTransaction", ID: 1})
+		panic("test panic")
+	}, false))
+
+	req := httptest.NewRequest("POST", "/passthrough", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	require.Contains(t, w.Body.String(), "server error")
+	require.NotContains(t, w.Body.String(), "passthrough")
+
+	var entry *log.Entry
+	for i := range hook.AllEntries() {
+		if hook.AllEntries()[i].Level == log.ErrorLevel {
+			entry = hook.AllEntries()[i]
+		}
+	}
+	require.NotNil(t, entry)
+	require.Contains(t, entry.Message, "test panic")
+	require.Equal(t, "POST", entry.Data["http_method"])
+	require.Equal(t, "/passthrough", entry.Data["path"])
+	require.Equal(t, "eth_sendRawTransaction", entry.Data["rpc_method"])
+}
+
 
 // Test helpers.
 
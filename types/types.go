@@ -1,6 +1,15 @@
 package types
 
-import "github.com/ethereum/go-ethereum/core/types"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
 
 // JSONRPCRequest defines the structure of an incoming JSON-RPC request.
 type JSONRPCRequest struct {
@@ -10,6 +19,34 @@ type JSONRPCRequest struct {
 	ID      interface{}    `json:"id"`
 }
 
+// UnmarshalJSON decodes the request, keeping a numeric id as a json.Number so that it's echoed back exactly,
+// whereas a float64 would lose the precision of the integers above 2^53.
+func (r *JSONRPCRequest) UnmarshalJSON(data []byte) error {
+	type request JSONRPCRequest
+	var decoded struct {
+		request
+		ID json.RawMessage `json:"id"`
+	}
+	err := json.Unmarshal(data, &decoded)
+	if err != nil {
+		return err
+	}
+	*r = JSONRPCRequest(decoded.request)
+	r.ID, err = DecodeID(decoded.ID)
+	return err
+}
+
+// DecodeID decodes a raw JSON-RPC id, numbers as json.Number. A missing id decodes to nil.
+func DecodeID(raw json.RawMessage) (interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var id interface{}
+	err := decoder.Decode(&id)
+	return id, err
+}
 
 // JSONRPCResponse defines the structure of a JSON-RPC response.
 type JSONRPCResponse struct {
@@ -23,6 +60,13 @@ type JSONRPCResponse struct {
 type JSONRPCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// Data holds additional details on the error, e.g. why a transaction couldn't be decoded.
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Error makes the errors returned by the node usable as Go errors, keeping their code for the callers relaying them.
+func (e *JSONRPCError) Error() string {
+	return e.Message
 }
 
 // TransactionStatus represents the current status of a transaction.
@@ -35,11 +79,23 @@ const (
 	SPEDUP
 	FAILED
 	BROADCASTED
+	// EXPIRED is the status of the transactions that stayed STORED longer than their time to live.
+	EXPIRED
 )
 
 // String method provides a string representation for the TransactionStatus enum.
 func (s TransactionStatus) String() string {
-	return [...]string{"STORED", "CANCELED", "SPEDUP","FAILED","BROADCASTED"}[s]
+	return [...]string{"STORED", "CANCELED", "SPEDUP","FAILED","BROADCASTED","EXPIRED"}[s]
+}
+
+// ParseTransactionStatus returns the status named name, ignoring case.
+func ParseTransactionStatus(name string) (TransactionStatus, error) {
+	for status := STORED; status <= EXPIRED; status++ {
+		if strings.EqualFold(status.String(), name) {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown transaction status: %q", name)
 }
 
 // Transaction struct extends the go-ethereum core Transaction type with application-specific fields.
@@ -47,5 +103,126 @@ type Transaction struct {
 	types.Transaction
 	Status TransactionStatus
 	RawHex string
+	// StoredAt is when the transaction was queued.
+	StoredAt time.Time
+	// Reason explains a FAILED status set by the server rather than by the node, e.g. "evicted".
+	Reason string
+	// BroadcastErrors counts the transient RPC errors returned by the node when broadcasting the transaction.
+	BroadcastErrors int
+	// InFlight is set while the transaction is being broadcast, so that a concurrent check doesn't send it twice.
+	InFlight bool
+	// ReplacesBroadcast is set on the speed-up of a BROADCASTED transaction, it's broadcast whatever the gas price.
+	ReplacesBroadcast bool
+	// EligibleTicks counts the consecutive checks the transaction was eligible at, when broadcasts must be confirmed.
+	EligibleTicks int
+	// Attempts lists the last attempts to broadcast the transaction, oldest first.
+	Attempts []BroadcastAttempt
+	// StoreGasPrice is the gas price last observed when the transaction was queued, 0 when none was observed yet.
+	StoreGasPrice float64
+	// Deadline, when set, is when the transaction is broadcast whatever the gas price, it's failed if it can't be.
+	Deadline time.Time
+	// ExpiresAt, when set, is when the transaction expires if it's still STORED.
+	ExpiresAt time.Time
+	// Label is a free text set by the client to organize its transactions, e.g. "payroll batch". It doesn't affect the broadcast.
+	Label string
 }
 
+// Outcomes of a broadcast attempt.
+const (
+	AttemptSent        = "sent"
+	AttemptRejected    = "rejected"
+	AttemptUnreachable = "unreachable"
+)
+
+// BroadcastAttempt is an attempt to send a transaction to the network: when, at which gas price and its outcome,
+// Error being the node's rejection or the failure to reach it.
+type BroadcastAttempt struct {
+	Time     time.Time    `json:"time"`
+	GasPrice *hexutil.Big `json:"gasPrice"`
+	Outcome  string       `json:"outcome"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// TransactionView is the representation of a stored transaction returned by the query methods.
+type TransactionView struct {
+	Hash   string `json:"hash"`
+	Status string `json:"status"`
+	RawHex string `json:"rawHex,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// SenderPosition is the 1-based position of a STORED transaction among the STORED transactions of its sender, by nonce.
+	SenderPosition int `json:"senderPosition,omitempty"`
+	// QueueDepth is the number of STORED transactions.
+	QueueDepth int `json:"queueDepth"`
+	// From is the sender address, empty when it can't be recovered.
+	From      string          `json:"from,omitempty"`
+	Nonce     *hexutil.Uint64 `json:"nonce,omitempty"`
+	GasFeeCap *hexutil.Big    `json:"gasFeeCap,omitempty"`
+	GasTipCap *hexutil.Big    `json:"gasTipCap,omitempty"`
+	Label     string          `json:"label,omitempty"`
+	// ExpiresAt is when the transaction expires if it's still STORED, omitted when it never does.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// Attempts is only returned by the verbose queries.
+	Attempts []BroadcastAttempt `json:"attempts,omitempty"`
+}
+
+// QueuedTransaction is the result of eth_sendRawTransaction when the gas information is requested.
+// GasPrice is the last observed network gas price, null until the monitor observed one.
+type QueuedTransaction struct {
+	Hash     string       `json:"hash"`
+	GasPrice *hexutil.Big `json:"gasPrice"`
+	GasCap   *hexutil.Big `json:"gasCap"`
+}
+
+// StatusChange is the event emitted when a stored transaction moves to another status.
+type StatusChange struct {
+	Hash string    `json:"hash"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+	Time time.Time `json:"time"`
+}
+
+// GasStats aggregates the gas caps of the STORED transactions, the caps are null when none is stored.
+// GasPrice is the last observed network gas price, null until the monitor observed one.
+type GasStats struct {
+	Stored    int          `json:"stored"`
+	MinGasCap *hexutil.Big `json:"minGasCap"`
+	MaxGasCap *hexutil.Big `json:"maxGasCap"`
+	AvgGasCap *hexutil.Big `json:"avgGasCap"`
+	GasPrice  *hexutil.Big `json:"gasPrice"`
+}
+
+// SubscriberStats describes the active status change subscribers.
+// Hashes lists the transactions watched by the subscribers of a single transaction, Dropped the events they missed.
+type SubscriberStats struct {
+	Subscribers int      `json:"subscribers"`
+	Hashes      []string `json:"hashes"`
+	Dropped     uint64   `json:"dropped"`
+}
+
+// ServerStats is an operational snapshot of the server.
+// Queue counts the stored transactions by status, Checks the gas price checks and UpstreamErrors the failed requests to the node.
+type ServerStats struct {
+	UptimeSeconds  int64          `json:"uptimeSeconds"`
+	Requests       uint64         `json:"requests"`
+	Queue          map[string]int `json:"queue"`
+	GasPrice       *hexutil.Big   `json:"gasPrice"`
+	UpstreamErrors uint64         `json:"upstreamErrors"`
+	Checks         uint64         `json:"checks"`
+}
+
+// GasObservation is a gas price observed by the monitor and when it was observed.
+type GasObservation struct {
+	GasPrice  *hexutil.Big `json:"gasPrice"`
+	Timestamp time.Time    `json:"timestamp"`
+}
+
+// TestBroadcastResult is the outcome of sending a stored transaction to the node for diagnostics.
+// NodeError tells a rejection by the node from a failure to reach it, Permanent whether the monitor would fail the transaction for it.
+type TestBroadcastResult struct {
+	Sent      bool   `json:"sent"`
+	Error     string `json:"error,omitempty"`
+	NodeError bool   `json:"nodeError,omitempty"`
+	Permanent bool   `json:"permanent,omitempty"`
+	// Code is the JSON-RPC error code of the node error, when a single node answered it.
+	Code int `json:"code,omitempty"`
+}
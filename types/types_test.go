@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,4 +13,41 @@ func TestTransactionStatusString(t *testing.T) {
 	assert.Equal(t, "SPEDUP", SPEDUP.String(), "SPEDUP constant should match")
 	assert.Equal(t, "FAILED", FAILED.String(), "FAILED constant should match")
 	assert.Equal(t, "BROADCASTED", BROADCASTED.String(), "BROADCASTED constant should match")
+	assert.Equal(t, "EXPIRED", EXPIRED.String(), "EXPIRED constant should match")
+}
+
+func TestParseTransactionStatus(t *testing.T) {
+	status, err := ParseTransactionStatus("BROADCASTED")
+	assert.NoError(t, err)
+	assert.Equal(t, BROADCASTED, status, "status names should be parsed")
+
+	status, err = ParseTransactionStatus("stored")
+	assert.NoError(t, err)
+	assert.Equal(t, STORED, status, "status names should be parsed regardless of case")
+
+	_, err = ParseTransactionStatus("PENDING")
+	assert.EqualError(t, err, `unknown transaction status: "PENDING"`)
+}
+
+func TestJSONRPCRequestID(t *testing.T) {
+	var req JSONRPCRequest
+	err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","method":"eth_chainId","params":[1],"id":18446744073709551615}`), &req)
+	assert.NoError(t, err)
+	assert.Equal(t, json.Number("18446744073709551615"), req.ID)
+	assert.Equal(t, "eth_chainId", req.Method)
+	assert.Equal(t, []interface{}{float64(1)}, req.Params, "params should still be decoded as usual")
+
+	encoded, err := json.Marshal(JSONRPCResponse{Jsonrpc: "2.0", ID: req.ID})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"jsonrpc":"2.0","id":18446744073709551615}`, string(encoded))
+	assert.Contains(t, string(encoded), `"id":18446744073709551615`)
+
+	for raw, id := range map[string]interface{}{`"abc"`: "abc", `null`: nil} {
+		err := json.Unmarshal([]byte(`{"jsonrpc":"2.0","method":"eth_chainId","id":`+raw+`}`), &req)
+		assert.NoError(t, err)
+		assert.Equal(t, id, req.ID)
+	}
+	err = json.Unmarshal([]byte(`{"jsonrpc":"2.0","method":"eth_chainId"}`), &req)
+	assert.NoError(t, err)
+	assert.Nil(t, req.ID)
 }